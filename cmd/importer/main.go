@@ -1,21 +1,31 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"sync"
 
+	"braip/internal/audit"
 	"braip/internal/database"
+	"braip/internal/database/migrations"
+	"braip/internal/models"
+	"braip/internal/money"
+	"braip/internal/repository"
+	"braip/internal/textutil"
+	"braip/internal/validation"
 )
 
 // Estrutura do produto conforme a API externa
 type ExternalProduct struct {
 	ID          int     `json:"id"`
 	Title       string  `json:"title"`
-	Price       float64 `json:"price"` 		// Esse valor será convertido em centavos antes de ir para o database
+	Price       json.Number `json:"price"` 	// Valor decimal exato em dólares; convertido em centavos antes de ir para o database
 	Description string  `json:"description"`
 	Category    string  `json:"category"`
 	Image       string  `json:"image"`
@@ -23,22 +33,47 @@ type ExternalProduct struct {
 
 var dbMutex sync.Mutex // Mutex para sincronizar o acesso ao banco de dados
 
+// importerActor é o autor registrado na auditoria dos produtos importados
+var importerActor = audit.Actor{Name: "fakestoreapi", Source: audit.SourceImporter}
+
+// Políticas para os produtos cujo nome já é usado por outro produto (--on-duplicate-name)
+const (
+	duplicateSkip      = "skip"      // Ignora o produto importado
+	duplicateRename    = "rename"    // Importa com um sufixo no nome: "Camisa (2)", "Camisa (3)", ...
+	duplicateOverwrite = "overwrite" // Substitui os dados do produto existente pelos importados
+)
+
 func main() {
 	// Definir a flag --id para importar um produto por ID
 	idFlag := flag.Int("id", 0, "ID do produto a ser importado")
+	databaseURL := flag.String("database-url", os.Getenv("DATABASE_URL"), "DSN do banco: caminho do arquivo SQLite ou postgres://...")
+	onDuplicateName := flag.String("on-duplicate-name", duplicateSkip, "o que fazer com produtos de nome já usado: skip, rename ou overwrite")
 	flag.Parse()
 
+	switch *onDuplicateName {
+	case duplicateSkip, duplicateRename, duplicateOverwrite:
+	default:
+		log.Fatalf("Valor inválido em --on-duplicate-name: %q (use skip, rename ou overwrite)", *onDuplicateName)
+	}
+
+	// Conectar ao banco de dados
+	store, closeStore, err := openStore(*databaseURL)
+	if err != nil {
+		log.Fatalf("Erro ao conectar ao banco de dados: %v", err)
+	}
+	defer closeStore()
+
 	if *idFlag != 0 {
 		// Se o ID for fornecido, importar um produto específico
 		fmt.Printf("Importando produto de ID: %d...\n", *idFlag)
-		err := ImportProductByID(*idFlag)
+		err := ImportProductByID(store, *idFlag, *onDuplicateName)
 		if err != nil {
 			log.Fatalf("Erro ao importar produto: %v", err)
 		}
 	} else {
 		// Se não for fornecido ID, importar todos os produtos
 		fmt.Println("Importando todos os produtos...")
-		err := ImportProducts()
+		err := ImportProducts(store, *onDuplicateName)
 		if err != nil {
 			log.Fatalf("Erro ao importar produtos: %v", err)
 		}
@@ -47,8 +82,129 @@ func main() {
 	fmt.Println("Importação concluída com sucesso!")
 }
 
+// openStore conecta ao banco indicado pela DSN e cria o repositório de produtos
+func openStore(dsn string) (repository.ProductStore, func(), error) {
+	if dsn == "" {
+		dsn = db.DefaultDSN
+	}
+	driver := db.DriverFromDSN(dsn)
+
+	conn, err := db.OpenDB(dsn, db.DefaultPoolConfig())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Garantir que o esquema está atualizado antes de importar
+	migrator, err := migrations.New(conn, driver)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if _, err := migrator.Up(); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	store, err := repository.NewSQLProductRepository(conn, driver)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return store, func() { conn.Close() }, nil
+}
+
+// externalCurrency é a moeda dos preços da fakestoreapi
+const externalCurrency = "USD"
+
+// toProduct converte o produto da API externa para o modelo interno, mantendo o ID externo.
+// O preço é convertido para centavos sem passar por float (109.95 -> 10995); preços
+// com mais de duas casas decimais são recusados em vez de arredondados.
+func toProduct(apiProduct ExternalProduct) (models.Product, error) {
+	price, err := money.ParseAmount(apiProduct.Price.String(), externalCurrency)
+	if err != nil {
+		return models.Product{}, validation.Errors{{Field: "price", Code: validation.CodeDecimal, Message: err.Error()}}
+	}
+
+	return models.Product{
+		ID:          apiProduct.ID,
+		Name:        apiProduct.Title,
+		Price:       price,
+		Currency:    externalCurrency,
+		Description: apiProduct.Description,
+		Category:    apiProduct.Category,
+		ImageURL:    apiProduct.Image,
+	}, nil
+}
+
+// importProduct valida o produto externo com as mesmas regras da API e o insere no banco,
+// retornando a mensagem com o resultado. Produtos inválidos não são inseridos e o erro
+// lista os campos com problema; nomes já usados seguem a política onDuplicateName.
+func importProduct(store repository.ProductStore, apiProduct ExternalProduct, onDuplicateName string) (string, error) {
+	ctx := audit.WithActor(context.Background(), importerActor)
+	product, err := toProduct(apiProduct)
+	if err != nil {
+		return "", err
+	}
+
+	if err := validation.Product(product); err != nil {
+		return "", err
+	}
+
+	inserted, err := store.ImportProduct(ctx, product)
+	var nameInUse *repository.NameInUseError
+	if errors.As(err, &nameInUse) {
+		return importDuplicateName(ctx, store, product, nameInUse.ProductID, onDuplicateName)
+	}
+	if err != nil {
+		return "", err
+	}
+	if !inserted {
+		return fmt.Sprintf("Produto %d já existe, ignorado.", product.ID), nil
+	}
+	return fmt.Sprintf("Produto %d importado com sucesso!", product.ID), nil
+}
+
+// importDuplicateName aplica a política onDuplicateName ao produto cujo nome já é usado
+// pelo produto existingID
+func importDuplicateName(ctx context.Context, store repository.ProductStore, product models.Product, existingID int, onDuplicateName string) (string, error) {
+	switch onDuplicateName {
+	case duplicateRename:
+		name := textutil.Clean(product.Name)
+		for n := 2; ; n++ {
+			product.Name = fmt.Sprintf("%s (%d)", name, n)
+			other, err := store.ProductIDByName(ctx, product.Name, product.ID)
+			if err != nil {
+				return "", err
+			}
+			if other == 0 {
+				break
+			}
+		}
+		if err := validation.Product(product); err != nil {
+			return "", err
+		}
+		inserted, err := store.ImportProduct(ctx, product)
+		if err != nil {
+			return "", err
+		}
+		if !inserted {
+			return fmt.Sprintf("Produto %d já existe, ignorado.", product.ID), nil
+		}
+		return fmt.Sprintf("Produto %d importado como %q: o nome já é usado pelo produto %d.", product.ID, product.Name, existingID), nil
+
+	case duplicateOverwrite:
+		if err := store.UpdateProduct(ctx, existingID, product); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Produto %d gravado sobre o produto %d, que já usava o nome.", product.ID, existingID), nil
+
+	default:
+		return fmt.Sprintf("Produto %d ignorado: o nome já é usado pelo produto %d.", product.ID, existingID), nil
+	}
+}
+
 // ImportProducts busca todos os produtos da API externa e insere no banco
-func ImportProducts() error {
+func ImportProducts(store repository.ProductStore, onDuplicateName string) error {
 	resp, err := http.Get("https://fakestoreapi.com/products")
 	if err != nil {
 		return fmt.Errorf("erro ao buscar produtos da API externa: %v", err)
@@ -65,13 +221,6 @@ func ImportProducts() error {
 		return fmt.Errorf("erro ao decodificar resposta da API: %v", err)
 	}
 
-	// Conectar ao banco de dados
-	db, err := db.OpenDB()
-	if err != nil {
-		return fmt.Errorf("erro ao conectar ao banco de dados: %v", err)
-	}
-	defer db.Close()
-
 	var wg sync.WaitGroup
 
 	// Inserir cada produto no banco de dados em paralelo usando goroutines
@@ -83,19 +232,12 @@ func ImportProducts() error {
 			dbMutex.Lock()
 			defer dbMutex.Unlock()
 
-			// Converter o preço de reais para centavos
-			priceInCents := int(apiProduct.Price * 100)
-
-			_, err := db.Exec(`
-				INSERT INTO products (id, name, price, description, category, image_url)
-				VALUES (?, ?, ?, ?, ?, ?)
-				ON CONFLICT(id) DO NOTHING;`, // Usa ON CONFLICT para evitar duplicação
-				apiProduct.ID, apiProduct.Title, priceInCents, apiProduct.Description, apiProduct.Category, apiProduct.Image,
-			)
+			// Produtos com ID já existente são ignorados para evitar duplicação
+			result, err := importProduct(store, apiProduct, onDuplicateName)
 			if err != nil {
 				log.Printf("Erro ao inserir produto %d: %v", apiProduct.ID, err)
 			} else {
-				fmt.Printf("Produto %d importado com sucesso!\n", apiProduct.ID)
+				fmt.Println(result)
 			}
 		}(apiProduct)
 	}
@@ -108,7 +250,7 @@ func ImportProducts() error {
 }
 
 // ImportProductByID busca um produto específico da API externa e insere no banco
-func ImportProductByID(id int) error {
+func ImportProductByID(store repository.ProductStore, id int, onDuplicateName string) error {
 	// Buscar produto pela API externa
 	url := fmt.Sprintf("https://fakestoreapi.com/products/%d", id)
 	resp, err := http.Get(url)
@@ -127,30 +269,15 @@ func ImportProductByID(id int) error {
 		return fmt.Errorf("erro ao decodificar resposta da API: %v", err)
 	}
 
-	// Conectar ao banco de dados
-	db, err := db.OpenDB()
-	if err != nil {
-		return fmt.Errorf("erro ao conectar ao banco de dados: %v", err)
-	}
-	defer db.Close()
-
-	// Converter o preço de reais para centavos
-	priceInCents := int(apiProduct.Price * 100)
-
 	// Inserir o produto no banco
 	dbMutex.Lock()
 	defer dbMutex.Unlock()
 
-	_, err = db.Exec(`
-		INSERT INTO products (id, name, price, description, category, image_url)
-		VALUES (?, ?, ?, ?, ?, ?)
-		ON CONFLICT(id) DO NOTHING;`, // Usa ON CONFLICT para evitar duplicação
-		apiProduct.ID, apiProduct.Title, priceInCents, apiProduct.Description, apiProduct.Category, apiProduct.Image,
-	)
+	result, err := importProduct(store, apiProduct, onDuplicateName)
 	if err != nil {
 		return fmt.Errorf("erro ao inserir produto %d no banco de dados: %v", apiProduct.ID, err)
 	}
 
-	fmt.Printf("Produto %d importado com sucesso!\n", apiProduct.ID)
+	fmt.Println(result)
 	return nil
 }
\ No newline at end of file
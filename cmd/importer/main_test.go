@@ -0,0 +1,137 @@
+package main
+
+import (
+	"braip/internal/audit"
+	"braip/internal/models"
+	"braip/internal/repository"
+	"braip/internal/validation"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestToProduct(t *testing.T) {
+	tests := []struct {
+		price string
+		want  int
+	}{
+		{"109.95", 10995},
+		{"22.3", 2230},
+		{"55.99", 5599},
+		{"0.1", 10},
+		{"695", 69500},
+	}
+	for _, tt := range tests {
+		external := ExternalProduct{
+			ID:          1,
+			Title:       "Fjallraven - Foldsack No. 1 Backpack",
+			Price:       json.Number(tt.price),
+			Description: "Your perfect pack for everyday use",
+			Category:    "men's clothing",
+			Image:       "https://fakestoreapi.com/img/81fPKd-2AYL._AC_SL1500_.jpg",
+		}
+		product, err := toProduct(external)
+		if err != nil {
+			t.Errorf("toProduct(price %s): erro inesperado: %v", tt.price, err)
+			continue
+		}
+		if product.Price != tt.want || product.Currency != externalCurrency {
+			t.Errorf("toProduct(price %s): preço %d %s; esperado %d %s", tt.price, product.Price, product.Currency, tt.want, externalCurrency)
+		}
+		if product.ID != external.ID || product.Name != external.Title || product.Description != external.Description ||
+			product.Category != external.Category || product.ImageURL != external.Image {
+			t.Errorf("toProduct(price %s) = %+v; campos diferentes do produto externo", tt.price, product)
+		}
+	}
+}
+
+// Os preços vêm do JSON da API sem passar por float: o json.Number guarda o texto original
+func TestToProductFromJSON(t *testing.T) {
+	var external ExternalProduct
+	body := `{"id": 2, "title": "Mens Casual Premium Slim Fit T-Shirts", "price": 22.3, "category": "men's clothing"}`
+	if err := json.NewDecoder(strings.NewReader(body)).Decode(&external); err != nil {
+		t.Fatal(err)
+	}
+	product, err := toProduct(external)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if product.Price != 2230 {
+		t.Errorf("preço = %d; esperado 2230", product.Price)
+	}
+}
+
+func TestToProductRejectsPrice(t *testing.T) {
+	for _, price := range []string{"109.955", "0.001", "abc", "", "0x10", "1_000", " 9.5 "} {
+		_, err := toProduct(ExternalProduct{ID: 1, Title: "Produto", Price: json.Number(price)})
+		var errs validation.Errors
+		if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Field != "price" || errs[0].Code != validation.CodeDecimal {
+			t.Errorf("toProduct(price %q): erro = %v; esperado erro de validação %s em price", price, err, validation.CodeDecimal)
+		}
+	}
+}
+
+func TestImportDuplicateName(t *testing.T) {
+	external := ExternalProduct{ID: 10, Title: "camisa  básica", Price: "22.3", Description: "Importada", Category: "roupas"}
+	tests := []struct {
+		policy       string
+		wantImport   string // Nome do produto importado (vazio se não foi importado)
+		wantExisting models.Product
+	}{
+		{duplicateSkip, "", models.Product{Name: "Camisa Básica", Price: 1990, Currency: "BRL"}},
+		{duplicateRename, "camisa básica (2)", models.Product{Name: "Camisa Básica", Price: 1990, Currency: "BRL"}},
+		{duplicateOverwrite, "", models.Product{Name: "camisa  básica", Price: 2230, Currency: "USD"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			ctx := context.Background()
+			store := repository.NewMemoryProductRepository()
+			existingID, err := store.CreateProduct(ctx, models.Product{Name: "Camisa Básica", Price: 1990, Currency: "BRL", Description: "Da loja", Category: "roupas"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := importProduct(store, external, tt.policy); err != nil {
+				t.Fatalf("importProduct: erro inesperado: %v", err)
+			}
+
+			imported, err := store.GetProductByID(ctx, external.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantImport == "" {
+				if imported != nil {
+					t.Errorf("produto importado: %+v; esperado não importado", imported)
+				}
+			} else if imported == nil || imported.Name != tt.wantImport {
+				t.Errorf("produto importado: %+v; esperado o nome %q", imported, tt.wantImport)
+			}
+
+			existing, err := store.GetProductByID(ctx, int(existingID))
+			if err != nil || existing == nil {
+				t.Fatalf("produto existente: (%v, %v)", existing, err)
+			}
+			if existing.Name != tt.wantExisting.Name || existing.Price != tt.wantExisting.Price || existing.Currency != tt.wantExisting.Currency {
+				t.Errorf("produto existente = %+v; esperado %+v", existing, tt.wantExisting)
+			}
+		})
+	}
+}
+
+// As alterações do importador ficam na auditoria com a origem importer
+func TestImportAudit(t *testing.T) {
+	store := repository.NewMemoryProductRepository()
+	external := ExternalProduct{ID: 3, Title: "Mochila", Price: "109.95", Description: "Mochila", Category: "bags"}
+	if _, err := importProduct(store, external, duplicateSkip); err != nil {
+		t.Fatal(err)
+	}
+	page, err := store.GetAuditEntries(context.Background(), repository.AuditOptions{ProductID: external.ID, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 1 || page.Entries[0].Source != audit.SourceImporter || page.Entries[0].Actor != importerActor.Name {
+		t.Errorf("auditoria = %+v; esperado a criação pelo importador", page.Entries)
+	}
+}
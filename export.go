@@ -0,0 +1,107 @@
+package main
+
+import (
+	"braip/internal/database"
+	"braip/internal/export"
+	"braip/internal/models"
+	"braip/internal/repository"
+	"braip/internal/services"
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// runExport implementa o subcomando "export", que grava os produtos num arquivo
+// CSV, NDJSON ou XLSX, com a mesma seleção de GET /products/export:
+//
+//	export [--database-url=...] [--format=csv] [--output=products.csv] [--query="category=electronics&sort=-price"]
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	databaseURL := fs.String("database-url", envOrDefault("DATABASE_URL", db.DefaultDSN), "DSN do banco: caminho do arquivo SQLite ou postgres://...")
+	format := fs.String("format", export.FormatCSV, "Formato do arquivo: csv, ndjson ou xlsx")
+	output := fs.String("output", "", `Arquivo de saída (padrão: products.<formato>; "-" grava na saída padrão)`)
+	query := fs.String("query", "", "Seleção dos produtos, nos parâmetros de GET /products/export (q, filtros, sort e fields)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), `Uso: export [--database-url=...] [--format=csv] [--output=products.csv] [--query="category=electronics&sort=-price"]`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if err := export.CheckFormat(*format); err != nil {
+		return err
+	}
+	values, err := url.ParseQuery(strings.TrimPrefix(*query, "?"))
+	if err != nil {
+		return fmt.Errorf("--query inválida: %v", err)
+	}
+	opts, columns, err := export.ParseQuery(values)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		*output = "products." + *format
+	}
+
+	conn, err := db.OpenDB(*databaseURL, db.PoolConfig{MaxOpenConns: 1, MaxIdleConns: 1})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	repo, err := repository.NewSQLProductRepository(conn, db.DriverFromDSN(*databaseURL))
+	if err != nil {
+		return err
+	}
+	service := services.NewProductService(repo)
+
+	if *output == "-" {
+		_, err := exportTo(os.Stdout, service, *format, opts, columns)
+		return err
+	}
+
+	// O arquivo é gravado com outro nome e renomeado no fim, para que quem o lê
+	// (uma carga noturna, por exemplo) nunca encontre uma exportação pela metade
+	tmp, err := os.CreateTemp(filepath.Dir(*output), "."+filepath.Base(*output)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	count, err := exportTo(tmp, service, *format, opts, columns)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), *output); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d produto(s) exportado(s) para %s\n", count, *output)
+	return nil
+}
+
+// exportTo grava os produtos selecionados no formato informado, retornando quantos foram gravados
+func exportTo(f *os.File, service *services.ProductService, format string, opts repository.ExportOptions, columns []string) (int, error) {
+	writer, err := export.NewWriter(f, format, columns)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = service.ExportProducts(context.Background(), opts, func(p *models.Product) error {
+		count++
+		return writer.Write(p)
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, writer.Close()
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"braip/internal/repository"
+	"braip/internal/services"
+	"braip/internal/suggest"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// newTestRouter monta as rotas e os middlewares de main.go sobre o store informado
+// (nil usa um repositório em memória vazio)
+func newTestRouter(t *testing.T, store repository.ProductStore) http.Handler {
+	t.Helper()
+	if store == nil {
+		store = repository.NewMemoryProductRepository()
+	}
+	indexed, err := repository.NewSuggestIndexedStore(context.Background(), store, suggest.NewIndex(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	productHandler := NewProductHandler(services.NewProductService(indexed), services.NewPriceConverter(nil))
+	categoryHandler := NewCategoryHandler(services.NewCategoryService(indexed))
+	auditHandler := NewAuditHandler(services.NewAuditService(indexed))
+	idempotency := NewIdempotencyHandler(services.NewIdempotencyService(indexed, services.DefaultIdempotencyTTL))
+
+	r := mux.NewRouter()
+	r.NotFoundHandler = NotFoundHandler()
+	r.MethodNotAllowedHandler = MethodNotAllowedHandler()
+	r.HandleFunc("/products/search", productHandler.SearchProducts).Methods("GET")
+	r.HandleFunc("/products/suggest", productHandler.SuggestProducts).Methods("GET")
+	r.HandleFunc("/products/trash", productHandler.GetTrash).Methods("GET")
+	r.HandleFunc("/products/price-drops", productHandler.GetPriceDrops).Methods("GET")
+	r.HandleFunc("/products/export", productHandler.ExportProducts).Methods("GET")
+	r.HandleFunc("/products/bulk", productHandler.CreateProductsBulk).Methods("POST")
+	r.HandleFunc("/products/bulk", productHandler.PatchProductsBulk).Methods("PATCH")
+	r.HandleFunc("/products/bulk", productHandler.DeleteProductsBulk).Methods("DELETE")
+	r.HandleFunc("/products/{id}", productHandler.GetProductByID).Methods("GET")
+	r.HandleFunc("/products/search/categoryandname", productHandler.SearchProductsByNameAndCategory).Methods("GET")
+	r.HandleFunc("/products/search/category", productHandler.SearchProductsByCategory).Methods("GET")
+	r.HandleFunc("/products/search/image", productHandler.SearchProductsByImage).Methods("GET")
+	r.HandleFunc("/products", idempotency.Wrap(productHandler.CreateProduct)).Methods("POST")
+	r.HandleFunc("/products", productHandler.GetProducts).Methods("GET")
+	r.HandleFunc("/products/{id}", productHandler.UpdateProduct).Methods("PUT")
+	r.HandleFunc("/products/{id}", productHandler.PatchProduct).Methods("PATCH")
+	r.HandleFunc("/products/{id}", productHandler.DeleteProduct).Methods("DELETE")
+	r.HandleFunc("/products/{id}/restore", productHandler.RestoreProduct).Methods("POST")
+	r.HandleFunc("/products/{id}/history", auditHandler.GetProductHistory).Methods("GET")
+	r.HandleFunc("/products/{id}/prices", productHandler.GetPriceHistory).Methods("GET")
+	r.HandleFunc("/audit", auditHandler.GetAudit).Methods("GET")
+	r.HandleFunc("/categories", categoryHandler.GetCategories).Methods("GET")
+	r.HandleFunc("/categories", categoryHandler.CreateCategory).Methods("POST")
+	r.HandleFunc("/categories/{id}", categoryHandler.GetCategoryByID).Methods("GET")
+	r.HandleFunc("/categories/{id}", categoryHandler.UpdateCategory).Methods("PUT")
+	r.HandleFunc("/categories/{id}", categoryHandler.DeleteCategory).Methods("DELETE")
+
+	return RequestIDMiddleware(ActorMiddleware(ResponseVersionMiddleware(r)))
+}
+
+// request executa a requisição no roteador. header são pares nome, valor; com corpo
+// e sem Content-Type informado, o corpo é enviado como application/json.
+func request(t *testing.T, h http.Handler, method, target, body string, header ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeBody lê o corpo JSON da resposta em v
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("corpo da resposta não é JSON (%v): %s", err, rec.Body.String())
+	}
+}
+
+// expectStatus falha o teste se a resposta não tiver o status esperado
+func expectStatus(t *testing.T, rec *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("status = %d; esperado %d. Corpo: %s", rec.Code, status, rec.Body.String())
+	}
+}
+
+// testProduct é o produto como aparece nas respostas (versão 1)
+type testProduct struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Price       int    `json:"price"`
+	Currency    string `json:"currency"`
+	Description string `json:"description"`
+	CategoryID  int    `json:"category_id"`
+	Category    string `json:"category"`
+	ImageURL    string `json:"image_url"`
+	Version     int    `json:"version"`
+}
+
+// createTestProduct cria um produto pela API e o retorna como foi gravado
+func createTestProduct(t *testing.T, h http.Handler, name string, price int) testProduct {
+	t.Helper()
+	body := `{"name": ` + strconv.Quote(name) + `, "price": ` + strconv.Itoa(price) +
+		`, "description": "Produto de teste", "category": "Testes", "image_url": "https://example.com/p.png"}`
+	rec := request(t, h, "POST", "/products", body)
+	expectStatus(t, rec, http.StatusCreated)
+	var p testProduct
+	decodeBody(t, rec, &p)
+	return p
+}
+
+// getTestProduct lê o produto pela API
+func getTestProduct(t *testing.T, h http.Handler, id int) testProduct {
+	t.Helper()
+	rec := request(t, h, "GET", "/products/"+strconv.Itoa(id), "")
+	expectStatus(t, rec, http.StatusOK)
+	var p testProduct
+	decodeBody(t, rec, &p)
+	return p
+}
+
+// problemBody é o corpo de um erro application/problem+json
+type problemBody struct {
+	Type       string `json:"type"`
+	Title      string `json:"title"`
+	Status     int    `json:"status"`
+	Detail     string `json:"detail"`
+	Instance   string `json:"instance"`
+	TraceID    string `json:"trace_id"`
+	ExistingID int    `json:"existing_id"`
+	Errors     []struct {
+		Field string `json:"field"`
+		Code  string `json:"code"`
+	} `json:"errors"`
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"braip/internal/apperror"
+	"braip/internal/audit"
+	"braip/internal/filter"
+	"braip/internal/models"
+	"braip/internal/repository"
+	"braip/internal/services"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// AuditHandler expõe os endpoints HTTP da auditoria de produtos
+type AuditHandler struct {
+	service *services.AuditService
+}
+
+// NewAuditHandler cria os handlers da auditoria usando o serviço informado
+func NewAuditHandler(service *services.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// GetAudit retorna os registros da auditoria, do mais recente para o mais antigo.
+// Aceita os filtros product_id, actor, source, action, since e until e a paginação limit/offset.
+func (h *AuditHandler) GetAudit(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseAuditOptions(r)
+	if err != nil {
+		writeError(w, r, badRequest(err))
+		return
+	}
+
+	page, err := h.service.GetAuditEntries(r.Context(), opts)
+	writeAuditPage(w, r, page, err)
+}
+
+// GetProductHistory retorna o histórico de alterações de um produto, do mais recente
+// para o mais antigo. Aceita os mesmos filtros de GetAudit, exceto product_id.
+func (h *AuditHandler) GetProductHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, errInvalidID)
+		return
+	}
+
+	opts, err := parseAuditOptions(r)
+	if err != nil {
+		writeError(w, r, badRequest(err))
+		return
+	}
+
+	page, err := h.service.GetProductHistory(r.Context(), id, opts)
+	writeAuditPage(w, r, page, err)
+}
+
+// writeAuditPage escreve uma página da auditoria, com o total no cabeçalho X-Total-Count
+func writeAuditPage(w http.ResponseWriter, r *http.Request, page *repository.AuditPage, err error) {
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	entries := page.Entries
+	if entries == nil {
+		entries = []models.AuditEntry{} // Página vazia é serializada como [] e não como null
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// parseAuditOptions lê os filtros e a paginação da consulta à auditoria
+func parseAuditOptions(r *http.Request) (repository.AuditOptions, error) {
+	query := r.URL.Query()
+	opts := repository.AuditOptions{
+		Actor:  query.Get("actor"),
+		Source: query.Get("source"),
+		Action: query.Get("action"),
+	}
+
+	var err error
+	if opts.Limit, opts.Offset, err = parseLimitOffset(query); err != nil {
+		return opts, err
+	}
+
+	if v := query.Get("product_id"); v != "" {
+		if opts.ProductID, err = strconv.Atoi(v); err != nil || opts.ProductID <= 0 {
+			return opts, fmt.Errorf("parâmetro 'product_id' deve ser um número maior que zero")
+		}
+	}
+
+	switch opts.Source {
+	case "", audit.SourceAPI, audit.SourceImporter, audit.SourceCLI, audit.SourceSystem:
+	default:
+		return opts, fmt.Errorf("parâmetro 'source' inválido: %q", opts.Source)
+	}
+
+	switch opts.Action {
+	case "", audit.ActionCreate, audit.ActionUpdate, audit.ActionDelete, audit.ActionRestore, audit.ActionPurge:
+	default:
+		return opts, fmt.Errorf("parâmetro 'action' inválido: %q", opts.Action)
+	}
+
+	for _, param := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &opts.Since}, {"until", &opts.Until}} {
+		if v := query.Get(param.name); v != "" {
+			if *param.dst, err = filter.ParseTime(v); err != nil {
+				return opts, fmt.Errorf("parâmetro '%s' deve ser uma data (2006-01-02) ou data e hora RFC 3339", param.name)
+			}
+		}
+	}
+
+	return opts, nil
+}
+
+// validActor limita os nomes de autor aceitos no cabeçalho X-Actor
+var validActor = regexp.MustCompile(`^[\p{L}\p{N} ._@-]{1,64}$`)
+
+// ActorMiddleware identifica o autor das alterações feitas pela requisição
+// pelo cabeçalho X-Actor (sem ele, "anonymous"), para que sejam registradas
+// na auditoria junto com o ID da requisição. Deve rodar depois de RequestIDMiddleware.
+func ActorMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.Header.Get("X-Actor")
+		if name == "" {
+			name = "anonymous"
+		} else if !validActor.MatchString(name) {
+			writeError(w, r, apperror.BadRequest("cabeçalho X-Actor inválido"))
+			return
+		}
+
+		ctx := audit.WithActor(r.Context(), audit.Actor{Name: name, Source: audit.SourceAPI, RequestID: RequestID(r.Context())})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
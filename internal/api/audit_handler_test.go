@@ -0,0 +1,91 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+// testAuditEntry é o registro da auditoria como aparece nas respostas
+type testAuditEntry struct {
+	ProductID int          `json:"product_id"`
+	Action    string       `json:"action"`
+	Actor     string       `json:"actor"`
+	Source    string       `json:"source"`
+	RequestID string       `json:"request_id"`
+	Before    *testProduct `json:"before"`
+	After     *testProduct `json:"after"`
+}
+
+func TestProductHistory(t *testing.T) {
+	h := newTestRouter(t, nil)
+	p := createTestProduct(t, h, "Boné", 1990)
+	other := createTestProduct(t, h, "Meia", 990)
+	target := "/products/" + strconv.Itoa(p.ID)
+
+	rec := request(t, h, "PATCH", target, `{"price": 2990}`, "Content-Type", mergePatchType, "X-Actor", "maria@loja", "X-Request-ID", "req-42")
+	expectStatus(t, rec, http.StatusOK)
+	expectStatus(t, request(t, h, "DELETE", target, "", "X-Actor", "maria@loja"), http.StatusNoContent)
+
+	// Escritas recusadas não entram na auditoria
+	expectStatus(t, request(t, h, "PATCH", "/products/"+strconv.Itoa(other.ID), `{"price": 0}`, "Content-Type", mergePatchType), http.StatusBadRequest)
+	expectStatus(t, request(t, h, "POST", "/products", `{}`, "X-Actor", "maria@loja"), http.StatusBadRequest)
+
+	// O histórico continua disponível com o produto na lixeira
+	rec = request(t, h, "GET", target+"/history", "")
+	expectStatus(t, rec, http.StatusOK)
+	var history []testAuditEntry
+	decodeBody(t, rec, &history)
+	if len(history) != 3 || rec.Header().Get("X-Total-Count") != "3" {
+		t.Fatalf("histórico: %+v", history)
+	}
+	deleted, updated, created := history[0], history[1], history[2]
+	if deleted.Action != "delete" || updated.Action != "update" || created.Action != "create" {
+		t.Errorf("ações: %s, %s, %s", deleted.Action, updated.Action, created.Action)
+	}
+	if created.Actor != "anonymous" || created.Source != "api" || created.Before != nil || created.After == nil || created.After.Price != 1990 {
+		t.Errorf("criação: %+v", created)
+	}
+	if updated.Actor != "maria@loja" || updated.RequestID != "req-42" || updated.Before.Price != 1990 || updated.After.Price != 2990 {
+		t.Errorf("atualização: %+v", updated)
+	}
+
+	tests := []struct {
+		target string
+		status int
+		count  int
+	}{
+		{"/audit", http.StatusOK, 4},
+		{"/audit?actor=maria@loja", http.StatusOK, 2},
+		{"/audit?action=create", http.StatusOK, 2},
+		{"/audit?product_id=" + strconv.Itoa(other.ID), http.StatusOK, 1},
+		{"/audit?source=importer", http.StatusOK, 0},
+		{"/audit?limit=1&offset=1", http.StatusOK, 1},
+		{"/audit?since=2100-01-01", http.StatusOK, 0},
+		{target + "/history?action=delete", http.StatusOK, 1},
+		{"/audit?source=web", http.StatusBadRequest, 0},
+		{"/audit?action=edit", http.StatusBadRequest, 0},
+		{"/audit?product_id=0", http.StatusBadRequest, 0},
+		{"/audit?until=ontem", http.StatusBadRequest, 0},
+		{"/products/999/history", http.StatusNotFound, 0},
+	}
+	for _, tt := range tests {
+		rec := request(t, h, "GET", tt.target, "")
+		if rec.Code != tt.status {
+			t.Errorf("GET %s: status %d; esperado %d", tt.target, rec.Code, tt.status)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		var entries []testAuditEntry
+		decodeBody(t, rec, &entries)
+		if len(entries) != tt.count {
+			t.Errorf("GET %s: %d registros; esperado %d", tt.target, len(entries), tt.count)
+		}
+	}
+
+	// O autor precisa ser um nome válido
+	rec = request(t, h, "POST", "/products", `{"name": "Cinto", "price": 4990, "description": "Cinto", "category": "Testes"}`, "X-Actor", "<script>")
+	expectStatus(t, rec, http.StatusBadRequest)
+}
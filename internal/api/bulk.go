@@ -0,0 +1,267 @@
+package api
+
+import (
+	"braip/internal/apperror"
+	"braip/internal/models"
+	"braip/internal/services"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// ndjsonType é o formato com um objeto JSON por linha, aceito nos lotes além do array JSON
+const ndjsonType = "application/x-ndjson"
+
+// maxNDJSONLine é o tamanho máximo de uma linha do NDJSON
+const maxNDJSONLine = 1 << 20
+
+// bulkItemResult é o resultado de um item no relatório do lote
+type bulkItemResult struct {
+	Index   int              `json:"index"`
+	Status  int              `json:"status"`
+	ID      int              `json:"id,omitempty"`
+	Product *productResponse `json:"product,omitempty"`
+	Error   *Problem         `json:"error,omitempty"`
+}
+
+// bulkReport é a resposta das operações em lote. succeeded conta os itens gravados e
+// failed os que falharam por erro próprio; num lote atômico desfeito, os demais itens
+// têm status 424 e não entram em nenhuma das contagens.
+type bulkReport struct {
+	Mode      services.BulkMode `json:"mode"`
+	Committed bool              `json:"committed"`
+	Total     int               `json:"total"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []bulkItemResult  `json:"results"`
+}
+
+// bulkDeleteItem é um item do DELETE em lote na forma de objeto
+type bulkDeleteItem struct {
+	ID      int `json:"id"`
+	Version int `json:"version"`
+}
+
+// CreateProductsBulk cria vários produtos numa única transação (POST /products/bulk).
+// Cada item tem o mesmo formato do corpo do POST /products.
+func (h *ProductHandler) CreateProductsBulk(w http.ResponseWriter, r *http.Request) {
+	mode, items, strict, err := decodeBulkRequest(w, r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	ops := make([]services.BulkOperation, len(items))
+	for i, raw := range items {
+		product, err := decodeProduct(bytes.NewReader(raw), strict)
+		ops[i] = func(ctx context.Context, s *services.ProductService) (*models.Product, error) {
+			if err != nil {
+				return nil, err
+			}
+			id, err := s.CreateProduct(ctx, product)
+			if err != nil {
+				return nil, err
+			}
+			return s.GetProductByID(ctx, int(id))
+		}
+	}
+	h.runBulk(w, r, mode, ops, nil, http.StatusCreated)
+}
+
+// PatchProductsBulk altera vários produtos numa única transação (PATCH /products/bulk).
+// Cada item é um JSON Merge Patch com o id do produto e, opcionalmente, a versão
+// esperada em version (como o If-Match do PATCH de um produto).
+func (h *ProductHandler) PatchProductsBulk(w http.ResponseWriter, r *http.Request) {
+	mode, items, strict, err := decodeBulkRequest(w, r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	ops := make([]services.BulkOperation, len(items))
+	ids := make([]int, len(items))
+	for i, raw := range items {
+		var patch models.ProductPatch
+		var doc map[string]json.RawMessage
+		err := json.Unmarshal(raw, &doc)
+		if err != nil {
+			err = invalidJSON(err)
+		} else if ids[i], patch.Version, err = bulkTarget(doc); err == nil {
+			version := patch.Version
+			patch, err = productPatchFromFields(doc, strict)
+			patch.Version = version
+		}
+		id := ids[i]
+		ops[i] = func(ctx context.Context, s *services.ProductService) (*models.Product, error) {
+			if err != nil {
+				return nil, err
+			}
+			return s.PatchProduct(ctx, id, patch)
+		}
+	}
+	h.runBulk(w, r, mode, ops, ids, http.StatusOK)
+}
+
+// DeleteProductsBulk move vários produtos para a lixeira numa única transação
+// (DELETE /products/bulk). Cada item é o ID do produto ou um objeto com id e,
+// opcionalmente, a versão esperada em version.
+func (h *ProductHandler) DeleteProductsBulk(w http.ResponseWriter, r *http.Request) {
+	mode, items, _, err := decodeBulkRequest(w, r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	ops := make([]services.BulkOperation, len(items))
+	ids := make([]int, len(items))
+	for i, raw := range items {
+		var item bulkDeleteItem
+		err := json.Unmarshal(raw, &item.ID)
+		if err != nil {
+			var doc map[string]json.RawMessage
+			if err = json.Unmarshal(raw, &doc); err != nil {
+				err = apperror.BadRequest("item inválido: use o ID do produto ou um objeto com id e version")
+			} else {
+				item.ID, item.Version, err = bulkTarget(doc)
+			}
+		} else if item.ID <= 0 {
+			err = errInvalidID
+		}
+		ids[i] = item.ID
+		ops[i] = func(ctx context.Context, s *services.ProductService) (*models.Product, error) {
+			if err != nil {
+				return nil, err
+			}
+			return nil, s.DeleteProduct(ctx, item.ID, item.Version)
+		}
+	}
+	h.runBulk(w, r, mode, ops, ids, http.StatusNoContent)
+}
+
+// runBulk executa as operações e responde com o relatório do lote: 200 se todos os
+// itens foram gravados, 207 se o lote best-effort foi gravado com falhas e 422 se o
+// lote atômico foi desfeito. ids são os IDs dos itens, quando conhecidos antes da execução.
+func (h *ProductHandler) runBulk(w http.ResponseWriter, r *http.Request, mode services.BulkMode, ops []services.BulkOperation, ids []int, okStatus int) {
+	results, committed, err := h.service.RunBulk(r.Context(), ops, mode)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	version := responseVersion(r.Context())
+	report := bulkReport{Mode: mode, Committed: committed, Total: len(results), Results: make([]bulkItemResult, len(results))}
+	for i, result := range results {
+		item := bulkItemResult{Index: i, Status: okStatus}
+		if ids != nil {
+			item.ID = ids[i]
+		}
+		if result.Err != nil {
+			problem := newProblem(r, result.Err)
+			item.Status, item.Error = problem.Status, &problem
+			if apperror.KindOf(result.Err) != apperror.KindFailedDependency {
+				report.Failed++
+			}
+		} else {
+			report.Succeeded++
+			if result.Product != nil {
+				product := newProductResponse(result.Product, version)
+				item.ID, item.Product = result.Product.ID, &product
+			}
+		}
+		report.Results[i] = item
+	}
+
+	status := http.StatusOK
+	switch {
+	case !committed:
+		status = http.StatusUnprocessableEntity
+	case report.Failed > 0:
+		status = http.StatusMultiStatus
+	}
+	setProductContentType(w, r)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
+// decodeBulkRequest lê o modo (?mode=atomic|best-effort, padrão atomic) e os itens do lote.
+// Os itens são decodificados um a um pelos handlers, para que um item inválido
+// falhe sozinho; strict informa se o cliente pediu o modo estrito.
+func decodeBulkRequest(w http.ResponseWriter, r *http.Request) (mode services.BulkMode, items []json.RawMessage, strict bool, err error) {
+	mode = services.BulkMode(r.URL.Query().Get("mode"))
+	switch mode {
+	case "":
+		mode = services.BulkAtomic
+	case services.BulkAtomic, services.BulkBestEffort:
+	default:
+		return "", nil, false, apperror.BadRequest("modo inválido: " + string(mode) + " (use atomic ou best-effort)")
+	}
+
+	items, err = decodeBulkItems(r)
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	strict = strictHandling(r)
+	if strict {
+		w.Header().Set("Preference-Applied", "handling=strict")
+	}
+	return mode, items, strict, nil
+}
+
+// decodeBulkItems lê os itens do corpo: um array JSON (application/json) ou um item
+// por linha (application/x-ndjson), ignorando as linhas em branco
+func decodeBulkItems(r *http.Request) ([]json.RawMessage, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var items []json.RawMessage
+	switch mediaType {
+	case "", "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+			return nil, invalidJSON(err)
+		}
+
+	case ndjsonType, "application/ndjson":
+		scanner := bufio.NewScanner(r.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLine)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			items = append(items, json.RawMessage(bytes.Clone(line)))
+			if len(items) > services.MaxBulkItems {
+				break
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, invalidJSON(err)
+		}
+
+	default:
+		return nil, apperror.UnsupportedMediaType("use Content-Type application/json (array) ou " + ndjsonType)
+	}
+
+	if len(items) == 0 {
+		return nil, apperror.BadRequest("o lote não tem itens")
+	}
+	if len(items) > services.MaxBulkItems {
+		return nil, apperror.BadRequest("o lote tem mais de " + strconv.Itoa(services.MaxBulkItems) + " itens")
+	}
+	return items, nil
+}
+
+// bulkTarget lê o id (obrigatório) e a versão esperada (opcional) de um item do lote
+func bulkTarget(doc map[string]json.RawMessage) (id int, version int, err error) {
+	if err := json.Unmarshal(doc["id"], &id); err != nil || id <= 0 {
+		return 0, 0, apperror.BadRequest("informe o id do produto no item")
+	}
+	if raw, ok := doc["version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil || version < 0 {
+			return id, 0, apperror.BadRequest("valor inválido no campo version")
+		}
+	}
+	return id, version, nil
+}
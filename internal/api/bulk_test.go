@@ -0,0 +1,187 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testBulkReport é o relatório das operações em lote
+type testBulkReport struct {
+	Mode      string `json:"mode"`
+	Committed bool   `json:"committed"`
+	Total     int    `json:"total"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	Results   []struct {
+		Index   int          `json:"index"`
+		Status  int          `json:"status"`
+		ID      int          `json:"id"`
+		Product *testProduct `json:"product"`
+		Error   *problemBody `json:"error"`
+	} `json:"results"`
+}
+
+// statuses retorna o status de cada item do relatório, como "[201 409]"
+func (r testBulkReport) statuses() string {
+	statuses := make([]int, len(r.Results))
+	for i, item := range r.Results {
+		statuses[i] = item.Status
+	}
+	return fmt.Sprint(statuses)
+}
+
+// bulkRequest envia o lote e lê o relatório, conferindo o status da resposta
+func bulkRequest(t *testing.T, h http.Handler, method, target, body string, status int, header ...string) testBulkReport {
+	t.Helper()
+	rec := request(t, h, method, target, body, header...)
+	expectStatus(t, rec, status)
+	var report testBulkReport
+	decodeBody(t, rec, &report)
+	return report
+}
+
+// productNames retorna os nomes dos produtos fora da lixeira
+func productNames(t *testing.T, h http.Handler) string {
+	t.Helper()
+	var products []testProduct
+	if rec := request(t, h, "GET", "/products?sort=id", ""); rec.Code == http.StatusOK {
+		decodeBody(t, rec, &products)
+	}
+	names := make([]string, len(products))
+	for i, p := range products {
+		names[i] = p.Name
+	}
+	return strings.Join(names, ",")
+}
+
+// O segundo item repete o nome do primeiro e o terceiro não tem preço
+const bulkWithFailures = `[
+	{"name": "Boné", "price": 1990, "description": "Boné", "category": "Testes"},
+	{"name": "BONÉ", "price": 1990, "description": "Boné", "category": "Testes"},
+	{"name": "Meia", "description": "Meia", "category": "Testes"},
+	{"name": "Cinto", "price": 4990, "description": "Cinto", "category": "Testes"}
+]`
+
+// No modo atômico (padrão), qualquer falha desfaz o lote inteiro
+func TestBulkCreateAtomic(t *testing.T) {
+	h := newTestRouter(t, nil)
+	report := bulkRequest(t, h, "POST", "/products/bulk", bulkWithFailures, http.StatusUnprocessableEntity)
+	if report.Mode != "atomic" || report.Committed || report.Total != 4 || report.Succeeded != 0 || report.Failed != 2 {
+		t.Errorf("relatório: %+v", report)
+	}
+	if got := report.statuses(); got != "[424 409 400 424]" {
+		t.Errorf("status dos itens: %s", got)
+	}
+	if e := report.Results[1].Error; e == nil || e.Type != "/problems/conflict" {
+		t.Errorf("erro do item repetido: %+v", e)
+	}
+	if e := report.Results[2].Error; e == nil || len(e.Errors) != 1 || e.Errors[0].Field != "price" {
+		t.Errorf("erro do item inválido: %+v", e)
+	}
+	if names := productNames(t, h); names != "" {
+		t.Errorf("produtos gravados num lote desfeito: %s", names)
+	}
+
+	// Sem falhas, todos os itens são gravados juntos
+	body := `[{"name": "Boné", "price": 1990, "description": "Boné", "category": "Testes"},
+		{"name": "Meia", "price": 990, "description": "Meia", "category": "Testes", "image": "https://example.com/m.png"}]`
+	report = bulkRequest(t, h, "POST", "/products/bulk?mode=atomic", body, http.StatusOK)
+	if !report.Committed || report.Succeeded != 2 || report.statuses() != "[201 201]" {
+		t.Fatalf("relatório: %+v", report)
+	}
+	for _, item := range report.Results {
+		if item.Product == nil || item.ID != item.Product.ID || getTestProduct(t, h, item.ID) != *item.Product {
+			t.Errorf("item %d: %+v", item.Index, item.Product)
+		}
+	}
+	if report.Results[1].Product.ImageURL != "https://example.com/m.png" {
+		t.Errorf("imagem do item: %+v", report.Results[1].Product)
+	}
+}
+
+// No modo best-effort, os itens válidos são gravados mesmo com falhas em outros
+func TestBulkCreateBestEffort(t *testing.T) {
+	h := newTestRouter(t, nil)
+	report := bulkRequest(t, h, "POST", "/products/bulk?mode=best-effort", bulkWithFailures, http.StatusMultiStatus)
+	if !report.Committed || report.Succeeded != 2 || report.Failed != 2 || report.statuses() != "[201 409 400 201]" {
+		t.Errorf("relatório: %+v", report)
+	}
+	if names := productNames(t, h); names != "Boné,Cinto" {
+		t.Errorf("produtos gravados: %s", names)
+	}
+
+	// O mesmo lote em NDJSON, com linhas em branco
+	ndjson := `{"name": "Luva", "price": 2990, "description": "Luva", "category": "Testes"}
+
+{"name": "luva", "price": 2990, "description": "Luva", "category": "Testes"}
+{"name":
+`
+	report = bulkRequest(t, h, "POST", "/products/bulk?mode=best-effort", ndjson, http.StatusMultiStatus, "Content-Type", ndjsonType)
+	if report.Total != 3 || report.statuses() != "[201 409 400]" {
+		t.Errorf("relatório do NDJSON: %+v", report)
+	}
+}
+
+func TestBulkPatchAndDelete(t *testing.T) {
+	h := newTestRouter(t, nil)
+	bone := createTestProduct(t, h, "Boné", 1990)
+	meia := createTestProduct(t, h, "Meia", 990)
+	id := func(p testProduct) string { return strconv.Itoa(p.ID) }
+
+	// A versão antiga do segundo item desfaz o lote atômico
+	body := `[{"id": ` + id(bone) + `, "price": 2990}, {"id": ` + id(meia) + `, "version": 7, "price": 1490}]`
+	report := bulkRequest(t, h, "PATCH", "/products/bulk", body, http.StatusUnprocessableEntity)
+	if report.statuses() != "[424 412]" || report.Results[1].ID != meia.ID {
+		t.Errorf("relatório: %+v", report)
+	}
+	if got := getTestProduct(t, h, bone.ID); got.Price != 1990 {
+		t.Errorf("produto alterado num lote desfeito: %+v", got)
+	}
+
+	body = `[{"id": ` + id(bone) + `, "price": 2990}, {"id": ` + id(meia) + `, "version": 1, "price": 1490}, {"id": 999, "price": 1}, {"price": 1}]`
+	report = bulkRequest(t, h, "PATCH", "/products/bulk?mode=best-effort", body, http.StatusMultiStatus)
+	if report.statuses() != "[200 200 404 400]" || report.Results[0].Product.Price != 2990 || report.Results[1].Product.Version != 2 {
+		t.Errorf("relatório: %+v", report)
+	}
+
+	// Remoção pelo ID ou por objeto com id e versão
+	body = `[` + id(bone) + `, {"id": ` + id(meia) + `, "version": 2}, 999, "abc"]`
+	report = bulkRequest(t, h, "DELETE", "/products/bulk?mode=best-effort", body, http.StatusMultiStatus)
+	if report.statuses() != "[204 204 404 400]" || report.Succeeded != 2 {
+		t.Errorf("relatório: %+v", report)
+	}
+	if names := productNames(t, h); names != "" {
+		t.Errorf("produtos fora da lixeira: %s", names)
+	}
+}
+
+func TestBulkRejected(t *testing.T) {
+	h := newTestRouter(t, nil)
+	tooMany := "[" + strings.TrimSuffix(strings.Repeat(`{"name": "x"},`, 1001), ",") + "]"
+	tests := []struct {
+		name, target, body, contentType string
+		status                          int
+	}{
+		{"modo inválido", "/products/bulk?mode=parcial", `[{}]`, "application/json", http.StatusBadRequest},
+		{"lote vazio", "/products/bulk", `[]`, "application/json", http.StatusBadRequest},
+		{"não é array", "/products/bulk", `{"name": "Boné"}`, "application/json", http.StatusBadRequest},
+		{"itens demais", "/products/bulk", tooMany, "application/json", http.StatusBadRequest},
+		{"formato", "/products/bulk", `name,price`, "text/csv", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		rec := request(t, h, "POST", tt.target, tt.body, "Content-Type", tt.contentType)
+		if rec.Code != tt.status {
+			t.Errorf("%s: status %d; esperado %d", tt.name, rec.Code, tt.status)
+		}
+	}
+
+	// No modo estrito, o item com campo desconhecido falha sozinho
+	body := `[{"name": "Boné", "price": 1990, "description": "Boné", "category": "Testes", "cor": "azul"}]`
+	report := bulkRequest(t, h, "POST", "/products/bulk", body, http.StatusUnprocessableEntity, "Prefer", "handling=strict")
+	if report.statuses() != "[400]" || report.Results[0].Error.Detail != "campo desconhecido: cor" {
+		t.Errorf("relatório: %+v", report)
+	}
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"braip/internal/models"
+	"braip/internal/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// CategoryHandler expõe os endpoints HTTP de categorias
+type CategoryHandler struct {
+	service *services.CategoryService
+}
+
+// NewCategoryHandler cria os handlers de categorias usando o serviço informado
+func NewCategoryHandler(service *services.CategoryService) *CategoryHandler {
+	return &CategoryHandler{service: service}
+}
+
+// GetCategories retorna todas as categorias
+func (h *CategoryHandler) GetCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.service.GetCategories(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if categories == nil {
+		categories = []models.Category{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(categories)
+}
+
+// GetCategoryByID retorna uma categoria pelo ID
+func (h *CategoryHandler) GetCategoryByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, errInvalidID)
+		return
+	}
+
+	category, err := h.service.GetCategoryByID(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(category)
+}
+
+// CreateCategory cria uma nova categoria. O slug é gerado a partir do nome.
+func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var category models.Category
+	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
+		writeError(w, r, invalidJSON(err))
+		return
+	}
+
+	id, err := h.service.CreateCategory(r.Context(), category)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	created, err := h.service.GetCategoryByID(r.Context(), int(id))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// UpdateCategory atualiza o nome e a categoria pai de uma categoria
+func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, errInvalidID)
+		return
+	}
+
+	var category models.Category
+	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
+		writeError(w, r, invalidJSON(err))
+		return
+	}
+
+	if err := h.service.UpdateCategory(r.Context(), id, category); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	updated, err := h.service.GetCategoryByID(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeleteCategory remove uma categoria. Categorias com produtos ou subcategorias não podem ser removidas.
+func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, errInvalidID)
+		return
+	}
+
+	if err := h.service.DeleteCategory(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+// testCategory é a categoria como aparece nas respostas
+type testCategory struct {
+	ID       int    `json:"id"`
+	Slug     string `json:"slug"`
+	Name     string `json:"name"`
+	ParentID *int   `json:"parent_id"`
+}
+
+func TestCategoryEndpoints(t *testing.T) {
+	h := newTestRouter(t, nil)
+
+	rec := request(t, h, "POST", "/categories", `{"name": "Eletrônicos  e Games"}`)
+	expectStatus(t, rec, http.StatusCreated)
+	var parent testCategory
+	decodeBody(t, rec, &parent)
+	if parent.Slug != "eletronicos-e-games" || parent.Name != "Eletrônicos e Games" {
+		t.Errorf("categoria criada: %+v", parent)
+	}
+
+	rec = request(t, h, "POST", "/categories", `{"name": "Consoles", "parent_id": `+strconv.Itoa(parent.ID)+`}`)
+	expectStatus(t, rec, http.StatusCreated)
+	var child testCategory
+	decodeBody(t, rec, &child)
+
+	rec = request(t, h, "PUT", "/categories/"+strconv.Itoa(child.ID), `{"name": "Videogames", "parent_id": `+strconv.Itoa(parent.ID)+`}`)
+	expectStatus(t, rec, http.StatusOK)
+	var renamed testCategory
+	decodeBody(t, rec, &renamed)
+	if renamed.Slug != "videogames" || renamed.ParentID == nil || *renamed.ParentID != parent.ID {
+		t.Errorf("categoria atualizada: %+v", renamed)
+	}
+
+	// Os produtos informam a categoria pelo nome, e ela é a mesma pelo slug
+	rec = request(t, h, "POST", "/products", `{"name": "Console", "price": 199900, "description": "Console", "category": "VIDEOGAMES"}`)
+	expectStatus(t, rec, http.StatusCreated)
+	var product testProduct
+	decodeBody(t, rec, &product)
+	if product.CategoryID != child.ID || product.Category != "Videogames" {
+		t.Errorf("categoria do produto: %d (%s); esperado %d", product.CategoryID, product.Category, child.ID)
+	}
+
+	var categories []testCategory
+	rec = request(t, h, "GET", "/categories", "")
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &categories)
+	if len(categories) != 2 {
+		t.Errorf("GET /categories: %+v", categories)
+	}
+
+	tests := []struct {
+		method, target, body string
+		status               int
+	}{
+		{"GET", "/categories/999", "", http.StatusNotFound},
+		{"GET", "/categories/abc", "", http.StatusBadRequest},
+		{"POST", "/categories", `{"name": "eletronicos e games"}`, http.StatusConflict},
+		{"POST", "/categories", `{"name": ""}`, http.StatusBadRequest},
+		{"POST", "/categories", `{"name": "Outra", "parent_id": 999}`, http.StatusBadRequest},
+		{"POST", "/categories", `{"name":`, http.StatusBadRequest},
+		{"PUT", "/categories/" + strconv.Itoa(parent.ID), `{"name": "Eletrônicos e Games", "parent_id": ` + strconv.Itoa(child.ID) + `}`, http.StatusBadRequest},
+		{"PUT", "/categories/999", `{"name": "Outra"}`, http.StatusNotFound},
+		// Em uso por produtos ou subcategorias
+		{"DELETE", "/categories/" + strconv.Itoa(parent.ID), "", http.StatusConflict},
+		{"DELETE", "/categories/" + strconv.Itoa(child.ID), "", http.StatusConflict},
+		{"DELETE", "/categories/999", "", http.StatusNotFound},
+		// No corpo do produto, a categoria inexistente é um erro de validação do campo
+		{"POST", "/products", `{"name": "Jogo", "price": 19990, "description": "Jogo", "category_id": 999}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := request(t, h, tt.method, tt.target, tt.body)
+		if rec.Code != tt.status {
+			t.Errorf("%s %s %s: status %d; esperado %d. Corpo: %s", tt.method, tt.target, tt.body, rec.Code, tt.status, rec.Body.String())
+		}
+	}
+
+	rec = request(t, h, "POST", "/categories", `{"name": "Vazia"}`)
+	expectStatus(t, rec, http.StatusCreated)
+	var empty testCategory
+	decodeBody(t, rec, &empty)
+	expectStatus(t, request(t, h, "DELETE", "/categories/"+strconv.Itoa(empty.ID), ""), http.StatusNoContent)
+	expectStatus(t, request(t, h, "GET", "/categories/"+strconv.Itoa(empty.ID), ""), http.StatusNotFound)
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"braip/internal/apperror"
+	"braip/internal/models"
+	"braip/internal/money"
+	"braip/internal/validation"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// productRequest é o corpo aceito na criação (POST) e na substituição (PUT) de produtos.
+// A imagem pode vir em image_url ou em image, o nome usado na especificação do desafio;
+// se os dois forem enviados, vale image_url (image só é usado quando image_url falta ou é null).
+type productRequest struct {
+	Name        string          `json:"name"`
+	Price       json.RawMessage `json:"price"` // Lido por parsePrice, em unidades menores da moeda
+	Currency    string          `json:"currency"`
+	Description string          `json:"description"`
+	CategoryID  int             `json:"category_id"`
+	Category    string          `json:"category"`
+	ImageURL    *string         `json:"image_url"`
+	Image       *string         `json:"image"`
+
+	// Campos somente leitura das respostas: aceitos e ignorados, para que o produto
+	// lido possa ser reenviado no PUT mesmo no modo estrito
+	ID         json.RawMessage `json:"id"`
+	Version    json.RawMessage `json:"version"`
+	CreatedAt  json.RawMessage `json:"created_at"`
+	UpdatedAt  json.RawMessage `json:"updated_at"`
+	DeletedAt  json.RawMessage `json:"deleted_at"`
+	Conversion json.RawMessage `json:"conversion"`
+}
+
+// readOnlyFields são os campos das respostas que o cliente não altera (ignorados na escrita)
+var readOnlyFields = map[string]bool{
+	"id": true, "version": true, "created_at": true, "updated_at": true, "deleted_at": true, "conversion": true,
+}
+
+// product converte o corpo da requisição no produto a gravar
+func (req productRequest) product() (models.Product, error) {
+	price, err := parsePrice(req.Price)
+	if err != nil {
+		return models.Product{}, err
+	}
+	product := models.Product{
+		Name:        req.Name,
+		Price:       price,
+		Currency:    req.Currency,
+		Description: req.Description,
+		CategoryID:  req.CategoryID,
+		Category:    req.Category,
+	}
+	if req.ImageURL != nil {
+		product.ImageURL = *req.ImageURL
+	} else if req.Image != nil {
+		product.ImageURL = *req.Image
+	}
+	return product, nil
+}
+
+// parsePrice lê o preço do corpo, em unidades menores da moeda (centavos), com o
+// mesmo conversor decimal do importador e dos filtros: 1990 e 1.99e3 são aceitos,
+// mas 19.90 é recusado em vez de arredondado. Ausente ou null vale zero.
+func parsePrice(raw json.RawMessage) (int, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return 0, nil
+	}
+	// Textos ("1990"), com as aspas, não são números válidos e também são recusados
+	price, err := money.ParseMinor(string(raw))
+	if err != nil {
+		return 0, validation.Errors{{Field: "price", Code: validation.CodeDecimal, Message: err.Error()}}
+	}
+	return price, nil
+}
+
+// decodeProductRequest lê o corpo do POST ou do PUT. No modo estrito
+// (Prefer: handling=strict, RFC 7240), campos desconhecidos são recusados;
+// sem ele, são ignorados.
+func decodeProductRequest(w http.ResponseWriter, r *http.Request) (models.Product, error) {
+	strict := strictHandling(r)
+	if strict {
+		w.Header().Set("Preference-Applied", "handling=strict")
+	}
+	return decodeProduct(r.Body, strict)
+}
+
+// decodeProduct lê um productRequest; no modo estrito, recusa campos desconhecidos
+func decodeProduct(body io.Reader, strict bool) (models.Product, error) {
+	decoder := json.NewDecoder(body)
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+
+	var req productRequest
+	if err := decoder.Decode(&req); err != nil {
+		return models.Product{}, decodeError(err)
+	}
+	return req.product()
+}
+
+// decodeError converte um erro de leitura do corpo, dando uma mensagem própria
+// aos campos recusados pelo modo estrito
+func decodeError(err error) error {
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return apperror.BadRequest("campo desconhecido: " + strings.Trim(field, `"`))
+	}
+	return invalidJSON(err)
+}
+
+// strictHandling informa se o cliente pediu o modo estrito (Prefer: handling=strict)
+func strictHandling(r *http.Request) bool {
+	for _, header := range r.Header.Values("Prefer") {
+		for _, preference := range strings.Split(header, ",") {
+			token, _, _ := strings.Cut(preference, ";")
+			name, value, _ := strings.Cut(strings.TrimSpace(token), "=")
+			if strings.EqualFold(name, "handling") && strings.EqualFold(strings.Trim(value, `"`), "strict") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Versões do formato das respostas de produtos, escolhidas pelo parâmetro version
+// do Accept (Accept: application/json; version=2). Sem o parâmetro, vale a versão 1.
+const (
+	responseV1 = 1 // Formato original: a imagem em image_url
+	responseV2 = 2 // Nomes da especificação do desafio: a imagem em image
+
+	latestResponseVersion = responseV2
+)
+
+// productResponse é um produto como aparece nas respostas da API.
+// Apenas um dos campos de imagem é preenchido, conforme a versão pedida.
+type productResponse struct {
+	ID          int                     `json:"id"`
+	Name        string                  `json:"name"`
+	Price       int                     `json:"price"`
+	Currency    string                  `json:"currency"`
+	Description string                  `json:"description"`
+	CategoryID  int                     `json:"category_id"`
+	Category    string                  `json:"category"`
+	ImageURL    *string                 `json:"image_url,omitempty"` // Versão 1
+	Image       *string                 `json:"image,omitempty"`     // Versão 2
+	Version     int                     `json:"version"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+	DeletedAt   *time.Time              `json:"deleted_at,omitempty"`
+	Conversion  *models.PriceConversion `json:"conversion,omitempty"`
+}
+
+// newProductResponse monta a resposta do produto no formato da versão informada
+func newProductResponse(p *models.Product, version int) productResponse {
+	res := productResponse{
+		ID:          p.ID,
+		Name:        p.Name,
+		Price:       p.Price,
+		Currency:    p.Currency,
+		Description: p.Description,
+		CategoryID:  p.CategoryID,
+		Category:    p.Category,
+		Version:     p.Version,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+		DeletedAt:   p.DeletedAt,
+		Conversion:  p.Conversion,
+	}
+	image := p.ImageURL
+	if version >= responseV2 {
+		res.Image = &image
+	} else {
+		res.ImageURL = &image
+	}
+	return res
+}
+
+// newProductResponses monta a resposta de uma lista de produtos (nunca nil, para ser serializada como [])
+func newProductResponses(products []models.Product, version int) []productResponse {
+	responses := make([]productResponse, len(products))
+	for i := range products {
+		responses[i] = newProductResponse(&products[i], version)
+	}
+	return responses
+}
+
+// responseField é o nome do campo na resposta da versão informada
+func responseField(field string, version int) string {
+	if field == "image_url" && version >= responseV2 {
+		return "image"
+	}
+	return field
+}
+
+type responseVersionKey struct{}
+
+// ResponseVersionMiddleware lê a versão do formato das respostas pedida no Accept
+// e responde 406 se ela não existir. A versão fica no contexto para os handlers.
+func ResponseVersionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version, err := acceptedVersion(r.Header.Get("Accept"))
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), responseVersionKey{}, version)))
+	})
+}
+
+// acceptedVersion retorna a versão do primeiro tipo do Accept que a informa (padrão: 1)
+func acceptedVersion(accept string) (int, error) {
+	for _, part := range strings.Split(accept, ",") {
+		_, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || params["version"] == "" {
+			continue
+		}
+		version, err := strconv.Atoi(params["version"])
+		if err != nil || version < responseV1 || version > latestResponseVersion {
+			return 0, apperror.NotAcceptable("versão de resposta não suportada: " + params["version"] +
+				" (use de 1 a " + strconv.Itoa(latestResponseVersion) + ")")
+		}
+		return version, nil
+	}
+	return responseV1, nil
+}
+
+// responseVersion retorna a versão do formato das respostas guardada no contexto
+func responseVersion(ctx context.Context) int {
+	if version, ok := ctx.Value(responseVersionKey{}).(int); ok {
+		return version
+	}
+	return responseV1
+}
+
+// setProductContentType informa o tipo das respostas de produtos, com a versão
+// quando ela foi pedida, e que a resposta depende do Accept
+func setProductContentType(w http.ResponseWriter, r *http.Request) {
+	contentType := "application/json"
+	if version := responseVersion(r.Context()); version != responseV1 {
+		contentType += "; version=" + strconv.Itoa(version)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Vary", "Accept")
+}
+
+// writeProduct escreve um produto no formato da versão pedida
+func writeProduct(w http.ResponseWriter, r *http.Request, status int, p *models.Product) {
+	setProductContentType(w, r)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(newProductResponse(p, responseVersion(r.Context())))
+}
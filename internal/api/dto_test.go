@@ -0,0 +1,231 @@
+package api
+
+import (
+	"braip/internal/apperror"
+	"braip/internal/validation"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// O preço do corpo está em unidades menores da moeda e passa pelo mesmo conversor
+// decimal do importador e dos filtros: nada é arredondado nem truncado
+func TestDecodeProductPrice(t *testing.T) {
+	tests := []struct {
+		price string
+		want  int
+	}{
+		{"10995", 10995},
+		{"1.0995e4", 10995},
+		{"1990.0", 1990},
+		{"null", 0},
+	}
+	for _, tt := range tests {
+		product, err := decodeProduct(strings.NewReader(`{"name": "Camisa", "price": `+tt.price+`}`), false)
+		if err != nil {
+			t.Errorf("price %s: erro inesperado: %v", tt.price, err)
+			continue
+		}
+		if product.Price != tt.want {
+			t.Errorf("price %s = %d; esperado %d", tt.price, product.Price, tt.want)
+		}
+	}
+
+	for _, price := range []string{"109.95", "0.5", `"1990"`, "true", "2147483648"} {
+		_, err := decodeProduct(strings.NewReader(`{"name": "Camisa", "price": `+price+`}`), false)
+		if !isPriceError(err) {
+			t.Errorf("price %s: erro = %v; esperado erro de validação %s em price", price, err, validation.CodeDecimal)
+		}
+	}
+}
+
+func TestProductPatchPrice(t *testing.T) {
+	patch, err := productPatchFromFields(map[string]json.RawMessage{"price": json.RawMessage("1.99e3")}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patch.Price == nil || *patch.Price != 1990 {
+		t.Errorf("patch.Price = %v; esperado 1990", patch.Price)
+	}
+
+	_, err = productPatchFromFields(map[string]json.RawMessage{"price": json.RawMessage("19.90")}, false)
+	if !isPriceError(err) {
+		t.Errorf("price 19.90: erro = %v; esperado erro de validação %s em price", err, validation.CodeDecimal)
+	}
+}
+
+func isPriceError(err error) bool {
+	var errs validation.Errors
+	return errors.As(err, &errs) && len(errs) == 1 && errs[0].Field == "price" && errs[0].Code == validation.CodeDecimal
+}
+
+// A imagem vem em image_url ou em image (especificação do desafio); image_url prevalece
+func TestDecodeProductImage(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"image_url": "https://a.com/1.png"}`, "https://a.com/1.png"},
+		{`{"image": "https://a.com/2.png"}`, "https://a.com/2.png"},
+		{`{"image_url": "https://a.com/1.png", "image": "https://a.com/2.png"}`, "https://a.com/1.png"},
+		{`{"image": "https://a.com/2.png", "image_url": ""}`, ""},
+		{`{"image_url": null, "image": "https://a.com/2.png"}`, "https://a.com/2.png"},
+		{`{}`, ""},
+	}
+	for _, tt := range tests {
+		for _, strict := range []bool{false, true} {
+			product, err := decodeProduct(strings.NewReader(tt.body), strict)
+			if err != nil {
+				t.Errorf("%s (estrito: %v): %v", tt.body, strict, err)
+				continue
+			}
+			if product.ImageURL != tt.want {
+				t.Errorf("%s (estrito: %v): imagem %q; esperado %q", tt.body, strict, product.ImageURL, tt.want)
+			}
+		}
+	}
+
+	patch, err := productPatchFromFields(map[string]json.RawMessage{"image": json.RawMessage(`"https://a.com/2.png"`)}, true)
+	if err != nil || patch.ImageURL == nil || *patch.ImageURL != "https://a.com/2.png" {
+		t.Errorf("patch com image: %v, %v", patch.ImageURL, err)
+	}
+	patch, err = productPatchFromFields(map[string]json.RawMessage{"image": json.RawMessage(`"https://a.com/2.png"`), "image_url": json.RawMessage(`"https://a.com/1.png"`)}, true)
+	if err != nil || patch.ImageURL == nil || *patch.ImageURL != "https://a.com/1.png" {
+		t.Errorf("patch com image e image_url: %v, %v", patch.ImageURL, err)
+	}
+}
+
+// No modo estrito, campos desconhecidos são recusados; os somente leitura das respostas não
+func TestDecodeProductStrict(t *testing.T) {
+	readOnly := `{"name": "Camisa", "id": 7, "version": 3, "created_at": "2024-01-01T00:00:00Z", "updated_at": null, "deleted_at": null, "conversion": {}}`
+	for _, strict := range []bool{false, true} {
+		if _, err := decodeProduct(strings.NewReader(readOnly), strict); err != nil {
+			t.Errorf("campos somente leitura (estrito: %v): %v", strict, err)
+		}
+	}
+
+	unknown := `{"name": "Camisa", "imagem": "https://a.com/1.png"}`
+	if _, err := decodeProduct(strings.NewReader(unknown), false); err != nil {
+		t.Errorf("campo desconhecido fora do modo estrito: %v", err)
+	}
+	if _, err := decodeProduct(strings.NewReader(unknown), true); err == nil || err.Error() != "campo desconhecido: imagem" {
+		t.Errorf("campo desconhecido no modo estrito: %v", err)
+	}
+	fields := map[string]json.RawMessage{"imagem": json.RawMessage(`""`), "version": json.RawMessage("3")}
+	if _, err := productPatchFromFields(fields, true); err == nil || err.Error() != "campo desconhecido: imagem" {
+		t.Errorf("patch com campo desconhecido no modo estrito: %v", err)
+	}
+	if _, err := productPatchFromFields(fields, false); err != nil {
+		t.Errorf("patch com campo desconhecido fora do modo estrito: %v", err)
+	}
+}
+
+func TestStrictHandling(t *testing.T) {
+	tests := []struct {
+		prefer []string
+		want   bool
+	}{
+		{nil, false},
+		{[]string{"handling=strict"}, true},
+		{[]string{`respond-async, Handling="STRICT"; wait=5`}, true},
+		{[]string{"return=minimal", "handling=strict"}, true},
+		{[]string{"handling=lenient"}, false},
+		{[]string{"strict"}, false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/products", nil)
+		for _, v := range tt.prefer {
+			r.Header.Add("Prefer", v)
+		}
+		if got := strictHandling(r); got != tt.want {
+			t.Errorf("Prefer %q = %v; esperado %v", tt.prefer, got, tt.want)
+		}
+	}
+}
+
+func TestAcceptedVersion(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   int
+	}{
+		{"", responseV1},
+		{"application/json", responseV1},
+		{"application/json; version=2", responseV2},
+		{"text/html, application/json;version=1", responseV1},
+		{"*/*, application/json; version=2", responseV2},
+		{"application/json; version=0", 0},
+		{"application/json; version=3", 0},
+		{"application/json; version=v2", 0},
+	}
+	for _, tt := range tests {
+		got, err := acceptedVersion(tt.accept)
+		if tt.want == 0 {
+			if apperror.KindOf(err) != apperror.KindNotAcceptable {
+				t.Errorf("Accept %q: %d, %v; esperado erro 406", tt.accept, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Accept %q = %d, %v; esperado %d", tt.accept, got, err, tt.want)
+		}
+	}
+}
+
+// As respostas seguem a versão pedida no Accept, e o produto lido pode ser
+// reenviado no PUT mesmo no modo estrito
+func TestResponseVersions(t *testing.T) {
+	h := newTestRouter(t, nil)
+	p := createTestProduct(t, h, "Camisa", 1990)
+	target := "/products/" + strconv.Itoa(p.ID)
+	v2 := "application/json; version=2"
+
+	for _, path := range []string{target, "/products"} {
+		rec := request(t, h, "GET", path, "")
+		expectStatus(t, rec, http.StatusOK)
+		if body := rec.Body.String(); !strings.Contains(body, `"image_url":`) || strings.Contains(body, `"image":`) {
+			t.Errorf("GET %s (v1): %s", path, body)
+		}
+		if rec.Header().Get("Content-Type") != "application/json" || rec.Header().Get("Vary") != "Accept" {
+			t.Errorf("GET %s (v1): cabeçalhos %v", path, rec.Header())
+		}
+
+		rec = request(t, h, "GET", path, "", "Accept", v2)
+		expectStatus(t, rec, http.StatusOK)
+		if body := rec.Body.String(); strings.Contains(body, `"image_url":`) || !strings.Contains(body, `"image":"https://example.com/p.png"`) {
+			t.Errorf("GET %s (v2): %s", path, body)
+		}
+		if rec.Header().Get("Content-Type") != v2 {
+			t.Errorf("GET %s (v2): Content-Type %q", path, rec.Header().Get("Content-Type"))
+		}
+	}
+	expectStatus(t, request(t, h, "GET", target, "", "Accept", "application/json; version=3"), http.StatusNotAcceptable)
+
+	// O produto lido na versão 2 volta no PUT estrito, com a imagem em image
+	rec := request(t, h, "GET", target, "", "Accept", v2)
+	body := strings.Replace(rec.Body.String(), `"price":1990`, `"price":2990`, 1)
+	rec = request(t, h, "PUT", target, body, "Prefer", "handling=strict", "Accept", v2)
+	expectStatus(t, rec, http.StatusOK)
+	if rec.Header().Get("Preference-Applied") != "handling=strict" {
+		t.Errorf("Preference-Applied = %q", rec.Header().Get("Preference-Applied"))
+	}
+	if got := getTestProduct(t, h, p.ID); got.Price != 2990 || got.ImageURL != "https://example.com/p.png" {
+		t.Errorf("produto reenviado: %+v", got)
+	}
+
+	// Campos desconhecidos: ignorados por padrão, recusados no modo estrito
+	unknown := `{"name": "Calça", "price": 2990, "description": "Jeans", "category": "Testes", "cor": "azul"}`
+	expectStatus(t, request(t, h, "POST", "/products", unknown), http.StatusCreated)
+	rec = request(t, h, "PUT", target, unknown, "Prefer", "handling=strict")
+	expectStatus(t, rec, http.StatusBadRequest)
+	var problem problemBody
+	decodeBody(t, rec, &problem)
+	if problem.Detail != "campo desconhecido: cor" {
+		t.Errorf("problema: %+v", problem)
+	}
+	rec = request(t, h, "PATCH", target, `{"cor": "azul"}`, "Content-Type", mergePatchType, "Prefer", "handling=strict")
+	expectStatus(t, rec, http.StatusBadRequest)
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"braip/internal/apperror"
+	"braip/internal/models"
+	"braip/internal/repository"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// errNoCurrentProduct é o erro do If-Match enviado para um produto que não existe
+var errNoCurrentProduct = apperror.PreconditionFailed("If-Match informado, mas o produto não existe")
+
+// productETag é o ETag de um produto, derivado da sua versão
+func productETag(p *models.Product) string {
+	return `"` + strconv.Itoa(p.Version) + `"`
+}
+
+// bodyETag é o ETag de uma resposta montada a partir de vários produtos (listagens),
+// derivado do próprio corpo
+func bodyETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified informa o ETag da resposta e, se o cliente já tem essa versão
+// (If-None-Match), responde 304 e retorna true
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	if !etagMatches(r.Header.Get("If-None-Match"), etag, true) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// expectedVersion lê o If-Match de uma escrita e retorna a versão do produto que
+// ele identifica, para que a escrita só aconteça se o produto ainda estiver nela.
+// Sem If-Match retorna 0 (qualquer versão). Se o ETag não for o atual, retorna ErrVersionMismatch.
+func expectedVersion(r *http.Request, current func() (*models.Product, error)) (int, error) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return 0, nil
+	}
+
+	product, err := current()
+	if apperror.KindOf(err) == apperror.KindNotFound {
+		return 0, errNoCurrentProduct
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// If-Match usa a comparação forte: ETags fracos (W/) nunca coincidem
+	if !etagMatches(header, productETag(product), false) {
+		return 0, repository.ErrVersionMismatch
+	}
+	return product.Version, nil
+}
+
+// etagMatches compara o ETag com a lista de um cabeçalho If-Match ou If-None-Match
+// (RFC 9110). "*" coincide com qualquer ETag. Na comparação fraca, o prefixo W/ é ignorado.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		weak   bool
+		want   bool
+	}{
+		{`"2"`, false, true},
+		{`"1", "2"`, false, true},
+		{`*`, false, true},
+		{`"1"`, false, false},
+		{`W/"2"`, false, false},
+		{`W/"2"`, true, true},
+		{`"1" , W/"2"`, true, true},
+		{`2`, true, false},
+		{``, true, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, `"2"`, tt.weak); got != tt.want {
+			t.Errorf("etagMatches(%q, weak=%v) = %v; esperado %v", tt.header, tt.weak, got, tt.want)
+		}
+	}
+}
+
+// Leituras com If-None-Match respondem 304 enquanto o produto (ou a listagem) não muda
+func TestConditionalReads(t *testing.T) {
+	h := newTestRouter(t, nil)
+	p := createTestProduct(t, h, "Boné", 1990)
+	target := "/products/" + strconv.Itoa(p.ID)
+
+	for _, path := range []string{target, "/products", "/products?sort=-price&limit=5"} {
+		rec := request(t, h, "GET", path, "")
+		expectStatus(t, rec, http.StatusOK)
+		etag := rec.Header().Get("ETag")
+		if etag == "" {
+			t.Fatalf("GET %s sem ETag", path)
+		}
+
+		for _, header := range []string{etag, "W/" + etag, `"outro", ` + etag, "*"} {
+			rec = request(t, h, "GET", path, "", "If-None-Match", header)
+			expectStatus(t, rec, http.StatusNotModified)
+			if rec.Body.Len() != 0 || rec.Header().Get("ETag") != etag {
+				t.Errorf("GET %s com If-None-Match %s: ETag %q, corpo %q", path, header, rec.Header().Get("ETag"), rec.Body.String())
+			}
+		}
+		expectStatus(t, request(t, h, "GET", path, "", "If-None-Match", `"outro"`), http.StatusOK)
+	}
+
+	// Depois de uma alteração, o ETag antigo deixa de valer para o produto e para a listagem
+	product := request(t, h, "GET", target, "").Header().Get("ETag")
+	list := request(t, h, "GET", "/products", "").Header().Get("ETag")
+	rec := request(t, h, "PATCH", target, `{"price": 2490}`, "Content-Type", mergePatchType)
+	expectStatus(t, rec, http.StatusOK)
+	if rec.Header().Get("ETag") == product {
+		t.Error("ETag não mudou com a alteração")
+	}
+	expectStatus(t, request(t, h, "GET", target, "", "If-None-Match", product), http.StatusOK)
+	expectStatus(t, request(t, h, "GET", "/products", "", "If-None-Match", list), http.StatusOK)
+}
+
+// Escritas com If-Match só acontecem se o produto ainda estiver na versão do ETag;
+// caso contrário, respondem 412 sem alterar nada
+func TestConditionalWrites(t *testing.T) {
+	h := newTestRouter(t, nil)
+	p := createTestProduct(t, h, "Boné", 1990)
+	target := "/products/" + strconv.Itoa(p.ID)
+	etag := request(t, h, "GET", target, "").Header().Get("ETag")
+	body := `{"name": "Boné azul", "price": 2990, "description": "Boné", "category": "Testes"}`
+
+	// Duas ferramentas leram a mesma versão: a primeira escrita vale, a segunda recebe 412
+	rec := request(t, h, "PUT", target, body, "If-Match", etag)
+	expectStatus(t, rec, http.StatusOK)
+	current := rec.Header().Get("ETag")
+	if current == etag {
+		t.Fatal("ETag não mudou com a alteração")
+	}
+
+	stale := []struct {
+		method, body, contentType, ifMatch string
+	}{
+		{"PUT", `{"name": "Boné verde", "price": 990, "description": "Boné", "category": "Testes"}`, "application/json", etag},
+		{"PATCH", `{"price": 990}`, mergePatchType, etag},
+		{"DELETE", "", "", etag},
+		// If-Match usa a comparação forte
+		{"PATCH", `{"price": 990}`, mergePatchType, "W/" + current},
+	}
+	for _, tt := range stale {
+		rec := request(t, h, tt.method, target, tt.body, "Content-Type", tt.contentType, "If-Match", tt.ifMatch)
+		expectStatus(t, rec, http.StatusPreconditionFailed)
+		var problem problemBody
+		decodeBody(t, rec, &problem)
+		if problem.Type != "/problems/precondition-failed" {
+			t.Errorf("%s com If-Match %s: %+v", tt.method, tt.ifMatch, problem)
+		}
+	}
+	if got := getTestProduct(t, h, p.ID); got.Name != "Boné azul" || got.Price != 2990 {
+		t.Errorf("produto alterado por uma escrita recusada: %+v", got)
+	}
+
+	// Listas de ETags e "*" valem para o produto existente
+	rec = request(t, h, "PATCH", target, `{"price": 990}`, "Content-Type", mergePatchType, "If-Match", etag+", "+current)
+	expectStatus(t, rec, http.StatusOK)
+	rec = request(t, h, "PATCH", target, `{"price": 890}`, "Content-Type", mergePatchType, "If-Match", "*")
+	expectStatus(t, rec, http.StatusOK)
+	expectStatus(t, request(t, h, "DELETE", target, "", "If-Match", rec.Header().Get("ETag")), http.StatusNoContent)
+
+	// If-Match para um produto que não existe (ou está na lixeira)
+	for _, path := range []string{target, "/products/999"} {
+		expectStatus(t, request(t, h, "PUT", path, body, "If-Match", "*"), http.StatusPreconditionFailed)
+		expectStatus(t, request(t, h, "DELETE", path, "", "If-Match", `"1"`), http.StatusPreconditionFailed)
+	}
+}
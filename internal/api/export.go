@@ -0,0 +1,71 @@
+package api
+
+import (
+	"braip/internal/export"
+	"braip/internal/models"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ExportProducts exporta os produtos em CSV, NDJSON ou XLSX (GET /products/export?format=csv).
+// Aceita q e os mesmos filtros da busca, além de sort (sem q) e fields, sem paginação.
+// As linhas são lidas do banco e enviadas uma a uma, sem montar a resposta em memória.
+func (h *ProductHandler) ExportProducts(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = export.FormatCSV
+	}
+	if err := export.CheckFormat(format); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	opts, columns, err := export.ParseQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// A resposta só começa no primeiro produto (ou no fim, sem produtos), para que
+	// um erro da consulta ainda possa ser respondido com o problema correspondente.
+	// Os cabeçalhos vêm antes do writer, que pode começar a gravar o arquivo ao ser criado.
+	var writer export.Writer
+	start := func() error {
+		w.Header().Set("Content-Type", export.ContentType(format))
+		w.Header().Set("Content-Disposition", `attachment; filename="products-`+time.Now().UTC().Format("20060102")+"."+format+`"`)
+		started, err := export.NewWriter(w, format, columns)
+		if err != nil {
+			w.Header().Del("Content-Disposition")
+			return err
+		}
+		writer = started
+		return nil
+	}
+
+	err = h.service.ExportProducts(r.Context(), opts, func(p *models.Product) error {
+		if writer == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return writer.Write(p)
+	})
+	if err == nil && writer == nil {
+		err = start()
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+
+	if err != nil {
+		if writer == nil {
+			writeError(w, r, err)
+			return
+		}
+		// Parte do arquivo já foi enviada: a conexão é interrompida para que o
+		// cliente não confunda o arquivo incompleto com a exportação inteira
+		log.Printf("[%s] Exportação interrompida: %v", RequestID(r.Context()), err)
+		panic(http.ErrAbortHandler)
+	}
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"archive/zip"
+	"braip/internal/models"
+	"braip/internal/repository"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// createQuotedProduct cria um produto com vírgulas, aspas e quebra de linha no texto
+func createQuotedProduct(t *testing.T, h http.Handler) testProduct {
+	t.Helper()
+	body := `{"name": "Camisa \"polo\", azul", "price": 1990, "description": "Algodão;\nmanga curta, \"slim\"", "category": "Testes"}`
+	rec := request(t, h, "POST", "/products", body)
+	expectStatus(t, rec, http.StatusCreated)
+	var p testProduct
+	decodeBody(t, rec, &p)
+	return p
+}
+
+// expectExportHeaders confere o tipo e o nome do arquivo exportado
+func expectExportHeaders(t *testing.T, header http.Header, contentType, extension string) {
+	t.Helper()
+	if got := header.Get("Content-Type"); got != contentType {
+		t.Errorf("Content-Type = %q; esperado %q", got, contentType)
+	}
+	if got := header.Get("Content-Disposition"); !strings.HasPrefix(got, `attachment; filename="products-`) || !strings.HasSuffix(got, "."+extension+`"`) {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	h := newTestRouter(t, nil)
+	p := createQuotedProduct(t, h)
+	other := createTestProduct(t, h, "Calça", 2990)
+
+	rec := request(t, h, "GET", "/products/export?fields=id,name,description,price&sort=id", "")
+	expectStatus(t, rec, http.StatusOK)
+	expectExportHeaders(t, rec.Header(), "text/csv; charset=utf-8", "csv")
+
+	// Os textos com separador, aspas e quebra de linha voltam intactos numa leitura RFC 4180
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("CSV inválido: %v", err)
+	}
+	want := [][]string{
+		{"id", "name", "description", "price"},
+		{strconv.Itoa(p.ID), p.Name, p.Description, "1990"},
+		{strconv.Itoa(other.ID), "Calça", "Produto de teste", "2990"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("%d linhas no CSV; esperado %d: %q", len(records), len(want), records)
+	}
+	for i := range want {
+		if strings.Join(records[i], "\x00") != strings.Join(want[i], "\x00") {
+			t.Errorf("linha %d = %q; esperado %q", i, records[i], want[i])
+		}
+	}
+}
+
+func TestExportNDJSON(t *testing.T) {
+	h := newTestRouter(t, nil)
+	p := createQuotedProduct(t, h)
+	createTestProduct(t, h, "Calça", 2990)
+
+	// image é aceito como nome alternativo de image_url
+	rec := request(t, h, "GET", "/products/export?format=ndjson&fields=name,id,image&sort=id", "")
+	expectStatus(t, rec, http.StatusOK)
+	expectExportHeaders(t, rec.Header(), "application/x-ndjson", "ndjson")
+
+	lines := strings.Split(strings.TrimSuffix(rec.Body.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("%d linhas; esperado 2: %s", len(lines), rec.Body.String())
+	}
+	// As colunas saem na ordem pedida, e só elas
+	want := `{"name":` + strconv.Quote(p.Name) + `,"id":` + strconv.Itoa(p.ID) + `,"image_url":""}`
+	if lines[0] != want {
+		t.Errorf("linha = %s; esperado %s", lines[0], want)
+	}
+	var other map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &other); err != nil || len(other) != 3 {
+		t.Errorf("linha = %s; esperado um objeto JSON com 3 colunas", lines[1])
+	}
+}
+
+func TestExportXLSX(t *testing.T) {
+	h := newTestRouter(t, nil)
+	p := createQuotedProduct(t, h)
+
+	rec := request(t, h, "GET", "/products/export?format=xlsx&fields=id,name,price", "")
+	expectStatus(t, rec, http.StatusOK)
+	expectExportHeaders(t, rec.Header(), "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "xlsx")
+
+	body := rec.Body.Bytes()
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("XLSX não é um zip válido: %v", err)
+	}
+
+	// Todas as partes do pacote são XML bem formado
+	parts := map[string][]byte{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", f.Name, err)
+		}
+		decoder := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s não é XML válido: %v", f.Name, err)
+			}
+		}
+		parts[f.Name] = content
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("parte %s ausente do pacote", name)
+		}
+	}
+
+	// A planilha tem o cabeçalho e o produto, com números como células numéricas
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet); err != nil {
+		t.Fatal(err)
+	}
+	if len(sheet.Rows) != 2 || len(sheet.Rows[1].Cells) != 3 {
+		t.Fatalf("planilha com %d linhas; esperado o cabeçalho e 1 produto", len(sheet.Rows))
+	}
+	cells := sheet.Rows[1].Cells
+	if cells[0].Ref != "A2" || cells[0].Value != strconv.Itoa(p.ID) || cells[1].Type != "inlineStr" || cells[1].Inline != p.Name || cells[2].Value != "1990" {
+		t.Errorf("linha do produto = %+v", cells)
+	}
+}
+
+// A exportação não é paginada
+func TestExportRejectsPagination(t *testing.T) {
+	h := newTestRouter(t, nil)
+	for _, param := range []string{"limit=10", "offset=10", "cursor=abc"} {
+		rec := request(t, h, "GET", "/products/export?"+param, "")
+		expectStatus(t, rec, http.StatusBadRequest)
+		if rec.Header().Get("Content-Disposition") != "" {
+			t.Errorf("%s: erro enviado como anexo", param)
+		}
+	}
+}
+
+// failingExportStore falha a exportação depois do primeiro produto
+type failingExportStore struct {
+	repository.ProductStore
+}
+
+func (s failingExportStore) ExportProducts(ctx context.Context, opts repository.ExportOptions, fn func(p *models.Product) error) error {
+	if err := fn(&models.Product{ID: 1, Name: "Camisa", Price: 1990, Currency: "BRL"}); err != nil {
+		return err
+	}
+	return errors.New("conexão com o banco perdida")
+}
+
+// Um erro depois do início do arquivo interrompe a conexão, em vez de terminar
+// a resposta como se o arquivo incompleto fosse a exportação inteira
+func TestExportAbortsAfterOutputStarted(t *testing.T) {
+	h := newTestRouter(t, failingExportStore{repository.NewMemoryProductRepository()})
+	for _, format := range []string{"csv", "ndjson", "xlsx"} {
+		t.Run(format, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != http.ErrAbortHandler {
+					t.Errorf("recover() = %v; esperado http.ErrAbortHandler", r)
+				}
+			}()
+			request(t, h, "GET", "/products/export?format="+format, "")
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"braip/internal/apperror"
+	"braip/internal/services"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// validIdempotencyKey limita as chaves aceitas no cabeçalho Idempotency-Key (ex.: um UUID)
+var validIdempotencyKey = regexp.MustCompile(`^[\x21-\x7e]{1,255}$`)
+
+// IdempotencyHandler repete a resposta das requisições reenviadas com o mesmo
+// cabeçalho Idempotency-Key, em vez de executá-las de novo
+type IdempotencyHandler struct {
+	service *services.IdempotencyService
+}
+
+// NewIdempotencyHandler cria o handler de idempotência
+func NewIdempotencyHandler(service *services.IdempotencyService) *IdempotencyHandler {
+	return &IdempotencyHandler{service: service}
+}
+
+// Wrap aplica o Idempotency-Key à rota. Sem o cabeçalho, a requisição é executada
+// normalmente. Com ele, a resposta de sucesso (2xx) é guardada e repetida, com o
+// cabeçalho Idempotent-Replayed: true, nas requisições seguintes com a mesma chave e
+// o mesmo método, caminho, corpo e versão de resposta. A chave usada com outra
+// requisição recebe 422 e, enquanto a primeira requisição não termina, as repetições
+// recebem 409. Respostas de erro não são guardadas: a requisição que falhou (inclusive
+// com pânico ou sem conseguir guardar a resposta) pode ser repetida com a mesma chave.
+func (h *IdempotencyHandler) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Idempotency-Key")
+		if header == "" {
+			next(w, r)
+			return
+		}
+		// A chave pode vir entre aspas, como uma string de Structured Fields (RFC 8941)
+		key := strings.Trim(header, `"`)
+		if !validIdempotencyKey.MatchString(key) {
+			writeError(w, r, apperror.BadRequest("cabeçalho Idempotency-Key inválido (use até 255 caracteres ASCII visíveis, como um UUID)"))
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, r, invalidJSON(err))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		hash := requestHash(r, body)
+
+		stored, err := h.service.Begin(r.Context(), key, hash)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if stored != nil {
+			for name, values := range stored.Header {
+				w.Header()[name] = values
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.Status)
+			w.Write(stored.Body)
+			return
+		}
+
+		// A resposta já foi enviada: mesmo que o cliente tenha desistido, a chave
+		// precisa ser concluída ou liberada
+		ctx := context.WithoutCancel(r.Context())
+		release := func() {
+			if err := h.service.Release(ctx, key); err != nil {
+				log.Printf("[%s] Erro ao liberar Idempotency-Key: %v", RequestID(r.Context()), err)
+			}
+		}
+		defer func() {
+			if p := recover(); p != nil {
+				release()
+				panic(p)
+			}
+		}()
+
+		rec := &responseRecorder{ResponseWriter: w}
+		next(rec, r)
+
+		if rec.status < 200 || rec.status > 299 {
+			release()
+			return
+		}
+		responseHeader := w.Header().Clone()
+		responseHeader.Del("X-Request-ID")
+		if err := h.service.Complete(ctx, key, hash, rec.status, responseHeader, rec.body.Bytes()); err != nil {
+			// Sem a resposta guardada, a chave reservada daria 409 até expirar
+			log.Printf("[%s] Erro ao guardar a resposta da Idempotency-Key: %v", RequestID(r.Context()), err)
+			release()
+		}
+	}
+}
+
+// requestHash identifica a requisição pelo método, pelo caminho, pela versão de resposta
+// negociada no Accept e pelo corpo. Corpos JSON são comparados pelo conteúdo: espaços e
+// a ordem dos campos não mudam o hash.
+func requestHash(r *http.Request, body []byte) string {
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if decoder.Decode(&doc) == nil && !decoder.More() {
+		if canonical, err := json.Marshal(doc); err == nil {
+			body = canonical
+		}
+	}
+
+	sum := sha256.New()
+	io.WriteString(sum, r.Method+" "+r.URL.RequestURI()+"\n"+
+		"version="+strconv.Itoa(responseVersion(r.Context()))+"\n"+strconv.Itoa(len(body))+"\n")
+	sum.Write(body)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// responseRecorder repassa a resposta ao cliente e guarda uma cópia do status e do corpo
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader guarda o status e o repassa ao cliente
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Write guarda o corpo e o repassa ao cliente
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
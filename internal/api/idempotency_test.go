@@ -0,0 +1,226 @@
+package api
+
+import (
+	"braip/internal/audit"
+	"braip/internal/models"
+	"braip/internal/repository"
+	"braip/internal/services"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const testIdempotencyKey = "8e03978e-40d5-43e8-bc93-6894a57f9324"
+
+// wrapIdempotent aplica o Idempotency-Key a next, com os middlewares de que ele depende
+func wrapIdempotent(store repository.IdempotencyStore, ttl time.Duration, next http.HandlerFunc) http.Handler {
+	handler := NewIdempotencyHandler(services.NewIdempotencyService(store, ttl)).Wrap(next)
+	return ActorMiddleware(ResponseVersionMiddleware(handler))
+}
+
+// countingHandler responde 201 com o número de vezes em que foi executado
+func countingHandler(calls *int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"call": ` + strconv.Itoa(*calls) + `}`))
+	}
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	h := newTestRouter(t, nil)
+	body := `{"name": "Camisa", "price": 1990, "description": "Produto de teste", "category": "Testes"}`
+
+	first := request(t, h, "POST", "/products", body, "Idempotency-Key", testIdempotencyKey)
+	expectStatus(t, first, http.StatusCreated)
+	// Espaços e a ordem dos campos não mudam a requisição
+	replay := request(t, h, "POST", "/products", `{"category":"Testes","description":"Produto de teste","price":1990,"name":"Camisa"}`, "Idempotency-Key", testIdempotencyKey)
+	expectStatus(t, replay, http.StatusCreated)
+
+	if replay.Header().Get("Idempotent-Replayed") != "true" || first.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("Idempotent-Replayed = %q na primeira e %q na repetição", first.Header().Get("Idempotent-Replayed"), replay.Header().Get("Idempotent-Replayed"))
+	}
+	if replay.Body.String() != first.Body.String() || replay.Header().Get("ETag") != first.Header().Get("ETag") {
+		t.Errorf("resposta repetida = %s; esperado %s", replay.Body.String(), first.Body.String())
+	}
+
+	var products []testProduct
+	decodeBody(t, request(t, h, "GET", "/products", ""), &products)
+	if len(products) != 1 {
+		t.Errorf("%d produtos criados; esperado 1", len(products))
+	}
+}
+
+func TestIdempotencyKeyReused(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		header []string
+	}{
+		{"outro corpo", `{"name": "Calça", "price": 1990, "description": "Produto de teste", "category": "Testes"}`, nil},
+		{"outra versão de resposta", `{"name": "Camisa", "price": 1990, "description": "Produto de teste", "category": "Testes"}`, []string{"Accept", "application/json; version=2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestRouter(t, nil)
+			rec := request(t, h, "POST", "/products", `{"name": "Camisa", "price": 1990, "description": "Produto de teste", "category": "Testes"}`, "Idempotency-Key", testIdempotencyKey)
+			expectStatus(t, rec, http.StatusCreated)
+
+			rec = request(t, h, "POST", "/products", tt.body, append([]string{"Idempotency-Key", testIdempotencyKey}, tt.header...)...)
+			expectStatus(t, rec, http.StatusUnprocessableEntity)
+		})
+	}
+}
+
+func TestIdempotencyKeyPerClient(t *testing.T) {
+	h := newTestRouter(t, nil)
+	body := `{"name": "Camisa", "price": 1990, "description": "Produto de teste", "category": "Testes"}`
+	rec := request(t, h, "POST", "/products", body, "Idempotency-Key", testIdempotencyKey, "X-Actor", "loja")
+	expectStatus(t, rec, http.StatusCreated)
+
+	// A mesma chave, usada por outro cliente, é outra requisição
+	rec = request(t, h, "POST", "/products", `{"name": "Calça", "price": 1990, "description": "Produto de teste", "category": "Testes"}`, "Idempotency-Key", testIdempotencyKey, "X-Actor", "importador")
+	expectStatus(t, rec, http.StatusCreated)
+	if rec.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("resposta de outro cliente repetida")
+	}
+}
+
+func TestIdempotencyKeyInFlight(t *testing.T) {
+	var h http.Handler
+	var inFlight int
+	h = wrapIdempotent(repository.NewMemoryProductRepository(), time.Hour, func(w http.ResponseWriter, r *http.Request) {
+		// A repetição chega enquanto a primeira requisição ainda está em andamento
+		inFlight = request(t, h, "POST", "/products", `{}`, "Idempotency-Key", testIdempotencyKey).Code
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	rec := request(t, h, "POST", "/products", `{}`, "Idempotency-Key", testIdempotencyKey)
+	expectStatus(t, rec, http.StatusCreated)
+	if inFlight != http.StatusConflict {
+		t.Errorf("status da repetição em andamento = %d; esperado %d", inFlight, http.StatusConflict)
+	}
+}
+
+func TestIdempotencyErrorReleasesKey(t *testing.T) {
+	status := http.StatusInternalServerError
+	calls := 0
+	h := wrapIdempotent(repository.NewMemoryProductRepository(), time.Hour, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(status)
+	})
+
+	expectStatus(t, request(t, h, "POST", "/products", `{}`, "Idempotency-Key", testIdempotencyKey), http.StatusInternalServerError)
+	status = http.StatusCreated
+	expectStatus(t, request(t, h, "POST", "/products", `{}`, "Idempotency-Key", testIdempotencyKey), http.StatusCreated)
+	if calls != 2 {
+		t.Errorf("handler executado %d vezes; esperado 2", calls)
+	}
+}
+
+func TestIdempotencyPanicReleasesKey(t *testing.T) {
+	calls := 0
+	h := wrapIdempotent(repository.NewMemoryProductRepository(), time.Hour, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			panic(http.ErrAbortHandler)
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	func() {
+		defer func() {
+			if p := recover(); p != http.ErrAbortHandler {
+				t.Errorf("pânico = %v; esperado o do handler repassado", p)
+			}
+		}()
+		request(t, h, "POST", "/products", `{}`, "Idempotency-Key", testIdempotencyKey)
+	}()
+
+	expectStatus(t, request(t, h, "POST", "/products", `{}`, "Idempotency-Key", testIdempotencyKey), http.StatusCreated)
+	if calls != 2 {
+		t.Errorf("handler executado %d vezes; esperado 2", calls)
+	}
+}
+
+// failingCompleteStore não consegue guardar as respostas
+type failingCompleteStore struct {
+	repository.ProductStore
+}
+
+func (s failingCompleteStore) CompleteIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) error {
+	return errors.New("banco indisponível")
+}
+
+func TestIdempotencyCompleteFailureReleasesKey(t *testing.T) {
+	calls := 0
+	h := wrapIdempotent(failingCompleteStore{repository.NewMemoryProductRepository()}, time.Hour, countingHandler(&calls))
+
+	expectStatus(t, request(t, h, "POST", "/products", `{}`, "Idempotency-Key", testIdempotencyKey), http.StatusCreated)
+	// Sem a resposta guardada, a repetição é executada de novo, em vez de receber 409
+	expectStatus(t, request(t, h, "POST", "/products", `{}`, "Idempotency-Key", testIdempotencyKey), http.StatusCreated)
+	if calls != 2 {
+		t.Errorf("handler executado %d vezes; esperado 2", calls)
+	}
+}
+
+// abandonedStore não libera as chaves, como um servidor que caiu no meio da requisição
+type abandonedStore struct {
+	repository.ProductStore
+}
+
+func (s abandonedStore) CompleteIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) error {
+	return nil
+}
+
+func (s abandonedStore) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	return nil
+}
+
+func TestIdempotencyExpiry(t *testing.T) {
+	t.Run("reserva abandonada", func(t *testing.T) {
+		calls := 0
+		h := wrapIdempotent(abandonedStore{repository.NewMemoryProductRepository()}, 50*time.Millisecond, countingHandler(&calls))
+
+		expectStatus(t, request(t, h, "POST", "/products", `{}`, "Idempotency-Key", testIdempotencyKey), http.StatusCreated)
+		expectStatus(t, request(t, h, "POST", "/products", `{}`, "Idempotency-Key", testIdempotencyKey), http.StatusConflict)
+		time.Sleep(60 * time.Millisecond)
+		expectStatus(t, request(t, h, "POST", "/products", `{}`, "Idempotency-Key", testIdempotencyKey), http.StatusCreated)
+	})
+
+	t.Run("resposta guardada", func(t *testing.T) {
+		calls := 0
+		h := wrapIdempotent(repository.NewMemoryProductRepository(), 50*time.Millisecond, countingHandler(&calls))
+
+		expectStatus(t, request(t, h, "POST", "/products", `{}`, "Idempotency-Key", testIdempotencyKey), http.StatusCreated)
+		rec := request(t, h, "POST", "/products", `{}`, "Idempotency-Key", testIdempotencyKey)
+		if rec.Header().Get("Idempotent-Replayed") != "true" {
+			t.Fatal("resposta não repetida dentro do TTL")
+		}
+		time.Sleep(60 * time.Millisecond)
+		rec = request(t, h, "POST", "/products", `{}`, "Idempotency-Key", testIdempotencyKey)
+		if rec.Header().Get("Idempotent-Replayed") != "" || calls != 2 {
+			t.Errorf("resposta repetida depois do TTL (%d execuções)", calls)
+		}
+	})
+}
+
+func TestIdempotencyLeaseShorterThanTTL(t *testing.T) {
+	store := repository.NewMemoryProductRepository()
+	service := services.NewIdempotencyService(store, time.Hour)
+	ctx := context.Background()
+	if _, err := service.Begin(ctx, testIdempotencyKey, "hash"); err != nil {
+		t.Fatal(err)
+	}
+	record, _, err := store.ReserveIdempotencyKey(ctx, models.IdempotencyRecord{Key: audit.ActorFrom(ctx).Name + "/" + testIdempotencyKey})
+	if err != nil || record == nil {
+		t.Fatalf("registro da reserva: (%v, %v)", record, err)
+	}
+	if lease := record.ExpiresAt.Sub(record.CreatedAt); lease != services.IdempotencyLease {
+		t.Errorf("reserva de %v; esperado %v", lease, services.IdempotencyLease)
+	}
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"braip/internal/models"
+	"braip/internal/repository"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// parseListOptions lê os parâmetros limit, offset, cursor, sort e fields da requisição
+func parseListOptions(r *http.Request) (repository.ListOptions, error) {
+	query := r.URL.Query()
+	opts := repository.ListOptions{Cursor: query.Get("cursor")}
+
+	var err error
+	if opts.Limit, opts.Offset, err = parseLimitOffset(query); err != nil {
+		return opts, err
+	}
+
+	if opts.Cursor != "" && opts.Offset > 0 {
+		return opts, fmt.Errorf("parâmetros 'cursor' e 'offset' não podem ser usados juntos")
+	}
+
+	sort, err := repository.ParseSort(query.Get("sort"))
+	if err != nil {
+		return opts, err
+	}
+	opts.Sort = sort
+
+	names := strings.Split(query.Get("fields"), ",")
+	for i, name := range names {
+		if field, ok := fieldAliases[strings.TrimSpace(name)]; ok {
+			names[i] = field
+		}
+	}
+	fields, err := repository.ParseFields(strings.Join(names, ","))
+	if err != nil {
+		return opts, err
+	}
+	opts.Fields = fields
+
+	// A conversão de moeda (?currency=) precisa da moeda gravada junto com o preço
+	if query.Get("currency") != "" && contains(fields, "price") && !contains(fields, "currency") {
+		opts.Fields = append(opts.Fields, "currency")
+	}
+
+	return opts, nil
+}
+
+// fieldAliases são os nomes de campo da versão 2 das respostas aceitos em fields=
+var fieldAliases = map[string]string{"image": "image_url"}
+
+// parseLimitOffset lê os parâmetros limit e offset (zero quando não informados)
+func parseLimitOffset(query url.Values) (limit, offset int, err error) {
+	if v := query.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > repository.MaxLimit {
+			return 0, 0, fmt.Errorf("parâmetro 'limit' deve ser um número entre 1 e %d", repository.MaxLimit)
+		}
+	}
+
+	if v := query.Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("parâmetro 'offset' deve ser um número maior ou igual a zero")
+		}
+	}
+	return limit, offset, nil
+}
+
+// setPaginationHeaders informa o total de produtos (X-Total-Count) e os links
+// de navegação entre as páginas (Link, RFC 8288)
+func setPaginationHeaders(w http.ResponseWriter, r *http.Request, opts repository.ListOptions, page *repository.ProductPage) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = repository.DefaultLimit
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if page.NextCursor != "" {
+		w.Header().Set("X-Next-Cursor", page.NextCursor)
+	}
+
+	var links []string
+	link := func(rel string, params map[string]string) {
+		query := r.URL.Query()
+		query.Del("cursor")
+		query.Del("offset")
+		query.Set("limit", strconv.Itoa(limit))
+		for k, v := range params {
+			query.Set(k, v)
+		}
+		u := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+		links = append(links, fmt.Sprintf("<%s>; rel=\"%s\"", u.String(), rel))
+	}
+
+	if opts.Cursor != "" {
+		// Paginação por cursor: só é possível avançar ou voltar ao início
+		link("first", nil)
+		if page.NextCursor != "" {
+			link("next", map[string]string{"cursor": page.NextCursor})
+		}
+	} else {
+		link("first", map[string]string{"offset": "0"})
+		if opts.Offset+limit < page.Total {
+			link("next", map[string]string{"offset": strconv.Itoa(opts.Offset + limit)})
+		}
+		if opts.Offset > 0 {
+			prev := opts.Offset - limit
+			if prev < 0 {
+				prev = 0
+			}
+			link("prev", map[string]string{"offset": strconv.Itoa(prev)})
+		}
+		last := 0
+		if page.Total > 0 {
+			last = (page.Total - 1) / limit * limit
+		}
+		link("last", map[string]string{"offset": strconv.Itoa(last)})
+	}
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+// selectFields reduz cada produto aos campos pedidos em fields=, com os nomes da versão da resposta
+func selectFields(products []models.Product, fields []string, version int) ([]map[string]interface{}, error) {
+	selected := make([]map[string]interface{}, 0, len(products))
+	for i := range products {
+		data, err := json.Marshal(newProductResponse(&products[i], version))
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]interface{}
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+
+		item := make(map[string]interface{}, len(fields)+1)
+		for _, f := range fields {
+			item[responseField(f, version)] = all[responseField(f, version)]
+		}
+		if conversion, ok := all["conversion"]; ok {
+			item["conversion"] = conversion // Preço convertido com ?currency=
+		}
+		selected = append(selected, item)
+	}
+	return selected, nil
+}
+
+// contains informa se a lista de campos inclui field
+func contains(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
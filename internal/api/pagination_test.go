@@ -0,0 +1,117 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPaginationHeaders(t *testing.T) {
+	h := newTestRouter(t, nil)
+	for _, name := range []string{"Camisa", "Calça", "Meia", "Boné", "Tênis"} {
+		createTestProduct(t, h, name, 1990)
+	}
+
+	rec := request(t, h, "GET", "/products?limit=2&offset=2&sort=name", "")
+	expectStatus(t, rec, http.StatusOK)
+	var products []testProduct
+	decodeBody(t, rec, &products)
+	if len(products) != 2 || products[0].Name != "Camisa" || products[1].Name != "Meia" {
+		t.Errorf("segunda página por nome: %+v", products)
+	}
+	if got := rec.Header().Get("X-Total-Count"); got != "5" {
+		t.Errorf("X-Total-Count = %q; esperado 5", got)
+	}
+	link := rec.Header().Get("Link")
+	for _, want := range []string{
+		`</products?limit=2&offset=0&sort=name>; rel="first"`,
+		`</products?limit=2&offset=4&sort=name>; rel="next"`,
+		`</products?limit=2&offset=0&sort=name>; rel="prev"`,
+		`</products?limit=2&offset=4&sort=name>; rel="last"`,
+	} {
+		if !strings.Contains(link, want) {
+			t.Errorf("Link = %s; esperado %s", link, want)
+		}
+	}
+
+	// Seguindo X-Next-Cursor, a listagem chega ao fim sem repetir produtos
+	seen := map[int]bool{}
+	target := "/products?limit=2"
+	for pages := 0; target != ""; pages++ {
+		if pages > 5 {
+			t.Fatal("paginação por cursor não terminou")
+		}
+		rec := request(t, h, "GET", target, "")
+		expectStatus(t, rec, http.StatusOK)
+		var page []testProduct
+		decodeBody(t, rec, &page)
+		for _, p := range page {
+			if seen[p.ID] {
+				t.Errorf("produto %d repetido", p.ID)
+			}
+			seen[p.ID] = true
+		}
+		target = ""
+		if next := rec.Header().Get("X-Next-Cursor"); next != "" {
+			target = "/products?limit=2&cursor=" + url.QueryEscape(next)
+			if !strings.Contains(rec.Header().Get("Link"), `rel="next"`) {
+				t.Error("Link sem rel=next com X-Next-Cursor")
+			}
+		}
+	}
+	if len(seen) != 5 {
+		t.Errorf("%d produtos listados pelo cursor; esperado 5", len(seen))
+	}
+}
+
+func TestPaginationFields(t *testing.T) {
+	h := newTestRouter(t, nil)
+	createTestProduct(t, h, "Camisa", 1990)
+
+	// Na versão 2, image_url se chama image; os dois nomes são aceitos em fields=
+	for _, tt := range []struct {
+		version string
+		fields  string
+		want    []string
+	}{
+		{"1", "id,name,image", []string{"id", "name", "image_url"}},
+		{"2", "name,image_url", []string{"name", "image"}},
+	} {
+		rec := request(t, h, "GET", "/products?fields="+tt.fields, "", "Accept", "application/json; version="+tt.version)
+		expectStatus(t, rec, http.StatusOK)
+		var items []map[string]interface{}
+		decodeBody(t, rec, &items)
+		if len(items) != 1 || len(items[0]) != len(tt.want) {
+			t.Errorf("versão %s, fields=%s: %v; esperado os campos %v", tt.version, tt.fields, items, tt.want)
+			continue
+		}
+		for _, field := range tt.want {
+			if _, ok := items[0][field]; !ok {
+				t.Errorf("versão %s, fields=%s: campo %s ausente em %v", tt.version, tt.fields, field, items[0])
+			}
+		}
+	}
+}
+
+func TestPaginationRejected(t *testing.T) {
+	h := newTestRouter(t, nil)
+	createTestProduct(t, h, "Camisa", 1990)
+
+	for _, query := range []string{
+		"limit=0",
+		"limit=1001",
+		"limit=dez",
+		"offset=-1",
+		"cursor=abc&offset=1",
+		"cursor=abc",
+		"sort=description",
+		"sort=senha",
+		"fields=senha",
+	} {
+		rec := request(t, h, "GET", "/products?"+query, "")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("GET /products?%s: status %d; esperado 400", query, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"braip/internal/apperror"
+	"braip/internal/models"
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Formatos aceitos no corpo do PATCH
+const (
+	mergePatchType = "application/merge-patch+json" // RFC 7396
+	jsonPatchType  = "application/json-patch+json"  // RFC 6902
+)
+
+// errPatchConflict é o erro do JSON Patch aplicado sobre uma versão que outra escrita já substituiu
+var errPatchConflict = apperror.Conflict("o produto foi alterado por outra requisição durante o JSON Patch; aplique o patch de novo")
+
+// acceptPatch é o valor do cabeçalho Accept-Patch, que anuncia os formatos aceitos
+const acceptPatch = mergePatchType + ", " + jsonPatchType
+
+// patchOperation é uma operação de um JSON Patch
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// decodeProductPatch lê o corpo do PATCH no formato indicado pelo Content-Type.
+// current retorna o produto atual, necessário apenas para aplicar um JSON Patch.
+// No modo estrito (Prefer: handling=strict), campos desconhecidos no JSON Merge Patch são recusados.
+func decodeProductPatch(w http.ResponseWriter, r *http.Request, current func() (*models.Product, error)) (models.ProductPatch, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	strict := strictHandling(r)
+	if strict {
+		w.Header().Set("Preference-Applied", "handling=strict")
+	}
+
+	switch mediaType {
+	case mergePatchType:
+		var doc map[string]json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+			return models.ProductPatch{}, invalidJSON(err)
+		}
+		return productPatchFromFields(doc, strict)
+
+	case jsonPatchType:
+		var ops []patchOperation
+		if err := json.NewDecoder(r.Body).Decode(&ops); err != nil {
+			return models.ProductPatch{}, invalidJSON(err)
+		}
+		product, err := current()
+		if err != nil {
+			return models.ProductPatch{}, err
+		}
+		changed, err := applyJSONPatch(product, ops)
+		if err != nil {
+			return models.ProductPatch{}, err
+		}
+		return productPatchFromFields(changed, strict)
+
+	default:
+		return models.ProductPatch{}, apperror.UnsupportedMediaType("use Content-Type " + mergePatchType + " ou " + jsonPatchType)
+	}
+}
+
+// productPatchFromFields converte os campos alterados (no formato do JSON Merge Patch)
+// no patch do produto. null remove o campo: image_url fica vazio, currency volta
+// à moeda padrão e os campos obrigatórios ficam com o valor zero, recusado pela validação.
+// Como no PUT, image é aceito no lugar de image_url (que prevalece se os dois vierem),
+// os campos somente leitura são ignorados e os desconhecidos só são recusados no modo estrito.
+func productPatchFromFields(fields map[string]json.RawMessage, strict bool) (models.ProductPatch, error) {
+	var patch models.ProductPatch
+	targets := map[string]interface{}{
+		"name":        &patch.Name,
+		"price":       &patch.Price,
+		"currency":    &patch.Currency,
+		"description": &patch.Description,
+		"category_id": &patch.CategoryID,
+		"category":    &patch.Category,
+		"image_url":   &patch.ImageURL,
+	}
+	if _, ok := fields["image_url"]; !ok {
+		targets["image"] = &patch.ImageURL
+	}
+
+	// Ordem fixa, para que o erro informado seja sempre o mesmo
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	for _, field := range names {
+		raw := fields[field]
+		target, ok := targets[field]
+		if !ok {
+			if strict && field != "image" && !readOnlyFields[field] {
+				return models.ProductPatch{}, apperror.BadRequest("campo desconhecido: " + field)
+			}
+			continue
+		}
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			raw = zeroValue(field)
+		}
+		if field == "price" {
+			price, err := parsePrice(raw)
+			if err != nil {
+				return models.ProductPatch{}, err
+			}
+			patch.Price = &price
+			continue
+		}
+		if err := json.Unmarshal(raw, target); err != nil {
+			return models.ProductPatch{}, apperror.BadRequest("valor inválido no campo " + field)
+		}
+	}
+	return patch, nil
+}
+
+// zeroValue é o valor que substitui null em cada campo
+func zeroValue(field string) json.RawMessage {
+	if field == "price" || field == "category_id" {
+		return json.RawMessage("0")
+	}
+	return json.RawMessage(`""`)
+}
+
+// applyJSONPatch aplica as operações ao produto atual e retorna apenas os campos
+// que mudaram, com null nos que foram removidos. Como o produto é um objeto
+// sem aninhamento, os caminhos apontam sempre para um campo de primeiro nível.
+func applyJSONPatch(product *models.Product, ops []patchOperation) (map[string]json.RawMessage, error) {
+	encoded, err := json.Marshal(product)
+	if err != nil {
+		return nil, err
+	}
+	var original map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &original); err != nil {
+		return nil, err
+	}
+
+	doc := make(map[string]json.RawMessage, len(original))
+	for field, value := range original {
+		doc[field] = value
+	}
+
+	for i, op := range ops {
+		path, err := patchField(op.Path, original)
+		if err != nil {
+			return nil, err
+		}
+
+		switch op.Op {
+		case "add", "replace":
+			if op.Value == nil {
+				return nil, apperror.BadRequest("operação " + op.Op + " sem value")
+			}
+			doc[path] = op.Value
+		case "remove":
+			delete(doc, path)
+		case "move", "copy":
+			from, err := patchField(op.From, original)
+			if err != nil {
+				return nil, err
+			}
+			value, ok := doc[from]
+			if !ok {
+				return nil, apperror.BadRequest("campo inexistente em from: " + op.From)
+			}
+			doc[path] = value
+			if op.Op == "move" && from != path {
+				delete(doc, from)
+			}
+		case "test":
+			if !jsonEqual(doc[path], op.Value) {
+				return nil, apperror.Conflict("teste da operação " + strconv.Itoa(i) + " falhou: o valor de " + op.Path + " é diferente do esperado")
+			}
+		default:
+			return nil, apperror.BadRequest("operação de JSON Patch desconhecida: " + op.Op)
+		}
+	}
+
+	changed := make(map[string]json.RawMessage)
+	for field, before := range original {
+		after, ok := doc[field]
+		if !ok {
+			changed[field] = json.RawMessage("null")
+		} else if !jsonEqual(before, after) {
+			changed[field] = after
+		}
+	}
+	if _, ok := changed["id"]; ok {
+		return nil, apperror.BadRequest("o ID do produto não pode ser alterado")
+	}
+	return changed, nil
+}
+
+// patchField converte um JSON Pointer (/name) no nome do campo do produto
+func patchField(pointer string, fields map[string]json.RawMessage) (string, error) {
+	if !strings.HasPrefix(pointer, "/") || strings.Count(pointer, "/") != 1 {
+		return "", apperror.BadRequest("caminho inválido: " + pointer)
+	}
+	field := strings.NewReplacer("~1", "/", "~0", "~").Replace(pointer[1:])
+	if field == "image" {
+		field = "image_url" // Nome da versão 2 das respostas
+	}
+	if _, ok := fields[field]; !ok {
+		return "", apperror.BadRequest("campo desconhecido: " + pointer)
+	}
+	return field, nil
+}
+
+// jsonEqual compara dois valores JSON pelo conteúdo, ignorando a formatação
+func jsonEqual(a, b json.RawMessage) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"braip/internal/models"
+	"braip/internal/repository"
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestJSONPatchOperations(t *testing.T) {
+	tests := []struct {
+		name  string
+		ops   string
+		check func(p testProduct) bool
+	}{
+		{"add", `[{"op": "add", "path": "/description", "value": "Nova descrição"}]`,
+			func(p testProduct) bool { return p.Description == "Nova descrição" }},
+		{"replace", `[{"op": "replace", "path": "/price", "value": 1790}]`,
+			func(p testProduct) bool { return p.Price == 1790 }},
+		{"remove", `[{"op": "remove", "path": "/image_url"}]`,
+			func(p testProduct) bool { return p.ImageURL == "" }},
+		{"remove com o nome da versão 2", `[{"op": "remove", "path": "/image"}]`,
+			func(p testProduct) bool { return p.ImageURL == "" }},
+		{"copy", `[{"op": "copy", "from": "/name", "path": "/description"}]`,
+			func(p testProduct) bool { return p.Description == p.Name }},
+		{"move", `[{"op": "move", "from": "/image_url", "path": "/description"}]`,
+			func(p testProduct) bool { return p.Description == "https://example.com/p.png" && p.ImageURL == "" }},
+		{"test seguido de replace", `[{"op": "test", "path": "/price", "value": 1990}, {"op": "replace", "path": "/price", "value": 1490}]`,
+			func(p testProduct) bool { return p.Price == 1490 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestRouter(t, nil)
+			created := createTestProduct(t, h, "Camisa", 1990)
+
+			rec := request(t, h, "PATCH", "/products/"+strconv.Itoa(created.ID), tt.ops, "Content-Type", jsonPatchType)
+			expectStatus(t, rec, http.StatusOK)
+			got := getTestProduct(t, h, created.ID)
+			if !tt.check(got) {
+				t.Errorf("produto depois do patch = %+v", got)
+			}
+			if got.Version != created.Version+1 {
+				t.Errorf("versão = %d; esperado %d", got.Version, created.Version+1)
+			}
+		})
+	}
+}
+
+func TestJSONPatchRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		ops    string
+		status int
+	}{
+		{"test falhou", `[{"op": "test", "path": "/price", "value": 1}, {"op": "replace", "path": "/price", "value": 1490}]`, http.StatusConflict},
+		{"test depois de uma operação que o invalida", `[{"op": "replace", "path": "/price", "value": 1490}, {"op": "test", "path": "/price", "value": 1990}]`, http.StatusConflict},
+		{"operação desconhecida", `[{"op": "increment", "path": "/price", "value": 1}]`, http.StatusBadRequest},
+		{"campo desconhecido", `[{"op": "replace", "path": "/stock", "value": 1}]`, http.StatusBadRequest},
+		{"caminho aninhado", `[{"op": "replace", "path": "/name/0", "value": "x"}]`, http.StatusBadRequest},
+		{"replace sem value", `[{"op": "replace", "path": "/price"}]`, http.StatusBadRequest},
+		{"alteração do id", `[{"op": "replace", "path": "/id", "value": 99}]`, http.StatusBadRequest},
+		{"remoção de campo obrigatório", `[{"op": "remove", "path": "/name"}]`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestRouter(t, nil)
+			created := createTestProduct(t, h, "Camisa", 1990)
+
+			rec := request(t, h, "PATCH", "/products/"+strconv.Itoa(created.ID), tt.ops, "Content-Type", jsonPatchType)
+			expectStatus(t, rec, tt.status)
+
+			// Nenhuma operação é aplicada quando uma delas falha
+			if got := getTestProduct(t, h, created.ID); got != created {
+				t.Errorf("produto alterado por um patch recusado: %+v", got)
+			}
+		})
+	}
+}
+
+// interleavedStore executa write logo depois da primeira leitura de um produto,
+// simulando outra requisição que grava entre a leitura do PATCH e a sua escrita
+type interleavedStore struct {
+	repository.ProductStore
+	once  sync.Once
+	write func()
+}
+
+func (s *interleavedStore) GetProductByID(ctx context.Context, id int) (*models.Product, error) {
+	product, err := s.ProductStore.GetProductByID(ctx, id)
+	s.once.Do(s.write)
+	return product, err
+}
+
+func TestJSONPatchConcurrentChange(t *testing.T) {
+	memory := repository.NewMemoryProductRepository()
+	setup := newTestRouter(t, memory)
+	created := createTestProduct(t, setup, "Camisa", 1990)
+
+	concurrentPrice := 2490
+	store := &interleavedStore{ProductStore: memory, write: func() {
+		err := memory.PatchProduct(context.Background(), created.ID, models.ProductPatch{Price: &concurrentPrice})
+		if err != nil {
+			t.Errorf("escrita concorrente: %v", err)
+		}
+	}}
+	h := newTestRouter(t, store)
+
+	// O test passa na versão lida, mas o produto muda antes da escrita
+	ops := `[{"op": "test", "path": "/price", "value": 1990}, {"op": "replace", "path": "/description", "value": "Em promoção"}]`
+	rec := request(t, h, "PATCH", "/products/"+strconv.Itoa(created.ID), ops, "Content-Type", jsonPatchType)
+	expectStatus(t, rec, http.StatusConflict)
+
+	got := getTestProduct(t, h, created.ID)
+	if got.Price != concurrentPrice || got.Description != created.Description {
+		t.Errorf("produto = %+v; esperado apenas a escrita concorrente", got)
+	}
+
+	// Reaplicado sobre a versão nova, o test falha com o preço alterado
+	rec = request(t, h, "PATCH", "/products/"+strconv.Itoa(created.ID), ops, "Content-Type", jsonPatchType)
+	expectStatus(t, rec, http.StatusConflict)
+}
+
+func TestJSONPatchIfMatch(t *testing.T) {
+	h := newTestRouter(t, nil)
+	created := createTestProduct(t, h, "Camisa", 1990)
+	target := "/products/" + strconv.Itoa(created.ID)
+	ops := `[{"op": "replace", "path": "/price", "value": 1490}]`
+
+	rec := request(t, h, "PATCH", target, ops, "Content-Type", jsonPatchType, "If-Match", `"2"`)
+	expectStatus(t, rec, http.StatusPreconditionFailed)
+
+	rec = request(t, h, "PATCH", target, ops, "Content-Type", jsonPatchType, "If-Match", productETag(&models.Product{Version: created.Version}))
+	expectStatus(t, rec, http.StatusOK)
+	if etag := rec.Header().Get("ETag"); etag != productETag(&models.Product{Version: created.Version + 1}) {
+		t.Errorf("ETag = %s; esperado o da versão %d", etag, created.Version+1)
+	}
+}
+
+func TestPatchUnsupportedMediaType(t *testing.T) {
+	h := newTestRouter(t, nil)
+	created := createTestProduct(t, h, "Camisa", 1990)
+
+	rec := request(t, h, "PATCH", "/products/"+strconv.Itoa(created.ID), `{"price": 1490}`)
+	expectStatus(t, rec, http.StatusUnsupportedMediaType)
+	if got := rec.Header().Get("Accept-Patch"); got != acceptPatch {
+		t.Errorf("Accept-Patch = %q; esperado %q", got, acceptPatch)
+	}
+}
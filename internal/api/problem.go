@@ -0,0 +1,199 @@
+package api
+
+import (
+	"braip/internal/apperror"
+	"braip/internal/repository"
+	"braip/internal/validation"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// errInvalidID é o erro dos IDs de rota que não são números
+var errInvalidID = apperror.BadRequest("ID inválido")
+
+// problemTypeBase é o prefixo das URIs que identificam cada tipo de problema.
+// As URIs são estáveis: os clientes podem compará-las em vez das mensagens.
+const problemTypeBase = "/problems/"
+
+// Problem é o corpo das respostas de erro no formato RFC 7807 (application/problem+json)
+type Problem struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	TraceID  string            `json:"trace_id,omitempty"`
+	Errors   validation.Errors `json:"errors,omitempty"` // Campos inválidos (apenas em erros de validação)
+
+	ExistingID int `json:"existing_id,omitempty"` // Produto que já usa o nome (apenas em conflitos de nome)
+}
+
+// problemStatus é o status HTTP de cada tipo de erro
+var problemStatus = map[apperror.Kind]int{
+	apperror.KindBadRequest:       http.StatusBadRequest,
+	apperror.KindValidation:       http.StatusBadRequest,
+	apperror.KindNotFound:         http.StatusNotFound,
+	apperror.KindConflict:         http.StatusConflict,
+	apperror.KindPrecondition:     http.StatusPreconditionFailed,
+	apperror.KindUnsupported:      http.StatusUnsupportedMediaType,
+	apperror.KindNotAcceptable:    http.StatusNotAcceptable,
+	apperror.KindUnprocessable:    http.StatusUnprocessableEntity,
+	apperror.KindFailedDependency: http.StatusFailedDependency,
+	apperror.KindInternal:         http.StatusInternalServerError,
+}
+
+// problemTitles são os títulos de cada tipo de erro por idioma; o primeiro idioma é o padrão
+var problemTitles = map[string]map[apperror.Kind]string{
+	"pt": {
+		apperror.KindBadRequest:       "Requisição inválida",
+		apperror.KindValidation:       "Dados inválidos",
+		apperror.KindNotFound:         "Recurso não encontrado",
+		apperror.KindConflict:         "Conflito com o estado atual do recurso",
+		apperror.KindPrecondition:     "Pré-condição não atendida",
+		apperror.KindUnsupported:      "Formato do corpo não suportado",
+		apperror.KindNotAcceptable:    "Formato de resposta não suportado",
+		apperror.KindUnprocessable:    "Requisição não processável",
+		apperror.KindFailedDependency: "Operação desfeita pela falha de outra",
+		apperror.KindInternal:         "Erro interno do servidor",
+	},
+	"en": {
+		apperror.KindBadRequest:       "Bad request",
+		apperror.KindValidation:       "Validation failed",
+		apperror.KindNotFound:         "Resource not found",
+		apperror.KindConflict:         "Conflict with the current state of the resource",
+		apperror.KindPrecondition:     "Precondition failed",
+		apperror.KindUnsupported:      "Unsupported media type",
+		apperror.KindNotAcceptable:    "Not acceptable",
+		apperror.KindUnprocessable:    "Unprocessable content",
+		apperror.KindFailedDependency: "Failed dependency",
+		apperror.KindInternal:         "Internal server error",
+	},
+}
+
+const defaultLanguage = "pt"
+
+// writeError responde com o problema correspondente ao erro
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := newProblem(r, err)
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// newProblem monta o problema correspondente ao erro. Erros internos são
+// registrados no log com o trace ID e a causa não é exposta ao cliente.
+func newProblem(r *http.Request, err error) Problem {
+	kind := apperror.KindOf(err)
+	traceID := RequestID(r.Context())
+
+	problem := Problem{
+		Type:     problemTypeBase + string(kind),
+		Title:    problemTitles[language(r)][kind],
+		Status:   problemStatus[kind],
+		Detail:   apperror.MessageOf(err),
+		Instance: r.URL.Path,
+		TraceID:  traceID,
+	}
+
+	var fieldErrors validation.Errors
+	if errors.As(err, &fieldErrors) {
+		problem.Errors = fieldErrors
+	}
+	var nameInUse *repository.NameInUseError
+	if errors.As(err, &nameInUse) {
+		problem.ExistingID = nameInUse.ProductID
+	}
+
+	if kind == apperror.KindInternal {
+		log.Printf("[%s] %s %s: %v", traceID, r.Method, r.URL.Path, err)
+	}
+	return problem
+}
+
+// badRequest converte um erro de leitura da requisição (filtro, ordenação, ...) em erro de requisição inválida
+func badRequest(err error) error {
+	if apperror.KindOf(err) != apperror.KindInternal {
+		return err // Já tem um tipo definido
+	}
+	return apperror.BadRequest(err.Error())
+}
+
+// invalidJSON converte um erro de leitura do corpo da requisição em erro de requisição inválida
+func invalidJSON(err error) error {
+	return apperror.BadRequest("corpo da requisição inválido: " + err.Error())
+}
+
+// language escolhe o idioma dos títulos pelo cabeçalho Accept-Language
+// (na ordem em que os idiomas aparecem; os pesos q= não são considerados)
+func language(r *http.Request) string {
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if _, ok := problemTitles[base]; ok {
+			return base
+		}
+	}
+	return defaultLanguage
+}
+
+// requestIDKey é a chave do ID da requisição no contexto
+type requestIDKey struct{}
+
+// validRequestID limita os IDs aceitos do cliente, para que não poluam logs e cabeçalhos
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestIDMiddleware identifica cada requisição pelo cabeçalho X-Request-ID.
+// O ID enviado pelo cliente (ou por um proxy) é mantido; sem ele, um novo é gerado.
+// O ID volta no cabeçalho da resposta e no trace_id dos erros.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// RequestID retorna o ID da requisição guardado no contexto
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// NotFoundHandler responde às rotas inexistentes no formato problem+json
+func NotFoundHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, apperror.NotFound("rota não encontrada"))
+	})
+}
+
+// MethodNotAllowedHandler responde aos métodos não suportados no formato problem+json
+func MethodNotAllowedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		problem := Problem{
+			Type:     "about:blank", // Sem tipo próprio: o status já descreve o problema
+			Title:    http.StatusText(http.StatusMethodNotAllowed),
+			Status:   http.StatusMethodNotAllowed,
+			Instance: r.URL.Path,
+			TraceID:  RequestID(r.Context()),
+		}
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(problem.Status)
+		json.NewEncoder(w).Encode(problem)
+	})
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"braip/internal/apperror"
+	"braip/internal/models"
+	"braip/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Cada tipo de erro tem o seu status, URI e título em todos os idiomas
+func TestProblemKinds(t *testing.T) {
+	for kind, status := range problemStatus {
+		req := httptest.NewRequest("GET", "/products/1", nil)
+		problem := newProblem(req, apperror.New(kind, "detalhe"))
+		if problem.Status != status || problem.Type != "/problems/"+string(kind) || problem.Instance != "/products/1" {
+			t.Errorf("%s: %+v", kind, problem)
+		}
+		for language, titles := range problemTitles {
+			if titles[kind] == "" {
+				t.Errorf("%s: sem título em %s", kind, language)
+			}
+		}
+	}
+
+	// Erros sem tipo são internos; erros embrulhados mantêm o tipo
+	req := httptest.NewRequest("GET", "/", nil)
+	if problem := newProblem(req, errors.New("falha")); problem.Status != http.StatusInternalServerError {
+		t.Errorf("erro sem tipo: status %d", problem.Status)
+	}
+	wrapped := fmt.Errorf("ao buscar: %w", repository.ErrProductNotFound)
+	if problem := newProblem(req, wrapped); problem.Status != http.StatusNotFound || problem.Detail != "produto não encontrado" {
+		t.Errorf("erro embrulhado: %+v", problem)
+	}
+}
+
+func TestProblemLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "Recurso não encontrado"},
+		{"en", "Resource not found"},
+		{"en-US,pt;q=0.9", "Resource not found"},
+		{"fr-FR, EN;q=0.5", "Resource not found"},
+		{"pt-BR", "Recurso não encontrado"},
+		{"de", "Recurso não encontrado"},
+	}
+	h := newTestRouter(t, nil)
+	for _, tt := range tests {
+		rec := request(t, h, "GET", "/products/999", "", "Accept-Language", tt.header)
+		expectStatus(t, rec, http.StatusNotFound)
+		var problem problemBody
+		decodeBody(t, rec, &problem)
+		if problem.Title != tt.want {
+			t.Errorf("Accept-Language %q: título %q; esperado %q", tt.header, problem.Title, tt.want)
+		}
+	}
+}
+
+// failingStore falha as leituras de produto com um erro interno
+type failingStore struct {
+	repository.ProductStore
+}
+
+func (s failingStore) GetProductByID(ctx context.Context, id int) (*models.Product, error) {
+	return nil, errors.New("dial tcp 10.0.0.5:5432: senha incorreta para o usuário braip")
+}
+
+// Todas as respostas de erro são problem+json, com o ID da requisição em trace_id
+func TestProblemResponses(t *testing.T) {
+	h := newTestRouter(t, failingStore{repository.NewMemoryProductRepository()})
+	tests := []struct {
+		name, method, target, body string
+		status                     int
+		problemType                string
+	}{
+		{"rota inexistente", "GET", "/nada", "", http.StatusNotFound, "/problems/not-found"},
+		{"método não suportado", "PATCH", "/categories", "", http.StatusMethodNotAllowed, "about:blank"},
+		{"ID inválido", "GET", "/products/abc", "", http.StatusBadRequest, "/problems/bad-request"},
+		{"JSON inválido", "POST", "/products", `{"name": `, http.StatusBadRequest, "/problems/bad-request"},
+		{"filtro inválido", "GET", "/products?filter=price >", "", http.StatusBadRequest, "/problems/bad-request"},
+		{"erro interno", "GET", "/products/1", "", http.StatusInternalServerError, "/problems/internal"},
+	}
+	for _, tt := range tests {
+		rec := request(t, h, tt.method, strings.ReplaceAll(tt.target, " ", "%20"), tt.body, "X-Request-ID", "req-123")
+		if rec.Code != tt.status {
+			t.Errorf("%s: status %d; esperado %d", tt.name, rec.Code, tt.status)
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+			t.Errorf("%s: Content-Type %q", tt.name, ct)
+		}
+		var problem problemBody
+		decodeBody(t, rec, &problem)
+		if problem.Type != tt.problemType || problem.Status != tt.status || problem.TraceID != "req-123" || problem.Title == "" {
+			t.Errorf("%s: %+v", tt.name, problem)
+		}
+		// A causa dos erros internos fica só no log
+		if tt.status == http.StatusInternalServerError && strings.Contains(rec.Body.String(), "senha") {
+			t.Errorf("%s: causa exposta ao cliente: %s", tt.name, rec.Body.String())
+		}
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	h := newTestRouter(t, nil)
+
+	rec := request(t, h, "GET", "/products/999", "", "X-Request-ID", "abc-123_x.y")
+	if got := rec.Header().Get("X-Request-ID"); got != "abc-123_x.y" {
+		t.Errorf("ID do cliente não mantido: %q", got)
+	}
+
+	// IDs com caracteres inválidos ou longos demais são trocados por um novo
+	for _, id := range []string{"", "com espaço", "a\r\nSet-Cookie: x", strings.Repeat("a", 65)} {
+		rec := request(t, h, "GET", "/products/999", "", "X-Request-ID", id)
+		got := rec.Header().Get("X-Request-ID")
+		if got == id || !validRequestID.MatchString(got) {
+			t.Errorf("ID %q: resposta com %q", id, got)
+		}
+		var problem problemBody
+		decodeBody(t, rec, &problem)
+		if problem.TraceID != got {
+			t.Errorf("trace_id %q diferente do X-Request-ID %q", problem.TraceID, got)
+		}
+	}
+}
@@ -1,176 +1,610 @@
 package api
 
 import (
+	"braip/internal/apperror"
+	"braip/internal/filter"
 	"braip/internal/models"
 	"braip/internal/services"
+	"braip/internal/suggest"
+	"braip/internal/repository"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
 
-// GetProducts retorna todos os produtos
-func GetProducts(w http.ResponseWriter, r *http.Request) {
-	products, err := services.GetProducts()
+// ProductHandler expõe os endpoints HTTP de produtos
+type ProductHandler struct {
+	service   *services.ProductService
+	converter *services.PriceConverter // Conversão de moeda das leituras (?currency=)
+}
+
+// NewProductHandler cria os handlers de produtos usando o serviço e o conversor de moeda informados
+func NewProductHandler(service *services.ProductService, converter *services.PriceConverter) *ProductHandler {
+	return &ProductHandler{service: service, converter: converter}
+}
+
+// GetProducts retorna uma página de produtos.
+// Aceita filtros (name=camisa, price[gte]=1000, id=1,2,3, filter=<expressão>),
+// limit/offset ou cursor para paginação, sort=price,-name para ordenação
+// e fields=id,name para retornar apenas alguns campos.
+func (h *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r)
 	if err != nil {
-		log.Printf("Erro ao buscar produtos: %v", err)
-		http.Error(w, "Erro ao buscar produtos", http.StatusInternalServerError)
+		writeError(w, r, badRequest(err))
 		return
 	}
 
-	if len(products) == 0 {
-		log.Println("Nenhum produto encontrado")
-		http.Error(w, "Nenhum produto encontrado", http.StatusNotFound)
+	opts.Filter, err = filter.FromQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, r, badRequest(err))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(products)
+	page, err := h.service.GetProducts(r.Context(), opts)
+	if err == nil && page.Total == 0 && opts.Filter == nil {
+		writeError(w, r, apperror.NotFound("nenhum produto encontrado"))
+		return
+	}
+
+	h.writeProductPage(w, r, opts, page, err)
 }
 
-// CreateProduct cria um novo produto
-func CreateProduct(w http.ResponseWriter, r *http.Request) {
-	var product models.Product
-	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+// writeProductPage escreve uma página de produtos com os cabeçalhos de paginação,
+// com os preços convertidos para a moeda de ?currency=, se informada
+func (h *ProductHandler) writeProductPage(w http.ResponseWriter, r *http.Request, opts repository.ListOptions, page *repository.ProductPage, err error) {
+	if err == nil {
+		err = h.convert(r, page.Products)
+	}
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	// Validação dos dados
-	if product.Name == "" || product.Price <= 0 || product.Description == "" || product.Category == "" {
-		http.Error(w, "Campos obrigatórios não preenchidos corretamente", http.StatusBadRequest)
+	setPaginationHeaders(w, r, opts, page)
+
+	var body interface{}
+	version := responseVersion(r.Context())
+	if len(opts.Fields) > 0 {
+		selected, err := selectFields(page.Products, opts.Fields, version)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		body = selected
+	} else {
+		body = newProductResponses(page.Products, version)
+	}
+
+	// O corpo é montado antes de ser enviado para que o ETag da página possa ser calculado
+	data, err := json.Marshal(body)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	data = append(data, '\n')
+	setProductContentType(w, r)
+	if notModified(w, r, bodyETag(data)) {
+		return
+	}
+	w.Write(data)
+}
+
+// CreateProduct cria um novo produto
+func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
+	product, err := decodeProductRequest(w, r)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	// Cria o produto e obtém o ID gerado
-	id, err := services.CreateProduct(product)
+	// Valida e cria o produto, obtendo o ID gerado
+	id, err := h.service.CreateProduct(r.Context(), product)
 	if err != nil {
-		http.Error(w, "Erro ao criar produto", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	// Atualiza o ID do produto com o valor gerado
+	// Atualiza o ID do produto com o valor gerado e lê a categoria associada
 	product.ID = int(id)
+	if stored, err := h.service.GetProductByID(r.Context(), product.ID); err == nil && stored != nil {
+		product = *stored
+	}
 
 	// Retorna o produto criado com o ID correto
-	w.WriteHeader(http.StatusCreated)
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(product)
+	w.Header().Set("ETag", productETag(&product))
+	writeProduct(w, r, http.StatusCreated, &product)
 }
 
-// GetProductByID retorna um produto pelo ID
-func GetProductByID(w http.ResponseWriter, r *http.Request) {
+// GetProductByID retorna um produto pelo ID, com o ETag da sua versão.
+// Responde 304 se o cliente já tiver essa versão (If-None-Match).
+func (h *ProductHandler) GetProductByID(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id, err := strconv.Atoi(params["id"])
 	if err != nil {
-		http.Error(w, "ID inválido", http.StatusBadRequest)
+		writeError(w, r, errInvalidID)
 		return
 	}
 
-	product, err := services.GetProductByID(id)
+	product, err := h.service.GetProductByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Erro ao buscar produto", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	if product == nil {
-		http.Error(w, "Produto não encontrado", http.StatusNotFound)
+	// Com ?currency=, a resposta também depende da cotação: o ETag vem do corpo
+	if r.URL.Query().Get("currency") != "" {
+		products := []models.Product{*product}
+		if err := h.convert(r, products); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		data, err := json.Marshal(newProductResponse(&products[0], responseVersion(r.Context())))
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		data = append(data, '\n')
+		setProductContentType(w, r)
+		if notModified(w, r, bodyETag(data)) {
+			return
+		}
+		w.Write(data)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(product)
+	// O ETag identifica a versão do produto; como o formato depende do Accept, a resposta tem Vary: Accept
+	setProductContentType(w, r)
+	if notModified(w, r, productETag(product)) {
+		return
+	}
+	writeProduct(w, r, http.StatusOK, product)
 }
 
-// UpdateProduct atualiza um produto
-func UpdateProduct(w http.ResponseWriter, r *http.Request) {
+// UpdateProduct substitui um produto e retorna o produto como ficou gravado.
+// Sem cabeçalhos especiais, o produto precisa existir (caso contrário, 404).
+// Com If-None-Match: *, o produto é criado com o ID da URL apenas se ainda não existir (caso contrário, 412).
+// Com ?upsert=true, o produto é substituído ou criado com o ID da URL.
+// Com If-Match, o produto só é substituído se ainda estiver na versão do ETag (caso contrário, 412).
+func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id, err := strconv.Atoi(params["id"])
+	if err != nil || id <= 0 {
+		writeError(w, r, errInvalidID)
+		return
+	}
+
+	product, err := decodeProductRequest(w, r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// A versão enviada no corpo é ignorada: a versão esperada vem apenas do If-Match
+	product.Version, err = expectedVersion(r, h.currentProduct(r, id).get)
 	if err != nil {
-		http.Error(w, "ID inválido", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	var product models.Product
-	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var stored *models.Product
+	status := http.StatusOK
+	switch {
+	case r.Header.Get("If-None-Match") == "*":
+		stored, err = h.service.CreateProductWithID(r.Context(), id, product)
+		status = http.StatusCreated
+	case r.URL.Query().Get("upsert") == "true":
+		var created bool
+		stored, created, err = h.service.UpsertProduct(r.Context(), id, product)
+		if created {
+			status = http.StatusCreated
+		}
+	default:
+		stored, err = h.service.UpdateProduct(r.Context(), id, product)
+	}
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if product.Name == "" || product.Price <= 0 || product.Description == "" || product.Category == "" {
-		http.Error(w, "Campos obrigatórios não preenchidos corretamente", http.StatusBadRequest)
+	w.Header().Set("ETag", productETag(stored))
+	writeProduct(w, r, status, stored)
+}
+
+// PatchProduct altera apenas os campos enviados e retorna o produto como ficou gravado.
+// Aceita JSON Merge Patch (application/merge-patch+json), com os campos a alterar,
+// e JSON Patch (application/json-patch+json), com uma lista de operações.
+// Com If-Match, o produto só é alterado se ainda estiver na versão do ETag (caso contrário, 412).
+func (h *ProductHandler) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
+	if err != nil {
+		writeError(w, r, errInvalidID)
 		return
 	}
 
-	if err := services.UpdateProduct(id, product); err != nil {
-		http.Error(w, "Erro ao atualizar produto", http.StatusInternalServerError)
+	current := h.currentProduct(r, id)
+	patch, err := decodeProductPatch(w, r, current.get)
+	if err != nil {
+		if apperror.KindOf(err) == apperror.KindUnsupported {
+			w.Header().Set("Accept-Patch", acceptPatch)
+		}
+		writeError(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(product)
+	patch.Version, err = expectedVersion(r, current.get)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// O JSON Patch (inclusive os seus test) foi aplicado sobre o produto lido: a escrita
+	// só acontece se ele ainda estiver nessa versão, para que as operações valham juntas
+	// (RFC 6902). Uma alteração concorrente recebe 409 e o cliente pode tentar de novo.
+	pinned := patch.Version == 0 && current.product != nil
+	if pinned {
+		patch.Version = current.product.Version
+	}
+
+	stored, err := h.service.PatchProduct(r.Context(), id, patch)
+	if pinned && errors.Is(err, repository.ErrVersionMismatch) {
+		err = errPatchConflict
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", productETag(stored))
+	writeProduct(w, r, http.StatusOK, stored)
 }
 
-// DeleteProduct remove um produto
-func DeleteProduct(w http.ResponseWriter, r *http.Request) {
+// DeleteProduct move um produto para a lixeira; responde 404 se ele não existir.
+// Com If-Match, o produto só é removido se ainda estiver na versão do ETag (caso contrário, 412).
+func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id, err := strconv.Atoi(params["id"])
 	if err != nil {
-		http.Error(w, "ID inválido", http.StatusBadRequest)
+		writeError(w, r, errInvalidID)
 		return
 	}
 
-	if err := services.DeleteProduct(id); err != nil {
-		http.Error(w, "Erro ao excluir produto", http.StatusInternalServerError)
+	version, err := expectedVersion(r, h.currentProduct(r, id).get)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.service.DeleteProduct(r.Context(), id, version); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// GetTrash retorna uma página dos produtos da lixeira (GET /products/trash).
+// Aceita os mesmos filtros, paginação, ordenação e seleção de campos de GET /products.
+func (h *ProductHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r)
+	if err != nil {
+		writeError(w, r, badRequest(err))
+		return
+	}
+
+	opts.Filter, err = filter.FromQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, r, badRequest(err))
+		return
+	}
+
+	page, err := h.service.GetTrash(r.Context(), opts)
+	h.writeProductPage(w, r, opts, page, err)
+}
+
+// RestoreProduct tira um produto da lixeira e o retorna (POST /products/{id}/restore)
+func (h *ProductHandler) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
+	if err != nil {
+		writeError(w, r, errInvalidID)
+		return
+	}
+
+	product, err := h.service.RestoreProduct(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", productETag(product))
+	writeProduct(w, r, http.StatusOK, product)
+}
+
+// GetPriceHistory retorna os preços de um produto, do mais antigo para o mais recente
+// (GET /products/{id}/prices)
+func (h *ProductHandler) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, errInvalidID)
+		return
+	}
+
+	history, err := h.service.GetPriceHistory(r.Context(), id)
+	if err == nil {
+		err = h.converter.ConvertHistory(r.Context(), history, r.URL.Query().Get("currency"))
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if history == nil {
+		history = []models.PriceChange{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// Período padrão e máximo, em dias, da consulta de quedas de preço
+const (
+	defaultPriceDropDays = 30
+	maxPriceDropDays     = 3650
+)
+
+// GetPriceDrops retorna os produtos cujo preço caiu pelo menos min_drop% nos últimos
+// days dias (padrão 30), da maior queda para a menor (GET /products/price-drops)
+func (h *ProductHandler) GetPriceDrops(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	minDrop, err := strconv.ParseFloat(query.Get("min_drop"), 64)
+	if err != nil || minDrop <= 0 || minDrop > 100 {
+		writeError(w, r, apperror.BadRequest("parâmetro 'min_drop' deve ser um percentual maior que 0 e até 100"))
+		return
+	}
+
+	days := defaultPriceDropDays
+	if v := query.Get("days"); v != "" {
+		days, err = strconv.Atoi(v)
+		if err != nil || days <= 0 || days > maxPriceDropDays {
+			writeError(w, r, apperror.BadRequest(fmt.Sprintf("parâmetro 'days' deve ser um número entre 1 e %d", maxPriceDropDays)))
+			return
+		}
+	}
+
+	limit, _, err := parseLimitOffset(url.Values{"limit": query["limit"]})
+	if err != nil {
+		writeError(w, r, badRequest(err))
+		return
+	}
+
+	drops, err := h.service.GetPriceDrops(r.Context(), minDrop, days, limit)
+	if err == nil {
+		err = h.converter.ConvertDrops(r.Context(), drops, query.Get("currency"))
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	results := make([]priceDropResponse, len(drops))
+	for i := range drops {
+		results[i] = priceDropResponse{
+			productResponse: newProductResponse(&drops[i].Product, responseVersion(r.Context())),
+			PreviousPrice:   drops[i].PreviousPrice,
+			DropPercent:     drops[i].DropPercent,
+		}
+	}
+
+	setProductContentType(w, r)
+	json.NewEncoder(w).Encode(results)
+}
+
+// priceDropResponse é um item da resposta de GET /products/price-drops: o produto,
+// o preço no início do período e a queda em relação a ele
+type priceDropResponse struct {
+	productResponse
+	PreviousPrice int     `json:"previous_price"`
+	DropPercent   float64 `json:"drop_percent"`
+}
+
+// convert converte os preços dos produtos para a moeda de ?currency=, se informada
+func (h *ProductHandler) convert(r *http.Request, products []models.Product) error {
+	return h.converter.ConvertProducts(r.Context(), products, r.URL.Query().Get("currency"))
+}
+
+// currentProduct retorna a função que lê o produto atual, usada pelo If-Match e pelo JSON Patch.
+// O produto é lido no máximo uma vez: os dois o avaliam na mesma versão.
+func (h *ProductHandler) currentProduct(r *http.Request, id int) *productSnapshot {
+	return &productSnapshot{load: func() (*models.Product, error) {
+		return h.service.GetProductByID(r.Context(), id)
+	}}
+}
+
+// productSnapshot guarda o produto lido por currentProduct
+type productSnapshot struct {
+	load    func() (*models.Product, error)
+	loaded  bool
+	product *models.Product
+	err     error
+}
+
+// get lê o produto na primeira chamada e repete o resultado nas seguintes
+func (s *productSnapshot) get() (*models.Product, error) {
+	if !s.loaded {
+		s.product, s.err = s.load()
+		s.loaded = true
+	}
+	return s.product, s.err
+}
+
+// searchResult é um item da resposta da busca textual: o produto, sua relevância
+// e os trechos de nome e descrição com os termos encontrados destacados
+type searchResult struct {
+	productResponse
+	Score     float64           `json:"score"`
+	Highlight map[string]string `json:"highlight"`
+}
+
+// SearchProducts faz a busca textual em nome e descrição (GET /products/search?q=...).
+// Ignora acentos e maiúsculas, aceita prefixos e ordena por relevância.
+// Também aceita os filtros e a paginação (limit/offset) de GET /products.
+func (h *ProductHandler) SearchProducts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if strings.TrimSpace(q) == "" {
+		writeError(w, r, apperror.BadRequest("Parâmetro 'q' é obrigatório"))
+		return
+	}
+
+	opts, err := parseListOptions(r)
+	if err != nil {
+		writeError(w, r, badRequest(err))
+		return
+	}
+	if opts.Cursor != "" || len(opts.Sort) > 0 || len(opts.Fields) > 0 {
+		writeError(w, r, apperror.BadRequest("A busca textual aceita apenas limit e offset para paginação"))
+		return
+	}
+
+	opts.Filter, err = filter.FromQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, r, badRequest(err))
+		return
+	}
+
+	page, err := h.service.SearchProducts(r.Context(), q, opts)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	products := make([]models.Product, len(page.Results))
+	for i, res := range page.Results {
+		products[i] = res.Product
+	}
+	if err := h.convert(r, products); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	results := make([]searchResult, 0, len(page.Results))
+	for i, res := range page.Results {
+		results = append(results, searchResult{
+			productResponse: newProductResponse(&products[i], responseVersion(r.Context())),
+			Score:   res.Score,
+			Highlight: map[string]string{
+				"name":        res.NameHighlight,
+				"description": res.DescriptionHighlight,
+			},
+		})
+	}
+
+	setPaginationHeaders(w, r, opts, &repository.ProductPage{Total: page.Total})
+	setProductContentType(w, r)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false) // Mantém os marcadores <mark> legíveis
+	encoder.Encode(results)
+}
+
+// SuggestProducts retorna sugestões de nomes e categorias para o autocompletar
+// (GET /products/suggest?prefix=cam). Parâmetros opcionais: limit (limitado ao
+// máximo configurado no servidor), typos (erros de digitação tolerados, 0 a 2)
+// e type (name ou category).
+func (h *ProductHandler) SuggestProducts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	prefix := query.Get("prefix")
+	if strings.TrimSpace(prefix) == "" {
+		writeError(w, r, apperror.BadRequest("Parâmetro 'prefix' é obrigatório"))
+		return
+	}
+
+	opts := suggest.Options{MaxTypos: 1, Kind: query.Get("type")}
+	if opts.Kind != "" && opts.Kind != suggest.KindName && opts.Kind != suggest.KindCategory {
+		writeError(w, r, apperror.BadRequest("Parâmetro 'type' deve ser 'name' ou 'category'"))
+		return
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			writeError(w, r, apperror.BadRequest("Parâmetro 'limit' deve ser um número maior que zero"))
+			return
+		}
+		opts.Limit = limit
+	}
+	if v := query.Get("typos"); v != "" {
+		typos, err := strconv.Atoi(v)
+		if err != nil || typos < 0 || typos > 2 {
+			writeError(w, r, apperror.BadRequest("Parâmetro 'typos' deve ser 0, 1 ou 2"))
+			return
+		}
+		opts.MaxTypos = typos
+	}
+
+	suggestions, err := h.service.SuggestProducts(prefix, opts)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if suggestions == nil {
+		suggestions = []suggest.Suggestion{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suggestions)
+}
+
+// As rotas de busca abaixo são atalhos mantidos por compatibilidade:
+// equivalem a GET /products com o filtro correspondente.
+
 // SearchProductsByNameAndCategory busca produtos por nome e categoria
-func SearchProductsByNameAndCategory(w http.ResponseWriter, r *http.Request) {
+// (equivale a GET /products?name=...&category[eq]=...)
+func (h *ProductHandler) SearchProductsByNameAndCategory(w http.ResponseWriter, r *http.Request) {
 	name := r.URL.Query().Get("name")
 	category := r.URL.Query().Get("category")
 
 	if name == "" || category == "" {
-		http.Error(w, "Parâmetros 'name' e 'category' são obrigatórios", http.StatusBadRequest)
+		writeError(w, r, apperror.BadRequest("Parâmetros 'name' e 'category' são obrigatórios"))
 		return
 	}
 
-	products, err := services.SearchProductsByNameAndCategory(name, category)
+	opts, err := parseListOptions(r)
 	if err != nil {
-		http.Error(w, "Erro ao buscar produtos", http.StatusInternalServerError)
+		writeError(w, r, badRequest(err))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(products)
+	page, err := h.service.SearchProductsByNameAndCategory(r.Context(), name, category, opts)
+	h.writeProductPage(w, r, opts, page, err)
 }
 
 // SearchProductsByCategory busca produtos por categoria
-func SearchProductsByCategory(w http.ResponseWriter, r *http.Request) {
+// (equivale a GET /products?category[eq]=...)
+func (h *ProductHandler) SearchProductsByCategory(w http.ResponseWriter, r *http.Request) {
 	category := r.URL.Query().Get("category")
 
 	if category == "" {
-		http.Error(w, "Parâmetro 'category' é obrigatório", http.StatusBadRequest)
+		writeError(w, r, apperror.BadRequest("Parâmetro 'category' é obrigatório"))
 		return
 	}
 
-	products, err := services.SearchProductsByCategory(category)
+	opts, err := parseListOptions(r)
 	if err != nil {
-		http.Error(w, "Erro ao buscar produtos", http.StatusInternalServerError)
+		writeError(w, r, badRequest(err))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(products)
+	page, err := h.service.SearchProductsByCategory(r.Context(), category, opts)
+	h.writeProductPage(w, r, opts, page, err)
 }
 
 // SearchProductsByImage busca produtos com ou sem imagem
-func SearchProductsByImage(w http.ResponseWriter, r *http.Request) {
+// (equivale a GET /products?has_image=true|false)
+func (h *ProductHandler) SearchProductsByImage(w http.ResponseWriter, r *http.Request) {
 	hasImage := r.URL.Query().Get("image")
 
 	var hasImageBool bool
@@ -179,16 +613,16 @@ func SearchProductsByImage(w http.ResponseWriter, r *http.Request) {
 	} else if hasImage == "false" {
 		hasImageBool = false
 	} else {
-		http.Error(w, "Parâmetro 'image' deve ser 'true' ou 'false'", http.StatusBadRequest)
+		writeError(w, r, apperror.BadRequest("Parâmetro 'image' deve ser 'true' ou 'false'"))
 		return
 	}
 
-	products, err := services.SearchProductsByImage(hasImageBool)
+	opts, err := parseListOptions(r)
 	if err != nil {
-		http.Error(w, "Erro ao buscar produtos", http.StatusInternalServerError)
+		writeError(w, r, badRequest(err))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(products)
-}
\ No newline at end of file
+	page, err := h.service.SearchProductsByImage(r.Context(), hasImageBool, opts)
+	h.writeProductPage(w, r, opts, page, err)
+}
@@ -0,0 +1,350 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Nomes iguais sem diferenciar maiúsculas, acentos e espaços repetidos respondem 409
+// com o ID do produto que já usa o nome
+func TestNameConflict(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		update      bool // Altera o outro produto; senão, cria um produto
+		body        string
+		contentType string
+	}{
+		{"POST", "POST", false, `{"name": "jaqueta  algodao", "price": 1990, "description": "x", "category": "Testes"}`, "application/json"},
+		{"PUT", "PUT", true, `{"name": "JAQUETA ALGODÃO", "price": 1990, "description": "x", "category": "Testes"}`, "application/json"},
+		{"PATCH", "PATCH", true, `{"name": "Jaqueta Algodão"}`, mergePatchType},
+		{"JSON Patch", "PATCH", true, `[{"op": "replace", "path": "/name", "value": "jaqueta algodão"}]`, jsonPatchType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestRouter(t, nil)
+			existing := createTestProduct(t, h, "Jaqueta Algodão", 1990)
+			other := createTestProduct(t, h, "Calça", 2990)
+
+			path := "/products"
+			if tt.update {
+				path += "/" + strconv.Itoa(other.ID)
+			}
+			rec := request(t, h, tt.method, path, tt.body, "Content-Type", tt.contentType)
+			expectConflict(t, rec, existing.ID)
+			if got := getTestProduct(t, h, other.ID); got != other {
+				t.Errorf("produto alterado por uma escrita recusada: %+v", got)
+			}
+		})
+	}
+
+	t.Run("restauração", func(t *testing.T) {
+		h := newTestRouter(t, nil)
+		trashed := createTestProduct(t, h, "Jaqueta Algodão", 1990)
+		expectStatus(t, request(t, h, "DELETE", "/products/"+strconv.Itoa(trashed.ID), ""), http.StatusNoContent)
+
+		// Na lixeira, o nome fica livre para outro produto
+		replacement := createTestProduct(t, h, "jaqueta algodão", 2990)
+		rec := request(t, h, "POST", "/products/"+strconv.Itoa(trashed.ID)+"/restore", "")
+		expectConflict(t, rec, replacement.ID)
+	})
+}
+
+// expectConflict confere a resposta 409 de nome repetido
+func expectConflict(t *testing.T, rec *httptest.ResponseRecorder, existingID int) {
+	t.Helper()
+	expectStatus(t, rec, http.StatusConflict)
+	var problem problemBody
+	decodeBody(t, rec, &problem)
+	if problem.Type != "/problems/conflict" || problem.ExistingID != existingID {
+		t.Errorf("problema = %+v; esperado /problems/conflict com existing_id %d", problem, existingID)
+	}
+}
+
+func TestSearchProducts(t *testing.T) {
+	h := newTestRouter(t, nil)
+	createTestProduct(t, h, "Casaco", 2230)
+	jaqueta := createTestProduct(t, h, "Jaqueta Algodão", 5599)
+
+	rec := request(t, h, "GET", "/products/search?q=ALGODAO", "")
+	expectStatus(t, rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), `"name":"Jaqueta <mark>Algodão</mark>"`) {
+		t.Errorf("destaque não encontrado (ou escapado) no corpo: %s", rec.Body.String())
+	}
+	var results []struct {
+		testProduct
+		Score     float64           `json:"score"`
+		Highlight map[string]string `json:"highlight"`
+	}
+	decodeBody(t, rec, &results)
+	if len(results) != 1 || results[0].ID != jaqueta.ID || results[0].Score <= 0 {
+		t.Errorf("resultados = %+v; esperado a jaqueta, com relevância", results)
+	}
+	if rec.Header().Get("X-Total-Count") != "1" {
+		t.Errorf("X-Total-Count = %q; esperado 1", rec.Header().Get("X-Total-Count"))
+	}
+
+	// Os filtros de GET /products valem na busca
+	rec = request(t, h, "GET", "/products/search?q=algodao&price[lt]=5000", "")
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &results)
+	if len(results) != 0 {
+		t.Errorf("busca com filtro de preço: %+v", results)
+	}
+
+	for _, query := range []string{"", "q=%20", "q=jaqueta&sort=price", "q=jaqueta&cursor=abc", "q=jaqueta&fields=id", "q=jaqueta&limit=0"} {
+		expectStatus(t, request(t, h, "GET", "/products/search?"+query, ""), http.StatusBadRequest)
+	}
+}
+
+// Os erros de validação vêm todos juntos, um por campo, com o código de cada um
+func TestValidationErrors(t *testing.T) {
+	h := newTestRouter(t, nil)
+	p := createTestProduct(t, h, "Camisa", 1990)
+	path := "/products/" + strconv.Itoa(p.ID)
+
+	tests := []struct {
+		name                 string
+		method, target, body string
+		contentType          string
+		want                 []string
+	}{
+		{"POST", "POST", "/products", `{"name": " ", "price": 0, "currency": "XYZ", "category": "Testes", "image_url": "/c.png"}`, "",
+			[]string{"name:required", "price:positive", "currency:invalid_currency", "description:required", "image_url:invalid_url"}},
+		{"PUT", "PUT", path, `{"name": "Camisa", "price": -5, "description": "x"}`, "",
+			[]string{"price:positive", "category:required"}},
+		{"PATCH", "PATCH", path, `{"description": "", "category_id": 0}`, mergePatchType,
+			[]string{"description:required", "category_id:positive"}},
+		{"preço decimal", "POST", "/products", `{"name": "Meia", "price": "19.90", "description": "x", "category": "Testes"}`, "",
+			[]string{"price:invalid_decimal"}},
+	}
+	for _, tt := range tests {
+		header := []string{}
+		if tt.contentType != "" {
+			header = []string{"Content-Type", tt.contentType}
+		}
+		rec := request(t, h, tt.method, tt.target, tt.body, header...)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d; esperado 400. Corpo: %s", tt.name, rec.Code, rec.Body.String())
+			continue
+		}
+		var problem problemBody
+		decodeBody(t, rec, &problem)
+		got := make([]string, len(problem.Errors))
+		for i, fe := range problem.Errors {
+			got[i] = fe.Field + ":" + fe.Code
+		}
+		if problem.Type != "/problems/validation" || strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: %s %v; esperado /problems/validation %v", tt.name, problem.Type, got, tt.want)
+		}
+	}
+
+	// Nada foi gravado pelas requisições recusadas
+	if got := getTestProduct(t, h, p.ID); got != p {
+		t.Errorf("produto alterado por uma requisição inválida: %+v", got)
+	}
+}
+
+// Escritas em produtos inexistentes respondem 404, sem ecoar o corpo enviado
+func TestWritesToMissingProduct(t *testing.T) {
+	h := newTestRouter(t, nil)
+	body := `{"name": "Boné", "price": 1990, "description": "Boné", "category": "Testes"}`
+	tests := []struct {
+		method, body, contentType string
+	}{
+		{"PUT", body, "application/json"},
+		{"PATCH", `{"name": "Boné"}`, mergePatchType},
+		{"DELETE", "", ""},
+	}
+	for _, tt := range tests {
+		rec := request(t, h, tt.method, "/products/999", tt.body, "Content-Type", tt.contentType)
+		expectStatus(t, rec, http.StatusNotFound)
+		var problem problemBody
+		decodeBody(t, rec, &problem)
+		if problem.Type != "/problems/not-found" || problem.Detail != "produto não encontrado" {
+			t.Errorf("%s: %+v", tt.method, problem)
+		}
+	}
+	expectStatus(t, request(t, h, "GET", "/products/999", ""), http.StatusNotFound)
+}
+
+// PUT retorna o produto como ficou gravado: o ID vem da URL e a versão do banco
+func TestUpdateReturnsStoredProduct(t *testing.T) {
+	h := newTestRouter(t, nil)
+	p := createTestProduct(t, h, "Boné", 1990)
+
+	rec := request(t, h, "PUT", "/products/"+strconv.Itoa(p.ID),
+		`{"id": 12345, "version": 99, "name": "Boné aba reta", "price": 2990, "description": "Boné", "category": "Testes"}`)
+	expectStatus(t, rec, http.StatusOK)
+	var got testProduct
+	decodeBody(t, rec, &got)
+	if got.ID != p.ID || got.Version != 2 || got.Name != "Boné aba reta" || got.ImageURL != "" || got.CategoryID != p.CategoryID {
+		t.Errorf("produto retornado: %+v", got)
+	}
+	if rec.Header().Get("ETag") == "" {
+		t.Error("resposta sem ETag")
+	}
+	if stored := getTestProduct(t, h, p.ID); stored != got {
+		t.Errorf("gravado %+v; retornado %+v", stored, got)
+	}
+	expectStatus(t, request(t, h, "GET", "/products/12345", ""), http.StatusNotFound)
+}
+
+// Criação com o ID da URL: If-None-Match: * só cria; ?upsert=true cria ou substitui
+func TestPutCreatesWithID(t *testing.T) {
+	h := newTestRouter(t, nil)
+	body := func(name string) string {
+		return `{"name": "` + name + `", "price": 1990, "description": "Boné", "category": "Testes"}`
+	}
+
+	rec := request(t, h, "PUT", "/products/500", body("Boné"), "If-None-Match", "*")
+	expectStatus(t, rec, http.StatusCreated)
+	var created testProduct
+	decodeBody(t, rec, &created)
+	if created.ID != 500 || created.Version != 1 {
+		t.Errorf("produto criado: %+v", created)
+	}
+	rec = request(t, h, "PUT", "/products/500", body("Boné azul"), "If-None-Match", "*")
+	expectStatus(t, rec, http.StatusPreconditionFailed)
+	if got := getTestProduct(t, h, 500); got.Name != "Boné" {
+		t.Errorf("If-None-Match: * substituiu o produto: %+v", got)
+	}
+
+	rec = request(t, h, "PUT", "/products/500?upsert=true", body("Boné azul"))
+	expectStatus(t, rec, http.StatusOK)
+	var replaced testProduct
+	decodeBody(t, rec, &replaced)
+	if replaced.ID != 500 || replaced.Name != "Boné azul" || replaced.Version != 2 {
+		t.Errorf("produto substituído: %+v", replaced)
+	}
+
+	rec = request(t, h, "PUT", "/products/600?upsert=true", body("Viseira"))
+	expectStatus(t, rec, http.StatusCreated)
+	decodeBody(t, rec, &created)
+	if created.ID != 600 || created.Version != 1 {
+		t.Errorf("produto criado pelo upsert: %+v", created)
+	}
+
+	// Os IDs gerados continuam depois dos informados
+	if next := createTestProduct(t, h, "Chapéu", 2990); next.ID <= 600 {
+		t.Errorf("ID gerado = %d; esperado maior que 600", next.ID)
+	}
+
+	// O ID de um produto na lixeira não é reutilizado
+	expectStatus(t, request(t, h, "DELETE", "/products/600", ""), http.StatusNoContent)
+	expectStatus(t, request(t, h, "PUT", "/products/600?upsert=true", body("Viseira")), http.StatusConflict)
+	expectStatus(t, request(t, h, "PUT", "/products/600", body("Viseira"), "If-None-Match", "*"), http.StatusPreconditionFailed)
+
+	// Os dados continuam validados e o ID da URL precisa ser positivo
+	expectStatus(t, request(t, h, "PUT", "/products/700?upsert=true", `{"name": "Boina"}`), http.StatusBadRequest)
+	expectStatus(t, request(t, h, "PUT", "/products/0?upsert=true", body("Boina")), http.StatusBadRequest)
+	expectStatus(t, request(t, h, "GET", "/products/700", ""), http.StatusNotFound)
+}
+
+// created_at e updated_at aparecem nas respostas em UTC, são ignorados no corpo das
+// escritas e permitem buscar os produtos alterados com ?updated_since=
+func TestTimestamps(t *testing.T) {
+	h := newTestRouter(t, nil)
+	type timestamps struct {
+		ID        int       `json:"id"`
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+	read := func(rec *httptest.ResponseRecorder) timestamps {
+		t.Helper()
+		var ts timestamps
+		decodeBody(t, rec, &ts)
+		if ts.CreatedAt.IsZero() || ts.UpdatedAt.Before(ts.CreatedAt) || ts.UpdatedAt.Location() != time.UTC {
+			t.Errorf("created_at %v, updated_at %v", ts.CreatedAt, ts.UpdatedAt)
+		}
+		return ts
+	}
+
+	p := createTestProduct(t, h, "Boné", 1990)
+	createTestProduct(t, h, "Meia", 990)
+	created := read(request(t, h, "GET", "/products/"+strconv.Itoa(p.ID), ""))
+
+	time.Sleep(2 * time.Millisecond)
+	since := time.Now().UTC().Format(time.RFC3339Nano)
+	rec := request(t, h, "PUT", "/products/"+strconv.Itoa(p.ID),
+		`{"name": "Boné", "price": 2990, "description": "Boné", "category": "Testes", "created_at": "2000-01-01T00:00:00Z", "updated_at": "2000-01-01T00:00:00Z"}`)
+	expectStatus(t, rec, http.StatusOK)
+	updated := read(rec)
+	if !updated.CreatedAt.Equal(created.CreatedAt) || !updated.UpdatedAt.After(created.UpdatedAt) {
+		t.Errorf("depois do PUT: %+v; antes: %+v", updated, created)
+	}
+
+	rec = request(t, h, "GET", "/products?updated_since="+url.QueryEscape(since), "")
+	expectStatus(t, rec, http.StatusOK)
+	var changed []timestamps
+	decodeBody(t, rec, &changed)
+	if len(changed) != 1 || changed[0].ID != p.ID {
+		t.Errorf("alterados desde %s: %+v; esperado só o produto %d", since, changed, p.ID)
+	}
+
+	// Com o filtro, a lista vazia não é um erro
+	rec = request(t, h, "GET", "/products?updated_since=2100-01-01", "")
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &changed)
+	if len(changed) != 0 {
+		t.Errorf("alterados no futuro: %+v", changed)
+	}
+	expectStatus(t, request(t, h, "GET", "/products?updated_since=ontem", ""), http.StatusBadRequest)
+}
+
+// DELETE move o produto para a lixeira, de onde ele pode ser restaurado
+func TestTrash(t *testing.T) {
+	h := newTestRouter(t, nil)
+	p := createTestProduct(t, h, "Boné", 1990)
+	kept := createTestProduct(t, h, "Meia", 990)
+	target := "/products/" + strconv.Itoa(p.ID)
+
+	rec := request(t, h, "GET", "/products/trash", "")
+	expectStatus(t, rec, http.StatusOK)
+	var trash []struct {
+		ID        int        `json:"id"`
+		DeletedAt *time.Time `json:"deleted_at"`
+	}
+	decodeBody(t, rec, &trash)
+	if len(trash) != 0 {
+		t.Errorf("lixeira inicial: %+v", trash)
+	}
+
+	expectStatus(t, request(t, h, "DELETE", target, ""), http.StatusNoContent)
+	expectStatus(t, request(t, h, "GET", target, ""), http.StatusNotFound)
+	expectStatus(t, request(t, h, "DELETE", target, ""), http.StatusNotFound)
+
+	var products []testProduct
+	decodeBody(t, request(t, h, "GET", "/products", ""), &products)
+	if len(products) != 1 || products[0].ID != kept.ID {
+		t.Errorf("GET /products com um produto na lixeira: %+v", products)
+	}
+
+	rec = request(t, h, "GET", "/products/trash?name=bon", "")
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &trash)
+	if len(trash) != 1 || trash[0].ID != p.ID || trash[0].DeletedAt == nil {
+		t.Errorf("lixeira: %+v; esperado o produto %d", trash, p.ID)
+	}
+
+	rec = request(t, h, "POST", target+"/restore", "")
+	expectStatus(t, rec, http.StatusOK)
+	var restored testProduct
+	decodeBody(t, rec, &restored)
+	if restored.ID != p.ID || restored.Name != "Boné" || restored.Version != p.Version+2 {
+		t.Errorf("produto restaurado: %+v", restored)
+	}
+	if got := getTestProduct(t, h, p.ID); got != restored {
+		t.Errorf("gravado %+v; retornado %+v", got, restored)
+	}
+
+	for _, id := range []int{p.ID, kept.ID, 999} {
+		rec := request(t, h, "POST", "/products/"+strconv.Itoa(id)+"/restore", "")
+		expectStatus(t, rec, http.StatusNotFound)
+	}
+}
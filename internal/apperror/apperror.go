@@ -0,0 +1,124 @@
+// Package apperror define os tipos de erro da aplicação. Os erros nascem no
+// repository ou nos services com um tipo (não encontrado, conflito, ...) e a
+// camada api decide o status HTTP e o corpo da resposta apenas pelo tipo,
+// sem comparar mensagens.
+package apperror
+
+import "errors"
+
+// Kind é o tipo de um erro da aplicação
+type Kind string
+
+const (
+	KindBadRequest       Kind = "bad-request"            // Requisição malformada (parâmetro, ID ou JSON inválido)
+	KindValidation       Kind = "validation"             // Dados com um ou mais campos inválidos
+	KindNotFound         Kind = "not-found"              // Recurso inexistente
+	KindConflict         Kind = "conflict"               // Conflito com o estado atual do recurso
+	KindPrecondition     Kind = "precondition-failed"    // Pré-condição da requisição (If-None-Match, ...) não atendida
+	KindUnsupported      Kind = "unsupported-media-type" // Formato do corpo da requisição não suportado
+	KindNotAcceptable    Kind = "not-acceptable"         // Formato de resposta pedido (Accept) não suportado
+	KindUnprocessable    Kind = "unprocessable"          // Requisição bem formada, mas incompatível com uma anterior (ex.: Idempotency-Key reutilizada)
+	KindFailedDependency Kind = "failed-dependency"      // Operação desfeita porque outra da qual dependia falhou (lotes atômicos)
+	KindInternal         Kind = "internal"               // Falha inesperada (banco fora do ar, bug, ...)
+)
+
+// Error é um erro da aplicação com tipo e mensagem para o cliente.
+// A causa original, quando existe, é mantida para os logs e para errors.Is/As.
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error
+}
+
+// Error implementa a interface error
+func (e *Error) Error() string {
+	if e.Err != nil && e.Message == "" {
+		return e.Err.Error()
+	}
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap retorna a causa original
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New cria um erro do tipo informado
+func New(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// BadRequest cria um erro de requisição malformada
+func BadRequest(message string) *Error {
+	return New(KindBadRequest, message)
+}
+
+// NotFound cria um erro de recurso inexistente
+func NotFound(message string) *Error {
+	return New(KindNotFound, message)
+}
+
+// Conflict cria um erro de conflito com o estado atual do recurso
+func Conflict(message string) *Error {
+	return New(KindConflict, message)
+}
+
+// PreconditionFailed cria um erro de pré-condição não atendida
+func PreconditionFailed(message string) *Error {
+	return New(KindPrecondition, message)
+}
+
+// UnsupportedMediaType cria um erro de corpo em formato não suportado
+func UnsupportedMediaType(message string) *Error {
+	return New(KindUnsupported, message)
+}
+
+// NotAcceptable cria um erro de formato de resposta não suportado
+func NotAcceptable(message string) *Error {
+	return New(KindNotAcceptable, message)
+}
+
+// Unprocessable cria um erro de requisição bem formada que não pode ser processada
+func Unprocessable(message string) *Error {
+	return New(KindUnprocessable, message)
+}
+
+// Internal embrulha uma falha inesperada; a mensagem é a exibida ao cliente,
+// a causa fica apenas nos logs
+func Internal(message string, err error) *Error {
+	return &Error{Kind: KindInternal, Message: message, Err: err}
+}
+
+// kinded é implementado por erros que informam o próprio tipo (como validation.Errors)
+type kinded interface {
+	Kind() Kind
+}
+
+// KindOf retorna o tipo do erro; erros sem tipo são considerados internos
+func KindOf(err error) Kind {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Kind
+	}
+	var k kinded
+	if errors.As(err, &k) {
+		return k.Kind()
+	}
+	return KindInternal
+}
+
+// MessageOf retorna a mensagem do erro destinada ao cliente. Em erros internos,
+// a causa não é exposta: apenas a mensagem informada em Internal.
+func MessageOf(err error) string {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Message
+	}
+	if KindOf(err) == KindInternal {
+		return ""
+	}
+	return err.Error()
+}
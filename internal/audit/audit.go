@@ -0,0 +1,43 @@
+package audit
+
+import "context"
+
+// Origens das alterações registradas na auditoria
+const (
+	SourceAPI      = "api"      // Requisições HTTP
+	SourceImporter = "importer" // Importador de produtos (cmd/importer)
+	SourceCLI      = "cli"      // Subcomandos do servidor, como o purge
+	SourceSystem   = "system"   // Alterações sem autor identificado
+)
+
+// Ações registradas na auditoria
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"  // Produto movido para a lixeira
+	ActionRestore = "restore" // Produto retirado da lixeira
+	ActionPurge   = "purge"   // Produto removido de vez da lixeira
+)
+
+// Actor identifica quem fez uma alteração e por qual meio
+type Actor struct {
+	Name      string
+	Source    string
+	RequestID string // ID da requisição HTTP, quando a alteração veio da API
+}
+
+// actorKey é a chave do autor no contexto
+type actorKey struct{}
+
+// WithActor retorna um contexto que atribui as alterações feitas com ele ao autor informado
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom retorna o autor guardado no contexto; sem autor, as alterações são do sistema
+func ActorFrom(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return actor
+	}
+	return Actor{Name: "system", Source: SourceSystem}
+}
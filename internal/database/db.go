@@ -2,60 +2,124 @@ package db
 
 import (
 	"database/sql"
-	"log"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	_ "github.com/lib/pq"
 	_ "modernc.org/sqlite"
 )
 
-var DB *sql.DB
+// Drivers de banco de dados suportados
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
 
-// Abrir a conexão com o banco SQLite
-func OpenDB() (*sql.DB, error) {
-	// Verifica se o arquivo do banco já existe, se não, cria ele
-	_, err := os.Stat("database.db")
-	if os.IsNotExist(err) {
-		// Cria o banco de dados se não existir
-		file, err := os.Create("database.db")
-		if err != nil {
-			log.Fatal("Não foi possível criar o banco de dados:", err)
-			return nil, err
-		}
-		file.Close()
+// DefaultDSN é o banco usado quando nenhuma DSN é informada
+const DefaultDSN = "database.db"
+
+// PoolConfig define os limites do pool de conexões compartilhado pela aplicação
+type PoolConfig struct {
+	MaxOpenConns    int           // Número máximo de conexões abertas (0 = ilimitado)
+	MaxIdleConns    int           // Número máximo de conexões ociosas mantidas no pool
+	ConnMaxLifetime time.Duration // Tempo máximo de vida de uma conexão (0 = sem limite)
+}
+
+// DefaultPoolConfig retorna a configuração padrão do pool de conexões
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		MaxOpenConns:    10,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: 30 * time.Minute,
+	}
+}
+
+// DriverFromDSN identifica o driver a partir da DSN.
+// DSNs "postgres://" e "postgresql://" usam o PostgreSQL; qualquer outra é tratada
+// como o caminho de um arquivo SQLite (opcionalmente com o prefixo "sqlite://").
+func DriverFromDSN(dsn string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		return DriverPostgres
+	}
+	return DriverSQLite
+}
+
+// Abrir o pool de conexões com o banco indicado pela DSN.
+// O *sql.DB retornado deve ser criado uma única vez e compartilhado por toda a aplicação.
+func OpenDB(dsn string, pool PoolConfig) (*sql.DB, error) {
+	if dsn == "" {
+		dsn = DefaultDSN
 	}
 
-	// Conectar ao banco de dados SQLite
-	db, err := sql.Open("sqlite", "./database.db")
+	driver := DriverFromDSN(dsn)
+	var db *sql.DB
+	var err error
+	if driver == DriverPostgres {
+		db, err = sql.Open("postgres", dsn)
+	} else {
+		db, err = openSQLite(strings.TrimPrefix(dsn, "sqlite://"))
+	}
 	if err != nil {
-		log.Fatal("Erro ao conectar com o banco de dados:", err)
-		return nil, err
+		return nil, fmt.Errorf("erro ao conectar com o banco de dados: %v", err)
 	}
 
+	// Aplicar as configurações do pool
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+
 	// Verificar se a conexão foi bem-sucedida
 	err = db.Ping()
 	if err != nil {
-		log.Fatal("Erro ao verificar a conexão:", err)
-		return nil, err
+		db.Close()
+		return nil, fmt.Errorf("erro ao verificar a conexão: %v", err)
 	}
 
-	DB = db
-	return DB, nil
+	return db, nil
 }
 
-// Criar a tabela de produtos
-func CreateTable() {
-	sqlStmt := `
-	CREATE TABLE IF NOT EXISTS products (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL,
-		price INTEGER NOT NULL,
-		description TEXT NOT NULL,
-		category TEXT NOT NULL,
-		image_url TEXT
-	);
-	`
-	_, err := DB.Exec(sqlStmt)
-	if err != nil {
-		log.Fatal("Erro ao criar a tabela de produtos:", err)
+// openSQLite abre o arquivo SQLite, criando-o se ainda não existir
+func openSQLite(path string) (*sql.DB, error) {
+	// Verifica se o arquivo do banco já existe, se não, cria ele
+	_, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		// Cria o banco de dados se não existir
+		file, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("não foi possível criar o banco de dados: %v", err)
+		}
+		file.Close()
+	}
+
+	// O busy_timeout evita erros de "database is locked" quando várias
+	// conexões do pool tentam escrever ao mesmo tempo. O _time_format grava as
+	// datas em um formato que o SQLite entende e que ordena como texto. O
+	// _txlock=immediate abre as transações com BEGIN IMMEDIATE: elas leem antes de
+	// escrever (a verificação do nome, por exemplo) e, com o BEGIN comum, duas
+	// transações que leram ao mesmo tempo não conseguem passar à escrita e uma
+	// delas falha com SQLITE_BUSY sem esperar o busy_timeout.
+	return sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_time_format=sqlite&_txlock=immediate")
+}
+
+// Rebind troca os placeholders "?" da consulta pelo formato esperado pelo driver.
+// O PostgreSQL usa placeholders numerados ($1, $2, ...), enquanto o SQLite aceita "?".
+func Rebind(driver, query string) string {
+	if driver != DriverPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
 	}
+	return b.String()
 }
@@ -0,0 +1,319 @@
+// Package migrations aplica as alterações versionadas do esquema do banco.
+//
+// Cada migração é um par de arquivos SQL embutidos no binário, um para cada driver:
+//
+//	<driver>/<versão>_<nome>.up.sql    aplica a alteração
+//	<driver>/<versão>_<nome>.down.sql  desfaz a alteração
+//
+// As versões aplicadas ficam registradas na tabela schema_migrations.
+// Transformações de dados que o SQL sozinho não expressa ficam em etapas Go
+// (ver afterUp), executadas na mesma transação logo após o SQL da migração.
+package migrations
+
+import (
+	"braip/internal/database"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed sqlite/*.sql postgres/*.sql
+var files embed.FS
+
+// Migration representa uma alteração versionada do esquema
+type Migration struct {
+	Version int
+	Name    string
+	Up      string // SQL que aplica a migração
+	Down    string // SQL que desfaz a migração
+}
+
+// Status descreve a situação de uma migração no banco
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator aplica e desfaz as migrações de um banco
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	migrations []Migration
+}
+
+// New cria um Migrator com as migrações embutidas para o driver informado
+func New(conn *sql.DB, driver string) (*Migrator, error) {
+	migrations, err := load(driver)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: conn, driver: driver, migrations: migrations}, nil
+}
+
+// load lê e ordena as migrações embutidas do driver
+func load(driver string) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, driver)
+	if err != nil {
+		return nil, fmt.Errorf("não há migrações para o driver %q: %v", driver, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("arquivo de migração inválido: %s", name)
+		}
+
+		// Nome no formato 0001_create_products.up.sql
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		prefix, label, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("arquivo de migração sem nome: %s", name)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("versão inválida no arquivo de migração: %s", name)
+		}
+
+		content, err := files.ReadFile(path.Join(driver, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("versão %d usada por duas migrações: %s e %s", version, m.Name, label)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migração %d (%s) sem arquivo .up.sql", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Latest retorna a versão mais recente disponível
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version retorna a versão atual do banco (0 se nenhuma migração foi aplicada)
+func (m *Migrator) Version() (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	version := 0
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// Status lista todas as migrações conhecidas e se já foram aplicadas
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses = append(statuses, Status{Migration: migration, Applied: ok, AppliedAt: appliedAt})
+	}
+	return statuses, nil
+}
+
+// Up aplica todas as migrações pendentes e retorna quantas foram aplicadas
+func (m *Migrator) Up() (int, error) {
+	return m.To(m.Latest())
+}
+
+// Down desfaz a última migração aplicada
+func (m *Migrator) Down() error {
+	version, err := m.Version()
+	if err != nil {
+		return err
+	}
+	if version == 0 {
+		return nil // Nada a desfazer
+	}
+
+	previous := 0
+	for _, migration := range m.migrations {
+		if migration.Version < version {
+			previous = migration.Version
+		}
+	}
+	_, err = m.To(previous)
+	return err
+}
+
+// To leva o banco até a versão informada, aplicando ou desfazendo migrações
+// conforme necessário. Retorna quantas migrações foram executadas.
+func (m *Migrator) To(target int) (int, error) {
+	if target != 0 && !m.known(target) {
+		return 0, fmt.Errorf("versão de migração desconhecida: %d", target)
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+
+	// Aplicar, em ordem crescente, as migrações pendentes até a versão alvo
+	for _, migration := range m.migrations {
+		if migration.Version > target {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err := m.run(migration, true); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	// Desfazer, em ordem decrescente, as migrações acima da versão alvo
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version <= target {
+			break
+		}
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if err := m.run(migration, false); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// run executa uma migração em sua própria transação, registrando-a em schema_migrations
+func (m *Migrator) run(migration Migration, up bool) error {
+	script, action := migration.Up, "aplicar"
+	if !up {
+		script, action = migration.Down, "desfazer"
+		if script == "" {
+			return fmt.Errorf("migração %d (%s) não pode ser desfeita: arquivo .down.sql ausente", migration.Version, migration.Name)
+		}
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(script); err != nil {
+		return fmt.Errorf("erro ao %s a migração %d (%s): %v", action, migration.Version, migration.Name, err)
+	}
+	if step, ok := afterUp[migration.Version]; ok && up {
+		if err := step(tx, m.driver); err != nil {
+			return fmt.Errorf("erro ao %s a migração %d (%s): %v", action, migration.Version, migration.Name, err)
+		}
+	}
+
+	if up {
+		_, err = tx.Exec(db.Rebind(m.driver, "INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)"),
+			migration.Version, migration.Name, time.Now().UTC())
+	} else {
+		_, err = tx.Exec(db.Rebind(m.driver, "DELETE FROM schema_migrations WHERE version = ?"), migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao registrar a migração %d: %v", migration.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if up {
+		log.Printf("Migração %04d_%s aplicada", migration.Version, migration.Name)
+	} else {
+		log.Printf("Migração %04d_%s desfeita", migration.Version, migration.Name)
+	}
+	return nil
+}
+
+// applied cria a tabela de controle, se necessário, e retorna as versões já aplicadas
+func (m *Migrator) applied() (map[int]time.Time, error) {
+	_, err := m.db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar a tabela schema_migrations: %v", err)
+	}
+
+	rows, err := m.db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler a tabela schema_migrations: %v", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// known indica se existe uma migração com a versão informada
+func (m *Migrator) known(version int) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package migrations
+
+import (
+	"braip/internal/database"
+	"database/sql"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// schema descreve as tabelas, índices, visões e gatilhos do banco, para comparar
+// o esquema depois de desfazer e reaplicar migrações. As tabelas são comparadas
+// pelas colunas: recriadas por ALTER TABLE, o texto do CREATE TABLE muda.
+func schema(t *testing.T, conn *sql.DB) string {
+	t.Helper()
+	rows, err := conn.Query("SELECT type, name, COALESCE(sql, '') FROM sqlite_master WHERE name NOT LIKE 'sqlite_%' ORDER BY type, name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var objects [][3]string
+	for rows.Next() {
+		var object [3]string
+		if err := rows.Scan(&object[0], &object[1], &object[2]); err != nil {
+			t.Fatal(err)
+		}
+		objects = append(objects, object)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	for _, object := range objects {
+		definition := object[2]
+		if object[0] == "table" {
+			definition = strings.Join(columns(t, conn, object[1]), ", ")
+		}
+		b.WriteString(object[0] + " " + object[1] + ": " + definition + "\n")
+	}
+	return b.String()
+}
+
+// columns retorna as colunas da tabela, em ordem alfabética
+func columns(t *testing.T, conn *sql.DB, table string) []string {
+	t.Helper()
+	rows, err := conn.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Cada migração desfeita volta ao esquema da versão anterior, e aplicá-las de novo
+// chega ao mesmo esquema da primeira vez
+func TestMigrationsUpDownUp(t *testing.T) {
+	conn, migrator := openTestDB(t)
+	latest := migrator.Latest()
+
+	// Esquema de cada versão, aplicando uma migração de cada vez. Version cria a
+	// tabela de controle, que faz parte do esquema mesmo sem migrações aplicadas.
+	if _, err := migrator.Version(); err != nil {
+		t.Fatal(err)
+	}
+	schemas := map[int]string{0: schema(t, conn)}
+	for _, migration := range migrator.migrations {
+		migrateTo(t, migrator, migration.Version)
+		schemas[migration.Version] = schema(t, conn)
+	}
+	if version, err := migrator.Version(); err != nil || version != latest {
+		t.Fatalf("Version() = %d, %v; esperado %d", version, err, latest)
+	}
+
+	for i := len(migrator.migrations) - 1; i >= 0; i-- {
+		if err := migrator.Down(); err != nil {
+			t.Fatal(err)
+		}
+		previous := 0
+		if i > 0 {
+			previous = migrator.migrations[i-1].Version
+		}
+		if version, _ := migrator.Version(); version != previous {
+			t.Fatalf("versão %d depois de Down; esperado %d", version, previous)
+		}
+		if got := schema(t, conn); got != schemas[previous] {
+			t.Errorf("esquema depois de desfazer a migração %d difere do da versão %d:\n%s\nesperado:\n%s",
+				migrator.migrations[i].Version, previous, got, schemas[previous])
+		}
+	}
+	if err := migrator.Down(); err != nil {
+		t.Errorf("Down sem migrações aplicadas: %v", err)
+	}
+
+	count, err := migrator.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != len(migrator.migrations) {
+		t.Errorf("Up aplicou %d migrações; esperado %d", count, len(migrator.migrations))
+	}
+	if got := schema(t, conn); got != schemas[latest] {
+		t.Errorf("esquema depois de reaplicar as migrações difere do original:\n%s\nesperado:\n%s", got, schemas[latest])
+	}
+
+	// Sem migrações pendentes, Up não faz nada
+	if count, err := migrator.Up(); err != nil || count != 0 {
+		t.Errorf("Up repetido = %d, %v; esperado 0", count, err)
+	}
+}
+
+// Os produtos sobrevivem a migrações desfeitas e reaplicadas
+func TestMigrationsKeepData(t *testing.T) {
+	conn, migrator := openTestDB(t)
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	exec(t, conn, `INSERT INTO categories (slug, name) VALUES ('roupas', 'Roupas')`)
+	exec(t, conn, `INSERT INTO products (name, name_key, price, currency, description, category_id, image_url, created_at, updated_at)
+		VALUES ('Camisa', 'camisa', 1990, 'USD', 'Algodão', 1, 'https://example.com/1.png', '2024-05-01 10:00:00+00:00', '2024-05-01 10:00:00+00:00')`)
+
+	migrateTo(t, migrator, 3)
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	var name, description, category string
+	var price int
+	err := conn.QueryRow(`SELECT p.name, p.price, p.description, c.name FROM products p JOIN categories c ON c.id = p.category_id`).
+		Scan(&name, &price, &description, &category)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "Camisa" || price != 1990 || description != "Algodão" || category != "Roupas" {
+		t.Errorf("produto depois de desfazer e reaplicar as migrações: %s, %d, %s, %s", name, price, description, category)
+	}
+
+	// A busca textual é reconstruída com os produtos existentes
+	var count int
+	if err := conn.QueryRow("SELECT COUNT(*) FROM products_fts WHERE products_fts MATCH 'algodao'").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("%d produtos na busca textual; esperado 1", count)
+	}
+}
+
+func TestMigrateToUnknownVersion(t *testing.T) {
+	_, migrator := openTestDB(t)
+	if _, err := migrator.To(migrator.Latest() + 1); err == nil {
+		t.Error("To com uma versão desconhecida não retornou erro")
+	}
+	if version, _ := migrator.Version(); version != 0 {
+		t.Errorf("versão %d depois de um To recusado; esperado 0", version)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	_, migrator := openTestDB(t)
+	migrateTo(t, migrator, 2)
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != len(migrator.migrations) {
+		t.Fatalf("%d migrações em Status; esperado %d", len(statuses), len(migrator.migrations))
+	}
+	for _, status := range statuses {
+		if applied := status.Version <= 2; status.Applied != applied || status.AppliedAt.IsZero() == applied {
+			t.Errorf("migração %d: aplicada %v em %v", status.Version, status.Applied, status.AppliedAt)
+		}
+	}
+}
+
+// Os dois drivers têm as mesmas migrações, todas com o arquivo que as desfaz
+func TestMigrationsMatchAcrossDrivers(t *testing.T) {
+	sqlite, err := load(db.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	postgres, err := load(db.DriverPostgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sqlite) != len(postgres) {
+		t.Fatalf("%d migrações no SQLite e %d no PostgreSQL", len(sqlite), len(postgres))
+	}
+	for i := range sqlite {
+		if sqlite[i].Version != postgres[i].Version || sqlite[i].Name != postgres[i].Name {
+			t.Errorf("migração %d: %04d_%s no SQLite e %04d_%s no PostgreSQL",
+				i, sqlite[i].Version, sqlite[i].Name, postgres[i].Version, postgres[i].Name)
+		}
+		if sqlite[i].Down == "" || postgres[i].Down == "" {
+			t.Errorf("migração %04d_%s sem .down.sql", sqlite[i].Version, sqlite[i].Name)
+		}
+		if i > 0 && sqlite[i].Version <= sqlite[i-1].Version {
+			t.Errorf("migrações fora de ordem: %d depois de %d", sqlite[i].Version, sqlite[i-1].Version)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+-- Tabela de produtos. O IF NOT EXISTS permite adotar bancos criados antes das migrações.
+CREATE TABLE IF NOT EXISTS products (
+	id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	price INTEGER NOT NULL,
+	description TEXT NOT NULL,
+	category TEXT NOT NULL,
+	image_url TEXT
+);
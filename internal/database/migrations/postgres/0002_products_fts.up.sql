@@ -0,0 +1,15 @@
+-- Busca textual sobre nome e descrição dos produtos.
+-- A configuração braip_unaccent remove os acentos: "algodao" encontra "Algodão".
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+CREATE TEXT SEARCH CONFIGURATION braip_unaccent (COPY = simple);
+ALTER TEXT SEARCH CONFIGURATION braip_unaccent
+	ALTER MAPPING FOR hword, hword_part, word WITH unaccent, simple;
+
+-- Vetor de busca mantido pelo próprio banco; o nome tem peso maior que a descrição
+ALTER TABLE products ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('braip_unaccent'::regconfig, coalesce(name, '')), 'A') ||
+	setweight(to_tsvector('braip_unaccent'::regconfig, coalesce(description, '')), 'B')
+) STORED;
+
+CREATE INDEX products_search_vector_idx ON products USING GIN (search_vector);
@@ -0,0 +1,17 @@
+-- Categorias de produtos, com hierarquia opcional (parent_id).
+-- O slug é o identificador normalizado: minúsculas, sem acentos e com hífens.
+CREATE TABLE categories (
+	id SERIAL PRIMARY KEY,
+	slug TEXT NOT NULL UNIQUE,
+	name TEXT NOT NULL,
+	parent_id INTEGER REFERENCES categories(id)
+);
+
+CREATE INDEX categories_parent_id_idx ON categories (parent_id);
+
+ALTER TABLE products ADD COLUMN category_id INTEGER REFERENCES categories(id);
+
+CREATE INDEX products_category_id_idx ON products (category_id);
+
+-- As categorias são criadas a partir dos textos de products.category pela etapa Go
+-- normalizeCategories, que também preenche products.category_id
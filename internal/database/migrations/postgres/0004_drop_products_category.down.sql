@@ -0,0 +1,2 @@
+ALTER TABLE products ADD COLUMN category TEXT NOT NULL DEFAULT '';
+UPDATE products SET category = COALESCE((SELECT name FROM categories WHERE categories.id = products.category_id), '');
@@ -0,0 +1,2 @@
+-- O nome da categoria passa a vir da tabela categories (via products.category_id)
+ALTER TABLE products DROP COLUMN category;
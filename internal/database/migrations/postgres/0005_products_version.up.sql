@@ -0,0 +1,3 @@
+-- Versão de cada produto, incrementada a cada alteração: é a base do ETag
+-- e do controle de concorrência otimista (If-Match)
+ALTER TABLE products ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
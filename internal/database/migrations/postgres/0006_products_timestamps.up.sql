@@ -0,0 +1,7 @@
+-- Datas de criação e de última alteração dos produtos, gravadas pelo repositório em UTC.
+-- Os produtos já existentes recebem o momento da migração.
+ALTER TABLE products ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE products ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- Consultas de sincronização (updated_since=) filtram e ordenam por updated_at
+CREATE INDEX products_updated_at_idx ON products (updated_at);
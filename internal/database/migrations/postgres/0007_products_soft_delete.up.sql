@@ -0,0 +1,5 @@
+-- Exclusão lógica: produtos excluídos ficam na lixeira (deleted_at preenchido)
+-- até serem restaurados ou removidos de vez pelo comando purge
+ALTER TABLE products ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX products_deleted_at_idx ON products (deleted_at);
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS product_audit_append_only ON product_audit;
+DROP FUNCTION IF EXISTS product_audit_append_only();
+DROP INDEX IF EXISTS product_audit_created_at_idx;
+DROP INDEX IF EXISTS product_audit_product_id_idx;
+DROP TABLE IF EXISTS product_audit;
@@ -0,0 +1,27 @@
+-- Auditoria das alterações de produtos: cada criação, atualização, exclusão,
+-- restauração e remoção definitiva gera uma linha, gravada na mesma transação da alteração.
+-- Sem REFERENCES: o histórico continua disponível depois que o produto é removido de vez.
+CREATE TABLE product_audit (
+	id BIGSERIAL PRIMARY KEY,
+	product_id INTEGER NOT NULL,
+	action TEXT NOT NULL,
+	actor TEXT NOT NULL,
+	source TEXT NOT NULL,
+	request_id TEXT NOT NULL DEFAULT '',
+	before_data TEXT, -- Produto (JSON) antes da alteração; NULL na criação
+	after_data TEXT,  -- Produto (JSON) depois da alteração; NULL na remoção definitiva
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX product_audit_product_id_idx ON product_audit (product_id);
+CREATE INDEX product_audit_created_at_idx ON product_audit (created_at);
+
+-- A auditoria é somente de inserção: alterar ou apagar linhas é proibido
+CREATE FUNCTION product_audit_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'product_audit é somente de inserção';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER product_audit_append_only BEFORE UPDATE OR DELETE ON product_audit
+	FOR EACH ROW EXECUTE FUNCTION product_audit_append_only();
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS products_name_key_idx;
+ALTER TABLE products DROP COLUMN name_key;
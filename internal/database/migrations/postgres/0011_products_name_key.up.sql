@@ -0,0 +1,5 @@
+-- Nome normalizado (sem acentos, em minúsculas e com os espaços normalizados),
+-- preenchido pela etapa em Go da migração e mantido pelo repositório.
+-- O índice único impede dois produtos fora da lixeira com o mesmo nome.
+ALTER TABLE products ADD COLUMN name_key TEXT;
+CREATE UNIQUE INDEX products_name_key_idx ON products (name_key) WHERE deleted_at IS NULL;
@@ -0,0 +1,14 @@
+-- Respostas das requisições feitas com o cabeçalho Idempotency-Key, repetidas quando
+-- o cliente reenvia a requisição com a mesma chave. A linha é criada (com status 0)
+-- quando a primeira requisição começa e recebe a resposta quando ela termina.
+CREATE TABLE idempotency_keys (
+	idempotency_key TEXT PRIMARY KEY,
+	request_hash TEXT NOT NULL, -- SHA-256 do método, do caminho e do corpo
+	status INTEGER NOT NULL DEFAULT 0,
+	header TEXT, -- Cabeçalhos da resposta, em JSON
+	body BYTEA,
+	created_at TIMESTAMPTZ NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
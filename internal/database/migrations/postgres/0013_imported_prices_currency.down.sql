@@ -0,0 +1,2 @@
+-- A correção da moeda dos produtos importados não é desfeita: voltar para BRL
+-- deixaria os preços em dólar marcados com a moeda errada outra vez.
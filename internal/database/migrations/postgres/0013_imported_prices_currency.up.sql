@@ -0,0 +1,4 @@
+-- A 0010_products_currency gravou todos os preços já existentes como BRL, mas os
+-- produtos importados da fakestoreapi tinham os preços em centavos de dólar.
+-- A correção é feita pela etapa em Go (fixImportedCurrency), que registra cada
+-- produto corrigido na auditoria.
@@ -0,0 +1,26 @@
+-- Índice de busca textual (FTS5) sobre nome e descrição dos produtos.
+-- O tokenizer unicode61 com remove_diacritics ignora acentos: "algodao" encontra "Algodão".
+CREATE VIRTUAL TABLE IF NOT EXISTS products_fts USING fts5(
+	name,
+	description,
+	content='products',
+	content_rowid='id',
+	tokenize='unicode61 remove_diacritics 2'
+);
+
+-- Gatilhos que mantêm o índice sincronizado com a tabela de produtos
+CREATE TRIGGER IF NOT EXISTS products_fts_insert AFTER INSERT ON products BEGIN
+	INSERT INTO products_fts (rowid, name, description) VALUES (new.id, new.name, new.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS products_fts_delete AFTER DELETE ON products BEGIN
+	INSERT INTO products_fts (products_fts, rowid, name, description) VALUES ('delete', old.id, old.name, old.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS products_fts_update AFTER UPDATE OF name, description ON products BEGIN
+	INSERT INTO products_fts (products_fts, rowid, name, description) VALUES ('delete', old.id, old.name, old.description);
+	INSERT INTO products_fts (rowid, name, description) VALUES (new.id, new.name, new.description);
+END;
+
+-- Indexar os produtos já existentes
+INSERT INTO products_fts (products_fts) VALUES ('rebuild');
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS products_category_id_idx;
+ALTER TABLE products DROP COLUMN category_id;
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS products_updated_at_idx;
+ALTER TABLE products DROP COLUMN updated_at;
+ALTER TABLE products DROP COLUMN created_at;
@@ -0,0 +1,11 @@
+-- Datas de criação e de última alteração dos produtos, gravadas pelo repositório em UTC.
+-- O SQLite não aceita um padrão não constante no ADD COLUMN: os produtos já
+-- existentes recebem o momento da migração logo em seguida.
+ALTER TABLE products ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT '1970-01-01 00:00:00+00:00';
+ALTER TABLE products ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT '1970-01-01 00:00:00+00:00';
+UPDATE products SET
+	created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+	updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now');
+
+-- Consultas de sincronização (updated_since=) filtram e ordenam por updated_at
+CREATE INDEX products_updated_at_idx ON products (updated_at);
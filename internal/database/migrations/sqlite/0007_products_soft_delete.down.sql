@@ -0,0 +1,4 @@
+-- Os produtos que estavam na lixeira são removidos de vez
+DELETE FROM products WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS products_deleted_at_idx;
+ALTER TABLE products DROP COLUMN deleted_at;
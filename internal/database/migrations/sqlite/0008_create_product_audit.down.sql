@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS product_audit_no_delete;
+DROP TRIGGER IF EXISTS product_audit_no_update;
+DROP INDEX IF EXISTS product_audit_created_at_idx;
+DROP INDEX IF EXISTS product_audit_product_id_idx;
+DROP TABLE IF EXISTS product_audit;
@@ -0,0 +1,26 @@
+-- Auditoria das alterações de produtos: cada criação, atualização, exclusão,
+-- restauração e remoção definitiva gera uma linha, gravada na mesma transação da alteração.
+-- Sem REFERENCES: o histórico continua disponível depois que o produto é removido de vez.
+CREATE TABLE product_audit (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	product_id INTEGER NOT NULL,
+	action TEXT NOT NULL,
+	actor TEXT NOT NULL,
+	source TEXT NOT NULL,
+	request_id TEXT NOT NULL DEFAULT '',
+	before_data TEXT, -- Produto (JSON) antes da alteração; NULL na criação
+	after_data TEXT,  -- Produto (JSON) depois da alteração; NULL na remoção definitiva
+	created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX product_audit_product_id_idx ON product_audit (product_id);
+CREATE INDEX product_audit_created_at_idx ON product_audit (created_at);
+
+-- A auditoria é somente de inserção: alterar ou apagar linhas é proibido
+CREATE TRIGGER product_audit_no_update BEFORE UPDATE ON product_audit BEGIN
+	SELECT RAISE(ABORT, 'product_audit é somente de inserção');
+END;
+
+CREATE TRIGGER product_audit_no_delete BEFORE DELETE ON product_audit BEGIN
+	SELECT RAISE(ABORT, 'product_audit é somente de inserção');
+END;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS price_history_product_id_changed_at_idx;
+DROP TABLE IF EXISTS price_history;
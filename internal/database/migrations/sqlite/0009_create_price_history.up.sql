@@ -0,0 +1,15 @@
+-- Histórico de preços: uma linha por preço que o produto teve, gravada na mesma
+-- transação da criação ou alteração que o definiu. Como na auditoria, o histórico
+-- continua disponível depois que o produto é removido de vez.
+CREATE TABLE price_history (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	product_id INTEGER NOT NULL,
+	price INTEGER NOT NULL,
+	previous_price INTEGER, -- NULL no primeiro preço do produto
+	changed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX price_history_product_id_changed_at_idx ON price_history (product_id, changed_at);
+
+-- Os produtos já existentes começam o histórico com o preço atual, na data de criação
+INSERT INTO price_history (product_id, price, changed_at) SELECT id, price, created_at FROM products;
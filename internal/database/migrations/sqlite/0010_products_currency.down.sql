@@ -0,0 +1,2 @@
+ALTER TABLE price_history DROP COLUMN currency;
+ALTER TABLE products DROP COLUMN currency;
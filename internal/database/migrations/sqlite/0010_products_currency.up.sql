@@ -0,0 +1,6 @@
+-- Moeda dos preços (ISO 4217). Os preços gravados antes desta migração não
+-- tinham moeda explícita e passam a ser da moeda padrão, BRL.
+ALTER TABLE products ADD COLUMN currency TEXT NOT NULL DEFAULT 'BRL';
+
+-- O histórico de preços registra também a moeda de cada preço
+ALTER TABLE price_history ADD COLUMN currency TEXT NOT NULL DEFAULT 'BRL';
@@ -0,0 +1,258 @@
+package migrations
+
+import (
+	"braip/internal/audit"
+	"braip/internal/database"
+	"braip/internal/models"
+	"braip/internal/textutil"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// afterUp são as etapas em Go executadas após o SQL de subida de cada versão
+var afterUp = map[int]func(tx *sql.Tx, driver string) error{
+	3:  normalizeCategories,
+	11: fillNameKeys,
+	13: fixImportedCurrency,
+}
+
+// migrationActor é o autor, na auditoria, das alterações de produtos feitas pelas migrações
+var migrationActor = audit.Actor{Name: "migrations", Source: audit.SourceSystem}
+
+// normalizeCategories cria uma categoria para cada grafia distinta das categorias
+// dos produtos e associa os produtos a ela. Grafias com o mesmo slug
+// ("Electronics", "electronics", " ELECTRONICS") viram uma única categoria,
+// cujo nome é a grafia mais usada.
+func normalizeCategories(tx *sql.Tx, driver string) error {
+	rows, err := tx.Query("SELECT category, COUNT(*) FROM products GROUP BY category")
+	if err != nil {
+		return err
+	}
+
+	type spelling struct {
+		text  string
+		count int
+	}
+	bySlug := make(map[string][]spelling)
+	for rows.Next() {
+		var s spelling
+		if err := rows.Scan(&s.text, &s.count); err != nil {
+			rows.Close()
+			return err
+		}
+		slug := textutil.Slug(s.text)
+		if slug == "" {
+			continue // Produtos sem categoria aproveitável ficam com category_id nulo
+		}
+		bySlug[slug] = append(bySlug[slug], s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	slugs := make([]string, 0, len(bySlug))
+	for slug := range bySlug {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs) // IDs determinísticos
+
+	for _, slug := range slugs {
+		spellings := bySlug[slug]
+		sort.Slice(spellings, func(i, j int) bool {
+			if spellings[i].count != spellings[j].count {
+				return spellings[i].count > spellings[j].count
+			}
+			return spellings[i].text < spellings[j].text
+		})
+
+		var id int64
+		err := tx.QueryRow(db.Rebind(driver, "INSERT INTO categories (slug, name) VALUES (?, ?) RETURNING id"),
+			slug, textutil.Clean(spellings[0].text)).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range spellings {
+			if _, err := tx.Exec(db.Rebind(driver, "UPDATE products SET category_id = ? WHERE category = ?"), id, s.text); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fillNameKeys preenche a chave de nome (textutil.Key) de todos os produtos.
+// Nomes repetidos entre os produtos fora da lixeira impediriam o índice único:
+// o produto mais antigo (menor ID) mantém o nome e os demais recebem um sufixo
+// ("Camisa (2)", "Camisa (3)", ...), registrado no log e na auditoria.
+func fillNameKeys(tx *sql.Tx, driver string) error {
+	rows, err := tx.Query("SELECT id, name, deleted_at IS NOT NULL FROM products ORDER BY id")
+	if err != nil {
+		return err
+	}
+
+	type product struct {
+		id      int
+		name    string
+		trashed bool
+	}
+	var products []product
+	for rows.Next() {
+		var p product
+		if err := rows.Scan(&p.id, &p.name, &p.trashed); err != nil {
+			rows.Close()
+			return err
+		}
+		products = append(products, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	used := make(map[string]bool)
+	for _, p := range products {
+		name, key := p.name, textutil.Key(p.name)
+		if !p.trashed {
+			for n := 2; used[key]; n++ {
+				name = fmt.Sprintf("%s (%d)", textutil.Clean(p.name), n)
+				key = textutil.Key(name)
+			}
+			used[key] = true
+		}
+		if name != p.name {
+			log.Printf("Produto %d renomeado de %q para %q: nome repetido", p.id, p.name, name)
+			if err := updateProduct(tx, driver, p.id, "name = ?, name_key = ?", name, key); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err := tx.Exec(db.Rebind(driver, "UPDATE products SET name_key = ? WHERE id = ?"), key, p.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importerURLPrefix é o início das imagens dos produtos da fakestoreapi
+const importerURLPrefix = "https://fakestoreapi.com/"
+
+// fixImportedCurrency passa para USD os produtos importados da fakestoreapi que a
+// 0010_products_currency marcou como BRL. Um produto é considerado importado se a
+// última criação ou alteração registrada na auditoria veio do importador ou, sem
+// registros (importações anteriores à auditoria), se a imagem é da fakestoreapi.
+// O histórico de preços desses produtos também passa para USD. Os produtos com
+// imagem da fakestoreapi alterados depois por outro meio mantêm BRL e ficam no log,
+// para conferência manual.
+func fixImportedCurrency(tx *sql.Tx, driver string) error {
+	rows, err := tx.Query(db.Rebind(driver, `
+		SELECT p.id, (
+			SELECT a.source FROM product_audit a
+			WHERE a.product_id = p.id AND a.action IN (?, ?)
+			ORDER BY a.id DESC LIMIT 1
+		), p.image_url
+		FROM products p WHERE p.currency = 'BRL' ORDER BY p.id`),
+		audit.ActionCreate, audit.ActionUpdate)
+	if err != nil {
+		return err
+	}
+
+	var imported []int
+	for rows.Next() {
+		var id int
+		var source, imageURL sql.NullString
+		if err := rows.Scan(&id, &source, &imageURL); err != nil {
+			rows.Close()
+			return err
+		}
+		fromImporter := strings.HasPrefix(imageURL.String, importerURLPrefix)
+		switch {
+		case source.String == audit.SourceImporter || (!source.Valid && fromImporter):
+			imported = append(imported, id)
+		case fromImporter:
+			log.Printf("Produto %d tem imagem da fakestoreapi, mas foi alterado por último por outro meio (%s): moeda BRL mantida; confira o preço", id, source.String)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range imported {
+		if err := updateProduct(tx, driver, id, "currency = 'USD'"); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(db.Rebind(driver, "UPDATE price_history SET currency = 'USD' WHERE product_id = ? AND currency = 'BRL'"), id); err != nil {
+			return err
+		}
+	}
+	if len(imported) > 0 {
+		log.Printf("%d produtos importados passaram de BRL para USD", len(imported))
+	}
+	return nil
+}
+
+// updateProduct altera o produto com as atribuições set (e os argumentos delas) como
+// o repositório faria, incrementando a versão e a data de alteração, e registra a
+// alteração na auditoria, com o produto antes e depois dela
+func updateProduct(tx *sql.Tx, driver string, id int, set string, args ...interface{}) error {
+	before, err := readProduct(tx, driver, id)
+	if err != nil {
+		return err
+	}
+	changedAt := time.Now().UTC().Truncate(time.Microsecond)
+	query := "UPDATE products SET " + set + ", version = version + 1, updated_at = ? WHERE id = ?"
+	if _, err := tx.Exec(db.Rebind(driver, query), append(args, changedAt, id)...); err != nil {
+		return err
+	}
+	after, err := readProduct(tx, driver, id)
+	if err != nil {
+		return err
+	}
+
+	beforeData, err := json.Marshal(before)
+	if err != nil {
+		return err
+	}
+	afterData, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(db.Rebind(driver, `
+		INSERT INTO product_audit (product_id, action, actor, source, request_id, before_data, after_data, created_at)
+		VALUES (?, ?, ?, ?, '', ?, ?, ?)`),
+		id, audit.ActionUpdate, migrationActor.Name, migrationActor.Source, string(beforeData), string(afterData), changedAt)
+	return err
+}
+
+// readProduct lê o produto no formato registrado na auditoria
+func readProduct(tx *sql.Tx, driver string, id int) (*models.Product, error) {
+	var p models.Product
+	var categoryID sql.NullInt64
+	var category, imageURL sql.NullString
+	var createdAt, updatedAt, deletedAt sql.NullTime
+	err := tx.QueryRow(db.Rebind(driver, `
+		SELECT products.id, products.name, products.price, products.currency, products.description,
+			products.category_id, categories.name, products.image_url, products.version,
+			products.created_at, products.updated_at, products.deleted_at
+		FROM products LEFT JOIN categories ON categories.id = products.category_id
+		WHERE products.id = ?`), id).Scan(&p.ID, &p.Name, &p.Price, &p.Currency, &p.Description,
+		&categoryID, &category, &imageURL, &p.Version, &createdAt, &updatedAt, &deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	p.CategoryID, p.Category, p.ImageURL = int(categoryID.Int64), category.String, imageURL.String
+	p.CreatedAt, p.UpdatedAt = createdAt.Time.UTC(), updatedAt.Time.UTC()
+	if deletedAt.Valid {
+		t := deletedAt.Time.UTC()
+		p.DeletedAt = &t
+	}
+	return &p, nil
+}
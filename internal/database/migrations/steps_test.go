@@ -0,0 +1,191 @@
+package migrations
+
+import (
+	"braip/internal/database"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+// openTestDB abre um banco SQLite vazio no diretório temporário do teste
+func openTestDB(t *testing.T) (*sql.DB, *Migrator) {
+	t.Helper()
+	conn, err := db.OpenDB(filepath.Join(t.TempDir(), "test.db"), db.DefaultPoolConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	migrator, err := New(conn, db.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, migrator
+}
+
+// migrateTo leva o banco até a versão informada, falhando o teste em caso de erro
+func migrateTo(t *testing.T, migrator *Migrator, version int) {
+	t.Helper()
+	if _, err := migrator.To(version); err != nil {
+		t.Fatalf("migração até a versão %d: %v", version, err)
+	}
+}
+
+// exec executa os comandos SQL, falhando o teste em caso de erro
+func exec(t *testing.T, conn *sql.DB, query string, args ...interface{}) {
+	t.Helper()
+	if _, err := conn.Exec(query, args...); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
+
+func TestFixImportedCurrency(t *testing.T) {
+	conn, migrator := openTestDB(t)
+
+	// Antes da auditoria: o importador gravava os preços em centavos de dólar
+	migrateTo(t, migrator, 1)
+	exec(t, conn, `INSERT INTO products (id, name, price, description, category, image_url) VALUES
+		(1, 'Mochila Fjallraven', 10995, 'Mochila', 'bags', 'https://fakestoreapi.com/img/1.jpg'),
+		(2, 'Camisa da loja', 1990, 'Camisa', 'roupas', 'https://example.com/2.jpg'),
+		(3, 'Jaqueta', 5599, 'Jaqueta', 'roupas', 'https://fakestoreapi.com/img/3.jpg')`)
+
+	// Depois da auditoria: a jaqueta foi alterada pela API e outro produto foi importado
+	migrateTo(t, migrator, 9)
+	exec(t, conn, `INSERT INTO product_audit (product_id, action, actor, source, created_at) VALUES
+		(3, 'create', 'fakestoreapi', 'importer', '2024-05-01 10:00:00+00:00'),
+		(3, 'update', 'anonymous', 'api', '2024-05-02 10:00:00+00:00'),
+		(3, 'delete', 'fakestoreapi', 'importer', '2024-05-03 10:00:00+00:00')`)
+	exec(t, conn, `INSERT INTO products (id, name, price, description, category_id, image_url, created_at, updated_at)
+		VALUES (4, 'Relógio', 2230, 'Relógio', NULL, 'https://cdn.example.com/4.jpg', '2024-05-04 10:00:00+00:00', '2024-05-04 10:00:00+00:00')`)
+	exec(t, conn, `INSERT INTO product_audit (product_id, action, actor, source, created_at) VALUES
+		(4, 'create', 'fakestoreapi', 'importer', '2024-05-04 10:00:00+00:00')`)
+	exec(t, conn, `INSERT INTO price_history (product_id, price, changed_at) VALUES (4, 2230, '2024-05-04 10:00:00+00:00')`)
+
+	migrateTo(t, migrator, 13)
+
+	want := map[int]string{1: "USD", 2: "BRL", 3: "BRL", 4: "USD"}
+	for id, currency := range want {
+		var got string
+		var version int
+		if err := conn.QueryRow("SELECT currency, version FROM products WHERE id = ?", id).Scan(&got, &version); err != nil {
+			t.Fatal(err)
+		}
+		if got != currency {
+			t.Errorf("produto %d: moeda %s; esperado %s", id, got, currency)
+		}
+		if wantVersion := map[bool]int{true: 2, false: 1}[currency == "USD"]; version != wantVersion {
+			t.Errorf("produto %d: versão %d; esperado %d", id, version, wantVersion)
+		}
+
+		var history string
+		if err := conn.QueryRow("SELECT currency FROM price_history WHERE product_id = ?", id).Scan(&history); err != nil {
+			t.Fatal(err)
+		}
+		if history != currency {
+			t.Errorf("produto %d: moeda no histórico de preços %s; esperado %s", id, history, currency)
+		}
+	}
+
+	// Cada correção fica na auditoria, com o produto antes e depois
+	rows, err := conn.Query("SELECT product_id, actor, source, before_data, after_data FROM product_audit WHERE actor = 'migrations' ORDER BY product_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var audited []int
+	for rows.Next() {
+		var id int
+		var actor, source, before, after string
+		if err := rows.Scan(&id, &actor, &source, &before, &after); err != nil {
+			t.Fatal(err)
+		}
+		audited = append(audited, id)
+		var b, a struct {
+			Price    int    `json:"price"`
+			Currency string `json:"currency"`
+			Version  int    `json:"version"`
+		}
+		if err := json.Unmarshal([]byte(before), &b); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal([]byte(after), &a); err != nil {
+			t.Fatal(err)
+		}
+		if source != "system" || b.Currency != "BRL" || a.Currency != "USD" || a.Price != b.Price || a.Version != b.Version+1 {
+			t.Errorf("auditoria do produto %d: %s (%s), antes %+v, depois %+v", id, actor, source, b, a)
+		}
+	}
+	if len(audited) != 2 || audited[0] != 1 || audited[1] != 4 {
+		t.Errorf("produtos na auditoria da migração: %v; esperado [1 4]", audited)
+	}
+
+	// Desfeita e aplicada de novo, a migração não tem mais o que corrigir
+	migrateTo(t, migrator, 12)
+	migrateTo(t, migrator, 13)
+	var count int
+	if err := conn.QueryRow("SELECT COUNT(*) FROM product_audit WHERE actor = 'migrations'").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("%d registros da migração na auditoria depois de reaplicá-la; esperado 2", count)
+	}
+}
+
+func TestFillNameKeys(t *testing.T) {
+	conn, migrator := openTestDB(t)
+	migrateTo(t, migrator, 10)
+	exec(t, conn, `INSERT INTO products (id, name, price, description, image_url, created_at, updated_at, deleted_at) VALUES
+		(1, 'Camisa', 1990, 'Camisa', NULL, '2024-05-01 10:00:00+00:00', '2024-05-01 10:00:00+00:00', NULL),
+		(2, 'camisa ', 2990, 'Camisa', NULL, '2024-05-01 10:00:00+00:00', '2024-05-01 10:00:00+00:00', NULL),
+		(3, 'CAMISA', 3990, 'Camisa', NULL, '2024-05-01 10:00:00+00:00', '2024-05-01 10:00:00+00:00', '2024-05-02 10:00:00+00:00'),
+		(4, 'Camisa (2)', 4990, 'Camisa', NULL, '2024-05-01 10:00:00+00:00', '2024-05-01 10:00:00+00:00', NULL),
+		(5, 'Câmisa', 5990, 'Camisa', NULL, '2024-05-01 10:00:00+00:00', '2024-05-01 10:00:00+00:00', NULL)`)
+
+	migrateTo(t, migrator, 11)
+
+	// O mais antigo mantém o nome e os da lixeira não o disputam. O sufixo também
+	// evita os nomes já usados: "camisa (2)" tem a mesma chave que o produto 4.
+	want := map[int]string{1: "Camisa", 2: "camisa (2)", 3: "CAMISA", 4: "Camisa (2) (2)", 5: "Câmisa (3)"}
+	renamed := map[int]string{2: "camisa ", 4: "Camisa (2)", 5: "Câmisa"}
+
+	for id, name := range want {
+		var got string
+		var version int
+		if err := conn.QueryRow("SELECT name, version FROM products WHERE id = ?", id).Scan(&got, &version); err != nil {
+			t.Fatal(err)
+		}
+		if got != name {
+			t.Errorf("produto %d: nome %q; esperado %q", id, got, name)
+		}
+		if _, ok := renamed[id]; ok != (version == 2) {
+			t.Errorf("produto %d: versão %d", id, version)
+		}
+	}
+
+	// Cada renomeação fica na auditoria, com o nome antes e depois
+	rows, err := conn.Query("SELECT product_id, before_data, after_data FROM product_audit WHERE actor = 'migrations'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	audited := 0
+	for rows.Next() {
+		var id int
+		var before, after string
+		if err := rows.Scan(&id, &before, &after); err != nil {
+			t.Fatal(err)
+		}
+		audited++
+		var b, a struct {
+			Name string `json:"name"`
+		}
+		json.Unmarshal([]byte(before), &b)
+		json.Unmarshal([]byte(after), &a)
+		if b.Name != renamed[id] || a.Name != want[id] {
+			t.Errorf("auditoria do produto %d: nome %q -> %q; esperado %q -> %q", id, b.Name, a.Name, renamed[id], want[id])
+		}
+	}
+	if audited != len(renamed) {
+		t.Errorf("%d renomeações na auditoria; esperado %d", audited, len(renamed))
+	}
+}
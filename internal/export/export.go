@@ -0,0 +1,224 @@
+package export
+
+import (
+	"braip/internal/apperror"
+	"braip/internal/filter"
+	"braip/internal/models"
+	"braip/internal/repository"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formatos de exportação
+const (
+	FormatCSV    = "csv"    // Uma linha por produto, com cabeçalho (RFC 4180)
+	FormatNDJSON = "ndjson" // Um objeto JSON por linha
+	FormatXLSX   = "xlsx"   // Planilha do Excel (Office Open XML)
+)
+
+// contentTypes é o tipo MIME de cada formato
+var contentTypes = map[string]string{
+	FormatCSV:    "text/csv; charset=utf-8",
+	FormatNDJSON: "application/x-ndjson",
+	FormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// DefaultColumns são as colunas exportadas quando fields= não é informado
+var DefaultColumns = []string{"id", "name", "price", "currency", "description", "category_id", "category", "image_url", "version", "created_at", "updated_at"}
+
+// columnAliases são os nomes de coluna alternativos aceitos em fields= (os da versão 2 das respostas)
+var columnAliases = map[string]string{"image": "image_url"}
+
+// CheckFormat retorna um erro de requisição se o formato de exportação não existir
+func CheckFormat(format string) error {
+	if _, ok := contentTypes[format]; !ok {
+		return apperror.BadRequest("formato de exportação inválido: " + format + " (use csv, ndjson ou xlsx)")
+	}
+	return nil
+}
+
+// ContentType retorna o tipo MIME do formato
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// ParseQuery lê a seleção dos produtos e as colunas exportadas dos mesmos parâmetros
+// da busca: q (opcional), os filtros de GET /products, sort (apenas sem q) e fields.
+// A exportação não é paginada: limit, offset e cursor são recusados.
+func ParseQuery(query url.Values) (repository.ExportOptions, []string, error) {
+	var opts repository.ExportOptions
+	for _, param := range []string{"limit", "offset", "cursor"} {
+		if query.Has(param) {
+			return opts, nil, apperror.BadRequest("a exportação não é paginada: remova o parâmetro '" + param + "'")
+		}
+	}
+
+	opts.Query = strings.TrimSpace(query.Get("q"))
+	if query.Has("q") && opts.Query == "" {
+		return opts, nil, apperror.BadRequest("Parâmetro 'q' não pode ser vazio")
+	}
+
+	var err error
+	if opts.Sort, err = repository.ParseSort(query.Get("sort")); err != nil {
+		return opts, nil, apperror.BadRequest(err.Error())
+	}
+	if opts.Query != "" && len(opts.Sort) > 0 {
+		return opts, nil, apperror.BadRequest("com 'q', os produtos são exportados por relevância e 'sort' não é aceito")
+	}
+
+	if opts.Filter, err = filter.FromQuery(query); err != nil {
+		return opts, nil, apperror.BadRequest(err.Error())
+	}
+
+	names := strings.Split(query.Get("fields"), ",")
+	for i, name := range names {
+		if column, ok := columnAliases[strings.TrimSpace(name)]; ok {
+			names[i] = column
+		}
+	}
+	columns, err := repository.ParseFields(strings.Join(names, ","))
+	if err != nil {
+		return opts, nil, apperror.BadRequest(err.Error())
+	}
+	if len(columns) == 0 {
+		columns = DefaultColumns
+	}
+	return opts, columns, nil
+}
+
+// Writer grava os produtos exportados, um de cada vez
+type Writer interface {
+	// Write grava um produto
+	Write(p *models.Product) error
+	// Close termina o arquivo; deve ser chamado mesmo sem produtos
+	Close() error
+}
+
+// NewWriter cria o Writer do formato, que grava as colunas informadas em w.
+// A saída é bufferizada e só fica completa depois de Close.
+func NewWriter(w io.Writer, format string, columns []string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatNDJSON:
+		return &ndjsonWriter{w: bufio.NewWriter(w), columns: columns}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w, columns)
+	default:
+		return nil, CheckFormat(format)
+	}
+}
+
+// columnValue é o valor de uma coluna do produto: int, string ou nil (vazio)
+func columnValue(p *models.Product, column string) interface{} {
+	switch column {
+	case "id":
+		return p.ID
+	case "name":
+		return p.Name
+	case "price":
+		return p.Price
+	case "currency":
+		return p.Currency
+	case "description":
+		return p.Description
+	case "category_id":
+		return p.CategoryID
+	case "category":
+		return p.Category
+	case "image_url":
+		return p.ImageURL
+	case "version":
+		return p.Version
+	case "created_at":
+		return formatTime(p.CreatedAt)
+	case "updated_at":
+		return formatTime(p.UpdatedAt)
+	case "deleted_at":
+		if p.DeletedAt == nil {
+			return nil
+		}
+		return formatTime(*p.DeletedAt)
+	}
+	return nil
+}
+
+// formatTime formata as datas como nas respostas da API (RFC 3339, em UTC)
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// csvWriter grava os produtos em CSV, com uma linha de cabeçalho
+type csvWriter struct {
+	w       *csv.Writer
+	columns []string
+	record  []string
+}
+
+// newCSVWriter cria o writer e grava a linha de cabeçalho
+func newCSVWriter(w io.Writer, columns []string) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w), columns: columns, record: make([]string, len(columns))}
+	if err := cw.w.Write(columns); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+// Write grava a linha do produto
+func (cw *csvWriter) Write(p *models.Product) error {
+	for i, column := range cw.columns {
+		switch v := columnValue(p, column).(type) {
+		case int:
+			cw.record[i] = strconv.Itoa(v)
+		case string:
+			cw.record[i] = v
+		default:
+			cw.record[i] = ""
+		}
+	}
+	return cw.w.Write(cw.record)
+}
+
+// Close grava o que ainda está no buffer
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// ndjsonWriter grava cada produto como um objeto JSON, com as colunas na ordem pedida
+type ndjsonWriter struct {
+	w       *bufio.Writer
+	columns []string
+}
+
+// Write grava o objeto do produto, seguido de uma quebra de linha
+func (nw *ndjsonWriter) Write(p *models.Product) error {
+	nw.w.WriteByte('{')
+	for i, column := range nw.columns {
+		if i > 0 {
+			nw.w.WriteByte(',')
+		}
+		key, _ := json.Marshal(column)
+		value, err := json.Marshal(columnValue(p, column))
+		if err != nil {
+			return err
+		}
+		nw.w.Write(key)
+		nw.w.WriteByte(':')
+		nw.w.Write(value)
+	}
+	// Os erros do bufio.Writer se repetem nas escritas seguintes: o da última basta
+	_, err := nw.w.WriteString("}\n")
+	return err
+}
+
+// Close grava o que ainda está no buffer
+func (nw *ndjsonWriter) Close() error {
+	return nw.w.Flush()
+}
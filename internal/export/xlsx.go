@@ -0,0 +1,142 @@
+package export
+
+import (
+	"archive/zip"
+	"braip/internal/models"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Partes fixas do pacote XLSX (Office Open XML, ECMA-376): uma pasta de trabalho
+// com uma única planilha, sem estilos. As células de texto usam inlineStr, para que
+// as linhas possam ser gravadas uma a uma, sem a tabela de textos compartilhados.
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="products" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+// xlsxMaxCellText é o maior texto que o Excel aceita numa célula
+const xlsxMaxCellText = 32767
+
+// xlsxWriter grava os produtos numa planilha XLSX. O zip é gravado em sequência:
+// as partes fixas primeiro e a planilha, linha a linha, por último.
+type xlsxWriter struct {
+	zip     *zip.Writer
+	sheet   *bufio.Writer
+	columns []string
+	letters []string // Letra de cada coluna (A, B, ..., AA, ...)
+	row     int
+}
+
+// newXLSXWriter grava as partes fixas do pacote e a linha de cabeçalho
+func newXLSXWriter(w io.Writer, columns []string) (*xlsxWriter, error) {
+	zw := zip.NewWriter(w)
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	xw := &xlsxWriter{zip: zw, sheet: bufio.NewWriter(sheet), columns: columns, letters: make([]string, len(columns))}
+	for i := range columns {
+		xw.letters[i] = columnLetter(i)
+	}
+
+	xw.sheet.WriteString(xlsxSheetStart)
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		header[i] = column
+	}
+	return xw, xw.writeRow(header)
+}
+
+// Write grava a linha do produto
+func (xw *xlsxWriter) Write(p *models.Product) error {
+	values := make([]interface{}, len(xw.columns))
+	for i, column := range xw.columns {
+		values[i] = columnValue(p, column)
+	}
+	return xw.writeRow(values)
+}
+
+// writeRow grava uma linha: números como células numéricas e textos como inlineStr
+func (xw *xlsxWriter) writeRow(values []interface{}) error {
+	xw.row++
+	row := strconv.Itoa(xw.row)
+	xw.sheet.WriteString(`<row r="` + row + `">`)
+	for i, value := range values {
+		ref := xw.letters[i] + row
+		switch v := value.(type) {
+		case int:
+			xw.sheet.WriteString(`<c r="` + ref + `"><v>` + strconv.Itoa(v) + `</v></c>`)
+		case string:
+			v = truncateText(v, xlsxMaxCellText)
+			xw.sheet.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+			xml.EscapeText(xw.sheet, []byte(v))
+			xw.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+	// Os erros do bufio.Writer se repetem nas escritas seguintes: o da última basta
+	_, err := xw.sheet.WriteString(`</row>`)
+	return err
+}
+
+// Close termina a planilha e grava o índice do zip
+func (xw *xlsxWriter) Close() error {
+	xw.sheet.WriteString(xlsxSheetEnd)
+	if err := xw.sheet.Flush(); err != nil {
+		return err
+	}
+	return xw.zip.Close()
+}
+
+// truncateText corta o texto em no máximo max bytes, sem partir um caractere
+func truncateText(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
+
+// columnLetter é o nome da coluna de índice i (0 = A, 25 = Z, 26 = AA, ...)
+func columnLetter(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
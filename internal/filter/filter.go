@@ -0,0 +1,253 @@
+// Package filter define a linguagem de filtros da listagem de produtos.
+//
+// Um filtro é uma árvore de condições (campo, operador, valor) combinadas com
+// AND e OR. Ele pode ser montado a partir dos parâmetros da URL
+// (name=camisa&price[gte]=1000) ou de uma expressão textual no parâmetro filter=
+// (price gte 1000 and (category eq "electronics" or has_image eq true)).
+//
+// Os valores já saem deste pacote validados e convertidos para o tipo do campo;
+// cada backend de armazenamento traduz a árvore para a sua própria consulta.
+package filter
+
+import (
+	"braip/internal/money"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Type é o tipo de dado de um campo filtrável
+type Type int
+
+const (
+	String Type = iota
+	Int
+	Bool
+	Time
+	Code // Código comparado sem diferenciar maiúsculas, como a moeda (USD)
+)
+
+// Op é um operador de comparação
+type Op string
+
+const (
+	Eq       Op = "eq"
+	Ne       Op = "ne"
+	Contains Op = "contains"
+	Gt       Op = "gt"
+	Gte      Op = "gte"
+	Lt       Op = "lt"
+	Lte      Op = "lte"
+	In       Op = "in"
+)
+
+// Fields são os campos filtráveis e seus tipos
+var Fields = map[string]Type{
+	"id":          Int,
+	"name":        String,
+	"price":       Int,
+	"currency":    Code,
+	"description": String,
+	"category_id": Int,
+	"category":    String,
+	"has_image":   Bool,
+	"created_at":  Time,
+	"updated_at":  Time,
+}
+
+// allowedOps são os operadores aceitos por cada tipo de campo
+var allowedOps = map[Type][]Op{
+	String: {Eq, Ne, Contains, In},
+	Int:    {Eq, Ne, Gt, Gte, Lt, Lte, In},
+	Bool:   {Eq, Ne},
+	Time:   {Eq, Ne, Gt, Gte, Lt, Lte},
+	Code:   {Eq, Ne, In},
+}
+
+// defaultOps é o operador usado quando o parâmetro não indica um (ex.: name=camisa)
+var defaultOps = map[Type]Op{
+	String: Contains,
+	Int:    Eq,
+	Bool:   Eq,
+	Time:   Gte,
+	Code:   Eq,
+}
+
+// Expr é um nó da árvore de filtros: Condition, And ou Or
+type Expr interface {
+	String() string
+}
+
+// Condition compara um campo com um valor. Value é string, int, bool ou time.Time
+// (em UTC), conforme o tipo do campo; no operador In, é []string ou []int.
+type Condition struct {
+	Field string
+	Op    Op
+	Value interface{}
+}
+
+// And é satisfeito quando todas as expressões são satisfeitas
+type And []Expr
+
+// Or é satisfeito quando pelo menos uma das expressões é satisfeita
+type Or []Expr
+
+// NewCondition valida o campo e o operador e converte o valor para o tipo do campo
+func NewCondition(field string, op Op, raw string) (Condition, error) {
+	typ, ok := Fields[field]
+	if !ok {
+		return Condition{}, fmt.Errorf("campo de filtro inválido: %q", field)
+	}
+	if op == "" {
+		op = defaultOps[typ]
+	}
+	if !opAllowed(typ, op) {
+		return Condition{}, fmt.Errorf("operador %q não pode ser usado com o campo %q", op, field)
+	}
+	if typ == Code {
+		raw = strings.ToUpper(raw)
+	}
+
+	if op == In {
+		var parts []string
+		for _, part := range strings.Split(raw, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				parts = append(parts, part)
+			}
+		}
+		if len(parts) == 0 {
+			return Condition{}, fmt.Errorf("lista de valores vazia no filtro %q", field)
+		}
+		if typ == String || typ == Code {
+			return Condition{Field: field, Op: op, Value: parts}, nil
+		}
+		ints := make([]int, len(parts))
+		for i, part := range parts {
+			n, err := parseInt(field, part)
+			if err != nil {
+				return Condition{}, err
+			}
+			ints[i] = n
+		}
+		return Condition{Field: field, Op: op, Value: ints}, nil
+	}
+
+	switch typ {
+	case Int:
+		n, err := parseInt(field, raw)
+		if err != nil {
+			return Condition{}, err
+		}
+		return Condition{Field: field, Op: op, Value: n}, nil
+	case Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return Condition{}, fmt.Errorf("valor inválido no filtro %q: use true ou false", field)
+		}
+		return Condition{Field: field, Op: op, Value: b}, nil
+	case Time:
+		t, err := ParseTime(strings.TrimSpace(raw))
+		if err != nil {
+			return Condition{}, fmt.Errorf("valor inválido no filtro %q: use uma data (2006-01-02) ou data e hora RFC 3339 (2006-01-02T15:04:05Z)", field)
+		}
+		return Condition{Field: field, Op: op, Value: t}, nil
+	case Code:
+		return Condition{Field: field, Op: op, Value: strings.TrimSpace(raw)}, nil
+	default:
+		return Condition{Field: field, Op: op, Value: raw}, nil
+	}
+}
+
+// parseInt lê o valor de um campo inteiro. O preço é lido em unidades menores da
+// moeda (centavos), como é gravado, pelo mesmo conversor decimal do corpo das
+// requisições, que não aceita espaços em volta do valor.
+func parseInt(field, raw string) (int, error) {
+	if field == "price" {
+		n, err := money.ParseMinor(raw)
+		if err != nil {
+			return 0, fmt.Errorf("valor inválido no filtro %q: %v", field, err)
+		}
+		return n, nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		return 0, fmt.Errorf("valor inválido no filtro %q: %q não é um número inteiro", field, raw)
+	}
+	return n, nil
+}
+
+// ParseTime interpreta uma data e hora RFC 3339 ou apenas uma data (meia-noite em UTC)
+func ParseTime(raw string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		t, err = time.Parse("2006-01-02", raw)
+	}
+	return t.UTC(), err
+}
+
+// opAllowed indica se o operador pode ser usado com o tipo de campo
+func opAllowed(typ Type, op Op) bool {
+	for _, allowed := range allowedOps[typ] {
+		if allowed == op {
+			return true
+		}
+	}
+	return false
+}
+
+// Combine junta expressões com AND, ignorando as nulas
+func Combine(exprs ...Expr) Expr {
+	var and And
+	for _, e := range exprs {
+		if e != nil {
+			and = append(and, e)
+		}
+	}
+	switch len(and) {
+	case 0:
+		return nil
+	case 1:
+		return and[0]
+	default:
+		return and
+	}
+}
+
+// String representa a condição na sintaxe do parâmetro filter=
+func (c Condition) String() string {
+	switch v := c.Value.(type) {
+	case string:
+		return fmt.Sprintf("%s %s %q", c.Field, c.Op, v)
+	case []string:
+		quoted := make([]string, len(v))
+		for i, s := range v {
+			quoted[i] = strconv.Quote(s)
+		}
+		return fmt.Sprintf("%s %s (%s)", c.Field, c.Op, strings.Join(quoted, ", "))
+	case []int:
+		parts := make([]string, len(v))
+		for i, n := range v {
+			parts[i] = strconv.Itoa(n)
+		}
+		return fmt.Sprintf("%s %s (%s)", c.Field, c.Op, strings.Join(parts, ", "))
+	case time.Time:
+		return fmt.Sprintf("%s %s %s", c.Field, c.Op, v.Format(time.RFC3339Nano))
+	default:
+		return fmt.Sprintf("%s %s %v", c.Field, c.Op, v)
+	}
+}
+
+// String representa a expressão na sintaxe do parâmetro filter=
+func (a And) String() string { return join(a, " and ") }
+
+// String representa a expressão na sintaxe do parâmetro filter=
+func (o Or) String() string { return join(o, " or ") }
+
+func join(exprs []Expr, sep string) string {
+	parts := make([]string, len(exprs))
+	for i, e := range exprs {
+		parts[i] = e.String()
+	}
+	return "(" + strings.Join(parts, sep) + ")"
+}
@@ -0,0 +1,96 @@
+package filter
+
+import (
+	"net/url"
+	"testing"
+)
+
+// Os preços dos filtros estão em unidades menores da moeda (centavos), como no corpo
+// das requisições, e passam pelo mesmo conversor decimal
+func TestPriceValues(t *testing.T) {
+	tests := []struct {
+		query string
+		want  interface{}
+	}{
+		{"price[gte]=1000", 1000},
+		{"price[lte]=1.0995e4", 10995},
+		{"price=1990.00", 1990},
+		{"price[in]=1990,1.5e3", []int{1990, 1500}},
+	}
+	for _, tt := range tests {
+		query, err := url.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expr, err := FromQuery(query)
+		if err != nil {
+			t.Errorf("FromQuery(%s): erro inesperado: %v", tt.query, err)
+			continue
+		}
+		c, ok := expr.(Condition)
+		if !ok {
+			t.Errorf("FromQuery(%s) = %#v; esperado uma condição", tt.query, expr)
+			continue
+		}
+		if got, want := c.String(), (Condition{Field: "price", Op: c.Op, Value: tt.want}).String(); got != want {
+			t.Errorf("FromQuery(%s) = %s; esperado %s", tt.query, got, want)
+		}
+	}
+
+	// 19.90 não é arredondado para 1990 nem truncado para 19
+	for _, query := range []string{"price[gte]=19.90", "price[lte]=abc", "price[in]=1990,0.5", "price[gt]=1/2",
+		"price[lt]=%201990%20", "price=0x7c6", "price=1_990", "price[in]=1990,0b101"} {
+		values, _ := url.ParseQuery(query)
+		if expr, err := FromQuery(values); err == nil {
+			t.Errorf("FromQuery(%s) = %s; esperado erro", query, expr)
+		}
+	}
+	if expr, err := Parse("price >= 19.90"); err == nil {
+		t.Errorf("Parse(price >= 19.90) = %s; esperado erro", expr)
+	}
+
+	// Os demais campos inteiros continuam aceitando apenas inteiros
+	if expr, err := Parse("id = 1e3"); err == nil {
+		t.Errorf("Parse(id = 1e3) = %s; esperado erro", expr)
+	}
+}
+
+// A moeda é comparada sem diferenciar maiúsculas; na URL, ?currency= continua sendo
+// a moeda da conversão e o filtro precisa do operador
+func TestCurrencyValues(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"currency[eq]=usd", `currency eq "USD"`},
+		{"currency[ne]=BRL", `currency ne "BRL"`},
+		{"currency[in]=usd,Eur", `currency in ("USD", "EUR")`},
+		{"currency=USD", ""},
+		{"filter=currency = usd and price >= 1000", `(currency eq "USD" and price gte 1000)`},
+	}
+	for _, tt := range tests {
+		query, err := url.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expr, err := FromQuery(query)
+		if err != nil {
+			t.Errorf("FromQuery(%s): erro inesperado: %v", tt.query, err)
+			continue
+		}
+		got := ""
+		if expr != nil {
+			got = expr.String()
+		}
+		if got != tt.want {
+			t.Errorf("FromQuery(%s) = %s; esperado %s", tt.query, got, tt.want)
+		}
+	}
+
+	for _, query := range []string{"currency[contains]=US", "currency[gt]=BRL"} {
+		values, _ := url.ParseQuery(query)
+		if expr, err := FromQuery(values); err == nil {
+			t.Errorf("FromQuery(%s) = %s; esperado erro", query, expr)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package filter
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// symbolOps são os atalhos simbólicos aceitos na expressão de filter=
+var symbolOps = map[string]Op{
+	"=":  Eq,
+	"!=": Ne,
+	"~":  Contains,
+	">":  Gt,
+	">=": Gte,
+	"<":  Lt,
+	"<=": Lte,
+}
+
+// FromQuery monta o filtro a partir dos parâmetros da URL:
+//
+//	name=camisa            campo com o operador padrão (contains em textos, eq nos demais)
+//	price[gte]=1000        campo com operador explícito
+//	id=1,2,3               lista de IDs (equivale a id[in]=1,2,3)
+//	updated_since=<data>   alterados a partir da data (equivale a updated_at[gte]=<data>)
+//	match=any              combina os parâmetros acima com OR em vez de AND
+//	filter=<expressão>     expressão completa, combinada com AND aos demais parâmetros
+//
+// Parâmetros que não correspondem a campos filtráveis são ignorados.
+func FromQuery(query url.Values) (Expr, error) {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // Ordem determinística das condições
+
+	var conditions []Expr
+	for _, key := range keys {
+		field, op := key, Op("")
+		if i := strings.Index(key, "["); i > 0 && strings.HasSuffix(key, "]") {
+			field, op = key[:i], Op(key[i+1:len(key)-1])
+		}
+		if key == "updated_since" {
+			field, op = "updated_at", Gte // Atalho para a sincronização incremental
+		}
+		if key == "currency" {
+			continue // ?currency= é a moeda da conversão; o filtro usa currency[eq]= ou filter=
+		}
+		typ, ok := Fields[field]
+		if !ok {
+			continue
+		}
+
+		for _, raw := range query[key] {
+			fieldOp := op
+			if fieldOp == "" && typ == Int && strings.Contains(raw, ",") {
+				fieldOp = In
+			}
+			c, err := NewCondition(field, fieldOp, raw)
+			if err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, c)
+		}
+	}
+
+	var params Expr
+	switch match := query.Get("match"); match {
+	case "", "all":
+		params = Combine(conditions...)
+	case "any":
+		if len(conditions) == 1 {
+			params = conditions[0]
+		} else if len(conditions) > 1 {
+			params = Or(conditions)
+		}
+	default:
+		return nil, fmt.Errorf("parâmetro 'match' deve ser 'all' ou 'any'")
+	}
+
+	var expr Expr
+	if raw := query.Get("filter"); strings.TrimSpace(raw) != "" {
+		var err error
+		if expr, err = Parse(raw); err != nil {
+			return nil, err
+		}
+	}
+
+	return Combine(params, expr), nil
+}
+
+// Parse interpreta uma expressão de filtro. Gramática:
+//
+//	expr      = term { "or" term }
+//	term      = factor { "and" factor }
+//	factor    = "(" expr ")" | condition
+//	condition = campo operador valor
+//	operador  = eq | ne | contains | gt | gte | lt | lte | in | = | != | ~ | > | >= | < | <=
+//	valor     = palavra | "texto entre aspas" | "(" valor { "," valor } ")"
+//
+// Exemplo: price >= 1000 and (category = "electronics" or name contains ssd)
+func Parse(s string) (Expr, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("filtro inválido: trecho inesperado %q", p.peek().text)
+	}
+	return expr, nil
+}
+
+// token é uma unidade léxica da expressão de filtro
+type token struct {
+	text   string
+	quoted bool // Texto entre aspas: nunca é interpretado como palavra-chave
+}
+
+// tokenize separa a expressão em palavras, textos entre aspas, parênteses, vírgulas e símbolos
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(' || c == ')' || c == ',':
+			tokens = append(tokens, token{text: string(c)})
+			i++
+		case c == '"' || c == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != c; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				b.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("filtro inválido: aspas não fechadas")
+			}
+			tokens = append(tokens, token{text: b.String(), quoted: true})
+			i = j + 1
+		case strings.ContainsRune("=!<>~", c):
+			j := i + 1
+			if j < len(runes) && runes[j] == '=' {
+				j++
+			}
+			tokens = append(tokens, token{text: string(runes[i:j])})
+			i = j
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune("(),\"'=!<>~", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, token{text: string(runes[i:j])})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// parser é um analisador descendente recursivo sobre os tokens da expressão
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) done() bool { return p.pos >= len(p.tokens) }
+
+func (p *parser) peek() token {
+	if p.done() {
+		return token{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// keyword indica se o próximo token é a palavra-chave informada (sem aspas)
+func (p *parser) keyword(word string) bool {
+	t := p.peek()
+	return !p.done() && !t.quoted && strings.EqualFold(t.text, word)
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	or := Or{left}
+	for p.keyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		or = append(or, right)
+	}
+	if len(or) == 1 {
+		return left, nil
+	}
+	return or, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	and := And{left}
+	for p.keyword("and") {
+		p.next()
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, right)
+	}
+	if len(and) == 1 {
+		return left, nil
+	}
+	return and, nil
+}
+
+func (p *parser) parseFactor() (Expr, error) {
+	if p.done() {
+		return nil, fmt.Errorf("filtro inválido: expressão incompleta")
+	}
+
+	if p.keyword("(") {
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword(")") {
+			return nil, fmt.Errorf("filtro inválido: parêntese não fechado")
+		}
+		p.next()
+		return expr, nil
+	}
+
+	return p.parseCondition()
+}
+
+func (p *parser) parseCondition() (Expr, error) {
+	field := p.next()
+	if field.quoted {
+		return nil, fmt.Errorf("filtro inválido: esperado um campo, encontrado %q", field.text)
+	}
+
+	opToken := p.next()
+	if opToken.text == "" {
+		return nil, fmt.Errorf("filtro inválido: operador ausente após %q", field.text)
+	}
+	op, ok := symbolOps[opToken.text]
+	if !ok {
+		op = Op(strings.ToLower(opToken.text))
+	}
+
+	var raw string
+	if op == In {
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		raw = strings.Join(values, ",")
+	} else {
+		value := p.next()
+		if value.text == "" && !value.quoted {
+			return nil, fmt.Errorf("filtro inválido: valor ausente para %q", field.text)
+		}
+		raw = value.text
+	}
+
+	return NewCondition(field.text, op, raw)
+}
+
+// parseList lê uma lista de valores no formato (a, b, c)
+func (p *parser) parseList() ([]string, error) {
+	if !p.keyword("(") {
+		return nil, fmt.Errorf("filtro inválido: o operador in espera uma lista entre parênteses")
+	}
+	p.next()
+
+	var values []string
+	for {
+		value := p.next()
+		if value.text == "" && !value.quoted {
+			return nil, fmt.Errorf("filtro inválido: lista não fechada")
+		}
+		if strings.Contains(value.text, ",") {
+			return nil, fmt.Errorf("filtro inválido: valores de lista não podem conter vírgula")
+		}
+		values = append(values, value.text)
+
+		if p.keyword(")") {
+			p.next()
+			return values, nil
+		}
+		if !p.keyword(",") {
+			return nil, fmt.Errorf("filtro inválido: esperado ',' ou ')' na lista")
+		}
+		p.next()
+	}
+}
@@ -0,0 +1,123 @@
+package filter
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`name contains camisa`, `name contains "camisa"`},
+		{`price >= 1000`, `price gte 1000`},
+		{`price>=1000 and price<2000`, `(price gte 1000 and price lt 2000)`},
+		// AND tem precedência sobre OR; os parênteses mudam o agrupamento
+		{`id = 1 or id = 2 and price > 5`, `(id eq 1 or (id eq 2 and price gt 5))`},
+		{`(id = 1 or id = 2) and price > 5`, `((id eq 1 or id eq 2) and price gt 5)`},
+		{`price >= 1000 AND (category = "electronics" OR name contains ssd)`, `(price gte 1000 and (category eq "electronics" or name contains "ssd"))`},
+		// Textos entre aspas: espaços, palavras-chave e aspas escapadas
+		{`name = "camisa and calça"`, `name eq "camisa and calça"`},
+		{`name = 'or'`, `name eq "or"`},
+		{`name = "aspas \" dentro"`, `name eq "aspas \" dentro"`},
+		{`name = ""`, `name eq ""`},
+		// Listas
+		{`id in (1, 2,3)`, `id in (1, 2, 3)`},
+		{`category in ("roupas", calçados)`, `category in ("roupas", "calçados")`},
+		// Tipos convertidos
+		{`has_image = true`, `has_image eq true`},
+		{`has_image != FALSE`, `has_image ne false`},
+		{`created_at >= 2024-05-01`, `created_at gte 2024-05-01T00:00:00Z`},
+		{`updated_at < "2024-05-01T10:00:00-03:00"`, `updated_at lt 2024-05-01T13:00:00Z`},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%s): erro inesperado: %v", tt.input, err)
+			continue
+		}
+		if got := expr.String(); got != tt.want {
+			t.Errorf("Parse(%s) = %s; esperado %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		``,
+		`name`,
+		`name contains`,
+		`senha = 123`,
+		`NAME ~ camisa`, // Os nomes de campo diferenciam maiúsculas
+		`"name" = camisa`,
+		`name > camisa`,
+		`price contains 10`,
+		`has_image = talvez`,
+		`created_at >= ontem`,
+		`id = abc`,
+		`id in 1, 2`,
+		`id in (1, 2`,
+		`id in ()`,
+		`id in (1 2)`,
+		`(price > 1`,
+		`price > 1)`,
+		`price > 1 and`,
+		`price > 1 or or price < 2`,
+		`name = "sem fechar`,
+		`price > 1 price < 2`,
+	} {
+		if expr, err := Parse(input); err == nil {
+			t.Errorf("Parse(%s) = %s; esperado erro", input, expr)
+		}
+	}
+}
+
+func TestFromQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		// Operador padrão: contains nos textos, eq nos números
+		{"name=camisa", `name contains "camisa"`},
+		{"id=7", `id eq 7`},
+		{"id=1,2,3", `id in (1, 2, 3)`},
+		{"category[eq]=Roupas", `category eq "Roupas"`},
+		{"updated_since=2024-05-01", `updated_at gte 2024-05-01T00:00:00Z`},
+		// Parâmetros que não são campos são ignorados
+		{"limit=10&sort=price&q=camisa", ``},
+		// Condições na ordem das chaves, combinadas com AND, ou com OR em match=any
+		{"price[lt]=2000&name=camisa", `(name contains "camisa" and price lt 2000)`},
+		{"price[lt]=2000&name=camisa&match=any", `(name contains "camisa" or price lt 2000)`},
+		{"name=camisa&match=any", `name contains "camisa"`},
+		{"name=camisa&name=polo", `(name contains "camisa" and name contains "polo")`},
+		// filter= é combinado com AND aos demais parâmetros
+		{"name=camisa&match=any&price[gt]=1&filter=id in (1, 2)", `((name contains "camisa" or price gt 1) and id in (1, 2))`},
+		{"filter=  ", ``},
+	}
+	for _, tt := range tests {
+		query, err := url.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expr, err := FromQuery(query)
+		if err != nil {
+			t.Errorf("FromQuery(%s): erro inesperado: %v", tt.query, err)
+			continue
+		}
+		got := ""
+		if expr != nil {
+			got = expr.String()
+		}
+		if got != tt.want {
+			t.Errorf("FromQuery(%s) = %s; esperado %s", tt.query, got, tt.want)
+		}
+	}
+
+	for _, query := range []string{"match=algum&name=x", "name[gt]=x", "id=1,a", "price[foo]=1", "filter=price >"} {
+		values, _ := url.ParseQuery(query)
+		if expr, err := FromQuery(values); err == nil {
+			t.Errorf("FromQuery(%s) = %s; esperado erro", query, expr)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEntry é um registro da auditoria de produtos: quem alterou qual produto,
+// como e quando, com o produto antes e depois da alteração
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	ProductID int             `json:"product_id"`
+	Action    string          `json:"action"`
+	Actor     string          `json:"actor"`
+	Source    string          `json:"source"`
+	RequestID string          `json:"request_id,omitempty"`
+	Before    json.RawMessage `json:"before"` // null na criação
+	After     json.RawMessage `json:"after"`  // null na remoção definitiva
+	CreatedAt time.Time       `json:"created_at"`
+}
@@ -0,0 +1,11 @@
+package models
+
+// Category é uma categoria de produtos. O slug é gerado a partir do nome e é
+// único: "Electronics" e "electronics" são a mesma categoria.
+// ParentID é nil nas categorias de primeiro nível.
+type Category struct {
+	ID       int    `json:"id"`
+	Slug     string `json:"slug"`
+	Name     string `json:"name"`
+	ParentID *int   `json:"parent_id"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// IdempotencyRecord é a requisição feita com um Idempotency-Key e a resposta
+// guardada para ser repetida quando o cliente reenviar a mesma requisição
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string              // SHA-256 do método, do caminho e do corpo da requisição
+	Status      int                 // 0 enquanto a primeira requisição está em andamento
+	Header      map[string][]string // Cabeçalhos da resposta
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Completed informa se a resposta da requisição já foi guardada
+func (r *IdempotencyRecord) Completed() bool {
+	return r.Status != 0
+}
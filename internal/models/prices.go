@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// PriceChange é um ponto do histórico de preços: o preço que o produto passou a ter em ChangedAt
+type PriceChange struct {
+	ProductID     int       `json:"product_id"`
+	Price         int       `json:"price"`
+	Currency      string    `json:"currency"`
+	PreviousPrice *int      `json:"previous_price"` // nil no primeiro preço do produto
+	ChangedAt     time.Time `json:"changed_at"`
+
+	Conversion *PriceConversion `json:"conversion,omitempty"` // Presente quando o preço foi convertido na leitura (?currency=)
+}
+
+// PriceDrop é um produto cujo preço caiu em relação ao início de um período
+type PriceDrop struct {
+	Product
+	PreviousPrice int     `json:"previous_price"` // Preço no início do período
+	DropPercent   float64 `json:"drop_percent"`   // Queda em relação a PreviousPrice, em %
+}
@@ -1,10 +1,44 @@
 package models
 
+import "time"
+
 type Product struct {
 	ID          int     `json:"id"`
 	Name        string  `json:"name"`
-	Price       int 	`json:"price"`
+	Price       int 	`json:"price"` // Em unidades menores da moeda (centavos)
+	Currency    string  `json:"currency"` // Código ISO 4217; vazio na escrita usa a moeda padrão (BRL)
 	Description string  `json:"description"`
-	Category    string  `json:"category"`
+	CategoryID  int     `json:"category_id"`
+	Category    string  `json:"category"` // Nome da categoria; na escrita, usado quando category_id não é informado
 	ImageURL    string  `json:"image_url"`
-}
\ No newline at end of file
+	Version     int     `json:"version"` // Incrementada a cada alteração; base do ETag
+	CreatedAt   time.Time `json:"created_at"` // Mantidas pelo repositório, sempre em UTC
+	UpdatedAt   time.Time `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"` // Preenchida enquanto o produto está na lixeira
+
+	Conversion *PriceConversion `json:"conversion,omitempty"` // Presente quando o preço foi convertido na leitura (?currency=)
+}
+
+// PriceConversion descreve a conversão do preço de um produto para a moeda pedida
+// na leitura: o preço gravado e a cotação usada
+type PriceConversion struct {
+	OriginalPrice    int    `json:"original_price"`
+	OriginalCurrency string `json:"original_currency"`
+	Rate             string `json:"rate"`      // 1 original_currency = rate currency
+	RateDate         string `json:"rate_date"` // Data da cotação, como informada pela fonte
+}
+
+// ProductPatch descreve uma alteração parcial de produto (PATCH): apenas os campos
+// diferentes de nil são alterados. Como na escrita completa, category só é usada
+// quando category_id não é informado.
+type ProductPatch struct {
+	Name        *string
+	Price       *int
+	Currency    *string
+	Description *string
+	CategoryID  *int
+	Category    *string
+	ImageURL    *string
+
+	Version int // Versão esperada do produto (If-Match); 0 altera qualquer versão
+}
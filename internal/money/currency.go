@@ -0,0 +1,153 @@
+// Package money trata as moedas dos preços: os códigos ISO 4217 aceitos, as
+// cotações entre elas e a conversão de valores em unidades menores (centavos).
+//
+// Os valores nunca passam por float: as cotações são decimais exatos (big.Rat)
+// e a conversão usa o arredondamento bancário (metade para o par).
+package money
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency é a moeda dos preços que não informam uma, inclusive dos
+// produtos gravados antes de os preços terem moeda
+const DefaultCurrency = "BRL"
+
+// currencies são as moedas aceitas (ISO 4217) e o número de casas decimais de
+// cada uma: os preços são guardados em unidades menores (10995 BRL = R$ 109,95)
+var currencies = map[string]int{
+	"ARS": 2, "AUD": 2, "BRL": 2, "CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2, "COP": 2,
+	"EUR": 2, "GBP": 2, "JPY": 0, "KRW": 0, "MXN": 2, "PEN": 2, "PYG": 0, "USD": 2, "UYU": 2,
+}
+
+// Valid informa se o código é de uma moeda aceita
+func Valid(code string) bool {
+	_, ok := currencies[code]
+	return ok
+}
+
+// Decimals retorna o número de casas decimais da moeda
+func Decimals(code string) int {
+	return currencies[code]
+}
+
+// Normalize padroniza o código informado pelo cliente (maiúsculas, sem espaços);
+// vazio vira a moeda padrão
+func Normalize(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return DefaultCurrency
+	}
+	return code
+}
+
+// ParseAmount converte um valor decimal escrito em unidades da moeda ("109.95",
+// "1e2") para unidades menores (10995), sem passar por float. Valores com mais
+// casas decimais do que a moeda admite são recusados, em vez de arredondados.
+func ParseAmount(s string, currency string) (int, error) {
+	v, err := parseDecimal(s)
+	if err != nil {
+		return 0, err
+	}
+
+	decimals := Decimals(currency)
+	v.Mul(v, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	if !v.IsInt() {
+		return 0, fmt.Errorf("valor %q tem mais de %d casas decimais (%s)", s, decimals, currency)
+	}
+	return toInt(v, s)
+}
+
+// ParseMinor converte um valor já escrito em unidades menores ("10995", "1.0995e4"),
+// como os preços do corpo das requisições e dos filtros, com as regras de ParseAmount:
+// sem passar por float e recusando frações de unidade menor em vez de arredondá-las.
+func ParseMinor(s string) (int, error) {
+	v, err := parseDecimal(s)
+	if err != nil {
+		return 0, err
+	}
+	if !v.IsInt() {
+		return 0, fmt.Errorf("valor %q tem casas decimais: os preços são em unidades menores da moeda (centavos)", s)
+	}
+	return toInt(v, s)
+}
+
+// decimalPattern é o formato aceito dos valores: dígitos decimais, com sinal, parte
+// fracionária e expoente opcionais. Recusa o que o big.Rat aceitaria além disso:
+// frações ("1/2"), outras bases ("0x10", "0b101", "0o7"), separadores ("1_000") e espaços.
+var decimalPattern = regexp.MustCompile(`^[+-]?\d+(\.\d+)?([eE][+-]?\d+)?$`)
+
+// maxExponent limita o expoente dos valores: os aceitos cabem num int32, e um
+// expoente enorme ("1e999999999") faria o big.Rat calcular uma potência gigante
+const maxExponent = 100
+
+// parseDecimal lê o valor como um decimal exato, no formato de decimalPattern
+func parseDecimal(s string) (*big.Rat, error) {
+	if !decimalPattern.MatchString(s) {
+		return nil, fmt.Errorf("valor inválido: %q", s)
+	}
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		if exp, err := strconv.Atoi(s[i+1:]); err != nil || exp > maxExponent || exp < -maxExponent {
+			return nil, fmt.Errorf("valor inválido: %q (expoente fora do intervalo aceito)", s)
+		}
+	}
+	v, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("valor inválido: %q", s)
+	}
+	return v, nil
+}
+
+// toInt converte o valor inteiro v, lido de s, verificando se ele cabe no intervalo aceito
+func toInt(v *big.Rat, s string) (int, error) {
+	if !v.Num().IsInt64() || v.Num().Int64() > math.MaxInt32 || v.Num().Int64() < math.MinInt32 {
+		return 0, fmt.Errorf("valor fora do intervalo aceito: %q", s)
+	}
+	return int(v.Num().Int64()), nil
+}
+
+// Convert converte amount, em unidades menores de rate.From, para unidades
+// menores de rate.To, com arredondamento bancário
+func Convert(amount int, rate Rate) int {
+	v := new(big.Rat).Mul(new(big.Rat).SetInt64(int64(amount)), rate.Value)
+
+	// Ajusta a escala quando as moedas têm números de casas decimais diferentes
+	if shift := Decimals(rate.To) - Decimals(rate.From); shift != 0 {
+		scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(shift))), nil))
+		if shift > 0 {
+			v.Mul(v, scale)
+		} else {
+			v.Quo(v, scale)
+		}
+	}
+	return int(roundHalfEven(v).Int64())
+}
+
+// roundHalfEven arredonda para o inteiro mais próximo; na metade exata, para o par
+func roundHalfEven(v *big.Rat) *big.Int {
+	num, den := v.Num(), v.Denom() // den é sempre positivo
+	q, m := new(big.Int).DivMod(num, den, new(big.Int))
+
+	// q é o piso de v e 0 <= m < den: compara a parte fracionária (m/den) com 1/2
+	switch new(big.Int).Mul(m, big.NewInt(2)).Cmp(den) {
+	case 1:
+		q.Add(q, big.NewInt(1))
+	case 0:
+		if q.Bit(0) == 1 {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
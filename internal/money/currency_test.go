@@ -0,0 +1,107 @@
+package money
+
+import "testing"
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		want     int
+	}{
+		// Preços do catálogo da fakestoreapi, que chegam como decimais em dólares
+		{"109.95", "USD", 10995},
+		{"22.3", "USD", 2230},
+		{"55.99", "USD", 5599},
+		{"0.1", "USD", 10},
+		{"15.99", "USD", 1599},
+		{"695", "USD", 69500},
+		{"7.95", "USD", 795},
+
+		{"0", "BRL", 0},
+		{"0.00", "BRL", 0},
+		{"109.950", "USD", 10995}, // Zeros à direita não mudam o valor
+		{"+9.5", "BRL", 950},
+		{"1E2", "BRL", 10000},
+		{"1e+2", "BRL", 10000},
+		{"1e2", "BRL", 10000},
+		{"-3.25", "BRL", -325},
+		{"1500", "JPY", 1500},
+		{"21474836.47", "BRL", 2147483647},
+	}
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.value, tt.currency)
+		if err != nil {
+			t.Errorf("ParseAmount(%q, %s): erro inesperado: %v", tt.value, tt.currency, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAmount(%q, %s) = %d; esperado %d", tt.value, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestParseAmountRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		currency string
+	}{
+		{"três casas decimais", "109.955", "USD"},
+		{"fração de centavo", "0.001", "BRL"},
+		{"fração de uma moeda sem casas decimais", "1500.5", "JPY"},
+		{"expoente que deixa casas demais", "1e-3", "USD"},
+		{"vazio", "", "USD"},
+		{"texto", "abc", "USD"},
+		{"vírgula decimal", "109,95", "USD"},
+		{"fração", "1/2", "USD"},
+		{"acima do intervalo", "21474836.48", "BRL"},
+		{"espaços em volta", " 9.5 ", "BRL"},
+		{"hexadecimal", "0x10", "BRL"},
+		{"binário", "0b101", "BRL"},
+		{"octal", "0o7", "BRL"},
+		{"separador de milhar", "1_000", "BRL"},
+		{"ponto sem casas", "9.", "BRL"},
+		{"casas sem parte inteira", ".5", "BRL"},
+		{"expoente sem dígitos", "1e", "BRL"},
+		{"expoente enorme", "1e999999999", "BRL"},
+		{"infinito", "Inf", "BRL"},
+	}
+	for _, tt := range tests {
+		if got, err := ParseAmount(tt.value, tt.currency); err == nil {
+			t.Errorf("%s: ParseAmount(%q, %s) = %d; esperado erro", tt.name, tt.value, tt.currency, got)
+		}
+	}
+}
+
+func TestParseMinor(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"10995", 10995},
+		{"0", 0},
+		{"-50", -50},
+		{"1.0995e4", 10995},
+		{"1e3", 1000},
+		{"1990.00", 1990},
+		{"2147483647", 2147483647},
+	}
+	for _, tt := range tests {
+		got, err := ParseMinor(tt.value)
+		if err != nil {
+			t.Errorf("ParseMinor(%q): erro inesperado: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMinor(%q) = %d; esperado %d", tt.value, got, tt.want)
+		}
+	}
+
+	// Frações de centavo não são arredondadas: 19.90 provavelmente queria dizer 1990
+	for _, value := range []string{"19.90", "109.95", "0.5", "1e-1", "", "abc", "1/2", "2147483648",
+		" 1990 ", "0x7c6", "0b11111000110", "0o3706", "1_990", "1990\n", "1e-999999999"} {
+		if got, err := ParseMinor(value); err == nil {
+			t.Errorf("ParseMinor(%q) = %d; esperado erro", value, got)
+		}
+	}
+}
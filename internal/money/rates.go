@@ -0,0 +1,266 @@
+package money
+
+import (
+	"braip/internal/apperror"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rate é a cotação usada numa conversão: 1 unidade de From vale Value unidades de To
+type Rate struct {
+	From   string
+	To     string
+	Value  *big.Rat
+	Date   string // Data da cotação, como informada pela fonte
+	Source string // Fonte da cotação (arquivo ou URL)
+}
+
+// CrossRateDecimals é o número de casas decimais das cotações calculadas por meio
+// da moeda base que não têm representação decimal finita (ex.: BRL -> USD = 1 / 5.1385).
+// Elas são arredondadas antes do uso, para que a cotação informada seja a usada na conversão.
+const CrossRateDecimals = 10
+
+// String retorna a cotação exata usada por Convert, sem zeros à direita. Cotações sem
+// representação decimal finita (que Table não produz) saem como fração ("1/3").
+func (r Rate) String() string {
+	decimals, ok := decimalPlaces(r.Value)
+	if !ok {
+		return r.Value.RatString()
+	}
+	s := r.Value.FloatString(decimals)
+	if decimals > 0 {
+		s = strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// decimalPlaces retorna quantas casas decimais v tem, se a sua representação decimal
+// for finita (o denominador só tem os fatores 2 e 5)
+func decimalPlaces(v *big.Rat) (int, bool) {
+	den := new(big.Int).Set(v.Denom())
+	twos, fives := 0, 0
+	for den.Bit(0) == 0 {
+		den.Rsh(den, 1)
+		twos++
+	}
+	five, rem := big.NewInt(5), new(big.Int)
+	for {
+		q, m := new(big.Int).QuoRem(den, five, rem)
+		if m.Sign() != 0 {
+			break
+		}
+		den = q
+		fives++
+	}
+	return max(twos, fives), den.Cmp(big.NewInt(1)) == 0
+}
+
+// RateProvider fornece as cotações entre as moedas aceitas
+type RateProvider interface {
+	Rate(ctx context.Context, from, to string) (Rate, error)
+}
+
+// Table é uma tabela de cotações em relação a uma moeda base, no formato lido
+// dos arquivos e das URLs de cotações:
+//
+//	{"base": "USD", "date": "2024-05-10", "rates": {"BRL": "5.1234", "EUR": 0.9281}}
+//
+// As cotações podem vir como números ou textos; em ambos os casos são lidas sem
+// passar por float. A cotação entre duas moedas que não a base é calculada por
+// meio dela (BRL -> EUR = rates[EUR] / rates[BRL]).
+type Table struct {
+	Base  string                 `json:"base"`
+	Date  string                 `json:"date"`
+	Rates map[string]json.Number `json:"rates"`
+}
+
+// rate calcula a cotação de from para to a partir da tabela
+func (t *Table) rate(from, to string) (Rate, error) {
+	fromValue, err := t.value(from)
+	if err != nil {
+		return Rate{}, err
+	}
+	toValue, err := t.value(to)
+	if err != nil {
+		return Rate{}, err
+	}
+	value := new(big.Rat).Quo(toValue, fromValue)
+	if _, ok := decimalPlaces(value); !ok {
+		scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(CrossRateDecimals), nil)
+		scaled := roundHalfEven(new(big.Rat).Mul(value, new(big.Rat).SetInt(scale)))
+		value = new(big.Rat).SetFrac(scaled, scale)
+	}
+	return Rate{From: from, To: to, Value: value, Date: t.Date}, nil
+}
+
+// value retorna quanto 1 unidade da base vale na moeda informada
+func (t *Table) value(code string) (*big.Rat, error) {
+	if code == t.Base {
+		return big.NewRat(1, 1), nil
+	}
+	raw, ok := t.Rates[code]
+	if !ok {
+		return nil, apperror.BadRequest(fmt.Sprintf("sem cotação disponível para a moeda %s", code))
+	}
+	v, ok := new(big.Rat).SetString(raw.String())
+	if !ok || v.Sign() <= 0 {
+		return nil, fmt.Errorf("cotação inválida para a moeda %s: %q", code, raw)
+	}
+	return v, nil
+}
+
+// decodeTable lê e confere uma tabela de cotações
+func decodeTable(r io.Reader) (*Table, error) {
+	var t Table
+	if err := json.NewDecoder(r).Decode(&t); err != nil {
+		return nil, fmt.Errorf("tabela de cotações inválida: %w", err)
+	}
+	if !Valid(t.Base) {
+		return nil, fmt.Errorf("tabela de cotações com moeda base inválida: %q", t.Base)
+	}
+	return &t, nil
+}
+
+// NewRateProvider cria o provedor de cotações da fonte informada: uma URL
+// http(s) ou o caminho de um arquivo JSON, ambos no formato de Table
+func NewRateProvider(source string) (RateProvider, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return NewHTTPRateProvider(source, DefaultRateTTL), nil
+	}
+	return NewFileRateProvider(source)
+}
+
+// FileRateProvider lê as cotações de um arquivo JSON. O arquivo é lido de novo
+// sempre que muda, para que as cotações possam ser atualizadas sem reiniciar o servidor.
+type FileRateProvider struct {
+	path string
+
+	mu      sync.Mutex
+	table   *Table
+	modTime time.Time
+}
+
+// NewFileRateProvider cria o provedor e faz a primeira leitura do arquivo
+func NewFileRateProvider(path string) (*FileRateProvider, error) {
+	p := &FileRateProvider{path: path}
+	if _, err := p.load(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Rate retorna a cotação de from para to
+func (p *FileRateProvider) Rate(ctx context.Context, from, to string) (Rate, error) {
+	table, err := p.load()
+	if err != nil {
+		return Rate{}, err
+	}
+	rate, err := table.rate(from, to)
+	rate.Source = p.path
+	return rate, err
+}
+
+// load retorna a tabela, lendo o arquivo de novo se ele mudou desde a última leitura
+func (p *FileRateProvider) load() (*Table, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler as cotações: %w", err)
+	}
+	if p.table != nil && info.ModTime().Equal(p.modTime) {
+		return p.table, nil
+	}
+
+	f, err := os.Open(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler as cotações: %w", err)
+	}
+	defer f.Close()
+
+	table, err := decodeTable(f)
+	if err != nil {
+		return nil, err
+	}
+	p.table, p.modTime = table, info.ModTime()
+	return table, nil
+}
+
+// DefaultRateTTL é por quanto tempo as cotações buscadas por HTTP são reaproveitadas
+const DefaultRateTTL = time.Hour
+
+// HTTPRateProvider busca as cotações numa URL que responde com uma Table em JSON
+// (um serviço local ou um substituto dele em desenvolvimento). A tabela é
+// reaproveitada por ttl; se a busca falhar, a última tabela obtida continua em uso.
+type HTTPRateProvider struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	mu        sync.Mutex
+	table     *Table
+	fetchedAt time.Time
+}
+
+// NewHTTPRateProvider cria o provedor; a primeira busca acontece na primeira conversão
+func NewHTTPRateProvider(url string, ttl time.Duration) *HTTPRateProvider {
+	return &HTTPRateProvider{url: url, ttl: ttl, client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+// Rate retorna a cotação de from para to
+func (p *HTTPRateProvider) Rate(ctx context.Context, from, to string) (Rate, error) {
+	table, err := p.load(ctx)
+	if err != nil {
+		return Rate{}, err
+	}
+	rate, err := table.rate(from, to)
+	rate.Source = p.url
+	return rate, err
+}
+
+// load retorna a tabela em cache ou busca uma nova, se ela tiver expirado
+func (p *HTTPRateProvider) load(ctx context.Context) (*Table, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.table != nil && time.Since(p.fetchedAt) < p.ttl {
+		return p.table, nil
+	}
+
+	table, err := p.fetch(ctx)
+	if err != nil {
+		if p.table != nil {
+			return p.table, nil // Mantém a última tabela obtida
+		}
+		return nil, err
+	}
+	p.table, p.fetchedAt = table, time.Now()
+	return table, nil
+}
+
+// fetch busca a tabela de cotações na URL
+func (p *HTTPRateProvider) fetch(ctx context.Context) (*Table, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar as cotações: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("serviço de cotações retornou status %d", resp.StatusCode)
+	}
+	return decodeTable(resp.Body)
+}
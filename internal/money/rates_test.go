@@ -0,0 +1,65 @@
+package money
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestTableRate(t *testing.T) {
+	table := &Table{Base: "USD", Date: "2024-05-10", Rates: map[string]json.Number{
+		"BRL": "5.1385", "EUR": "0.9281", "GBP": "0.8", "CHF": "0.64", "JPY": "0.000001",
+	}}
+	tests := []struct {
+		from, to string
+		want     string
+	}{
+		{"USD", "BRL", "5.1385"},
+		{"USD", "USD", "1"},
+		{"GBP", "CHF", "0.8"},
+		// Cotações cruzadas sem representação decimal finita são arredondadas
+		{"BRL", "USD", "0.1946093218"},
+		{"BRL", "EUR", "0.1806169116"},
+		{"EUR", "BRL", "5.5365801099"},
+		// As de representação finita ficam exatas, mesmo com mais casas
+		{"GBP", "JPY", "0.00000125"},
+		{"CHF", "JPY", "0.0000015625"},
+		{"USD", "JPY", "0.000001"},
+		{"JPY", "GBP", "800000"},
+	}
+	for _, tt := range tests {
+		rate, err := table.rate(tt.from, tt.to)
+		if err != nil {
+			t.Errorf("rate(%s, %s): erro inesperado: %v", tt.from, tt.to, err)
+			continue
+		}
+		if got := rate.String(); got != tt.want {
+			t.Errorf("rate(%s, %s) = %s; esperado %s", tt.from, tt.to, got, tt.want)
+		}
+
+		// A cotação informada é exatamente a usada na conversão
+		reported, _ := new(big.Rat).SetString(rate.String())
+		if reported.Cmp(rate.Value) != 0 {
+			t.Errorf("rate(%s, %s): cotação informada %s diferente da usada %s", tt.from, tt.to, rate.String(), rate.Value.RatString())
+		}
+	}
+}
+
+func TestRateString(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"5", "5"},
+		{"10", "10"},
+		{"5.13850", "5.1385"},
+		{"0.00000000000125", "0.00000000000125"},
+		{"1/3", "1/3"}, // Sem representação decimal finita
+	}
+	for _, tt := range tests {
+		v, _ := new(big.Rat).SetString(tt.value)
+		if got := (Rate{Value: v}).String(); got != tt.want {
+			t.Errorf("Rate{%s}.String() = %s; esperado %s", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"braip/internal/audit"
+	"braip/internal/models"
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// AuditOptions filtra e pagina a consulta da auditoria de produtos.
+// Campos vazios (ou zero) não filtram.
+type AuditOptions struct {
+	ProductID int
+	Actor     string
+	Source    string
+	Action    string
+	Since     time.Time // Registros a partir deste instante (inclusive)
+	Until     time.Time // Registros anteriores a este instante
+
+	Limit  int
+	Offset int
+}
+
+// AuditPage é uma página da auditoria, do registro mais recente para o mais antigo
+type AuditPage struct {
+	Entries []models.AuditEntry
+	Total   int // Total de registros que satisfazem o filtro, sem paginação
+}
+
+// AuditStore define a consulta da auditoria de produtos. Os registros são
+// gravados pelo próprio ProductStore, junto com cada alteração, e nunca são
+// alterados ou removidos.
+type AuditStore interface {
+	GetAuditEntries(ctx context.Context, opts AuditOptions) (*AuditPage, error)
+}
+
+// normalize aplica os limites de paginação
+func (o AuditOptions) normalize() AuditOptions {
+	if o.Limit <= 0 {
+		o.Limit = DefaultLimit
+	}
+	if o.Limit > MaxLimit {
+		o.Limit = MaxLimit
+	}
+	if o.Offset < 0 {
+		o.Offset = 0
+	}
+	return o
+}
+
+// matches informa se o registro satisfaz o filtro (repositório em memória)
+func (o AuditOptions) matches(e *models.AuditEntry) bool {
+	return (o.ProductID == 0 || e.ProductID == o.ProductID) &&
+		(o.Actor == "" || e.Actor == o.Actor) &&
+		(o.Source == "" || e.Source == o.Source) &&
+		(o.Action == "" || e.Action == o.Action) &&
+		(o.Since.IsZero() || !e.CreatedAt.Before(o.Since)) &&
+		(o.Until.IsZero() || e.CreatedAt.Before(o.Until))
+}
+
+// newAuditEntry monta o registro de auditoria de uma alteração feita pelo autor do contexto.
+// before e after são nil quando o produto não existia antes ou deixou de existir.
+func newAuditEntry(ctx context.Context, action string, productID int, before, after *models.Product) (models.AuditEntry, error) {
+	actor := audit.ActorFrom(ctx)
+	entry := models.AuditEntry{
+		ProductID: productID,
+		Action:    action,
+		Actor:     actor.Name,
+		Source:    actor.Source,
+		RequestID: actor.RequestID,
+		CreatedAt: now(),
+	}
+
+	var err error
+	if entry.Before, err = snapshot(before); err != nil {
+		return entry, err
+	}
+	entry.After, err = snapshot(after)
+	return entry, err
+}
+
+// snapshot serializa o produto guardado no registro de auditoria (null se nil)
+func snapshot(p *models.Product) (json.RawMessage, error) {
+	if p == nil {
+		return json.RawMessage("null"), nil
+	}
+	return json.Marshal(p)
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"braip/internal/audit"
+	"braip/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// auditActions retorna as ações registradas para o produto, da mais recente para a mais antiga
+func auditActions(t *testing.T, store ProductStore, opts AuditOptions) []string {
+	t.Helper()
+	page, err := store.GetAuditEntries(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("GetAuditEntries(%+v): %v", opts, err)
+	}
+	actions := make([]string, len(page.Entries))
+	for i, e := range page.Entries {
+		actions[i] = e.Action
+	}
+	return actions
+}
+
+// auditPrice lê o preço de um produto guardado na auditoria (0 se nulo)
+func auditPrice(t *testing.T, data json.RawMessage) int {
+	t.Helper()
+	var p *models.Product
+	if err := json.Unmarshal(data, &p); err != nil {
+		t.Fatalf("produto da auditoria não é JSON (%v): %s", err, data)
+	}
+	if p == nil {
+		return 0
+	}
+	return p.Price
+}
+
+// Cada alteração gera um registro com o autor, a origem e o produto antes e depois
+func TestAuditTrail(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store ProductStore) {
+		ctx := audit.WithActor(context.Background(), audit.Actor{Name: "maria", Source: audit.SourceAPI, RequestID: "req-1"})
+		word := uniqueWord()
+		start := tick()
+
+		id, err := store.CreateProduct(ctx, models.Product{Name: "Boné " + word, Price: 1990, Currency: "BRL", Description: "Boné", Category: "Testes de integração"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		productID := int(id)
+		tick() // Separa a criação da atualização, para o filtro Until
+		price := 2990
+		if err := store.PatchProduct(ctx, productID, models.ProductPatch{Price: &price}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Escritas recusadas e transações desfeitas não deixam registros
+		stale := 1
+		if err := store.PatchProduct(ctx, productID, models.ProductPatch{Price: &stale, Version: 1}); !errors.Is(err, ErrVersionMismatch) {
+			t.Fatalf("PatchProduct com versão antiga: %v", err)
+		}
+		rollback := errors.New("desfazer")
+		err = store.WithTransaction(ctx, func(tx ProductStore) error {
+			if err := tx.PatchProduct(ctx, productID, models.ProductPatch{Price: &stale}); err != nil {
+				return err
+			}
+			return rollback
+		})
+		if !errors.Is(err, rollback) {
+			t.Fatalf("WithTransaction: %v", err)
+		}
+
+		// O importador e os comandos registram a própria origem
+		importer := audit.WithActor(context.Background(), audit.Actor{Name: "joao", Source: audit.SourceImporter})
+		if err := store.DeleteProduct(ctx, productID, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.RestoreProduct(importer, productID); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.DeleteProduct(importer, productID, 0); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.PurgeProducts(importer, tick()); err != nil {
+			t.Fatal(err)
+		}
+
+		page, err := store.GetAuditEntries(ctx, AuditOptions{ProductID: productID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{audit.ActionPurge, audit.ActionDelete, audit.ActionRestore, audit.ActionDelete, audit.ActionUpdate, audit.ActionCreate}
+		if got := auditActions(t, store, AuditOptions{ProductID: productID}); strings.Join(got, ",") != strings.Join(want, ",") || page.Total != len(want) {
+			t.Fatalf("ações: %v (total %d); esperado %v", got, page.Total, want)
+		}
+
+		create, update, purge := page.Entries[5], page.Entries[4], page.Entries[0]
+		if create.Actor != "maria" || create.Source != audit.SourceAPI || create.RequestID != "req-1" || create.CreatedAt.Before(start) {
+			t.Errorf("registro da criação: %+v", create)
+		}
+		if string(create.Before) != "null" || auditPrice(t, create.After) != 1990 {
+			t.Errorf("criação: antes %s, depois %s", create.Before, create.After)
+		}
+		if auditPrice(t, update.Before) != 1990 || auditPrice(t, update.After) != 2990 {
+			t.Errorf("atualização: antes %s, depois %s", update.Before, update.After)
+		}
+		if purge.Actor != "joao" || purge.Source != audit.SourceImporter || string(purge.After) != "null" || auditPrice(t, purge.Before) != 2990 {
+			t.Errorf("registro da remoção definitiva: %+v", purge)
+		}
+		for i := 1; i < len(page.Entries); i++ {
+			if page.Entries[i].CreatedAt.After(page.Entries[i-1].CreatedAt) {
+				t.Errorf("registros fora de ordem: %v depois de %v", page.Entries[i].CreatedAt, page.Entries[i-1].CreatedAt)
+			}
+		}
+
+		// Filtros e paginação
+		tests := []struct {
+			opts AuditOptions
+			want string
+		}{
+			{AuditOptions{ProductID: productID, Actor: "joao"}, "purge,delete,restore"},
+			{AuditOptions{ProductID: productID, Source: audit.SourceAPI}, "delete,update,create"},
+			{AuditOptions{ProductID: productID, Action: audit.ActionDelete}, "delete,delete"},
+			{AuditOptions{ProductID: productID, Since: purge.CreatedAt}, "purge"},
+			{AuditOptions{ProductID: productID, Until: update.CreatedAt}, "create"},
+			{AuditOptions{ProductID: productID, Limit: 2, Offset: 1}, "delete,restore"},
+			{AuditOptions{ProductID: productID, Actor: "ninguém"}, ""},
+		}
+		for _, tt := range tests {
+			if got := strings.Join(auditActions(t, store, tt.opts), ","); got != tt.want {
+				t.Errorf("GetAuditEntries(%+v) = %s; esperado %s", tt.opts, got, tt.want)
+			}
+		}
+	})
+}
+
+// A tabela product_audit é somente de inserção: os gatilhos recusam UPDATE e DELETE
+func TestAuditAppendOnly(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store ProductStore) {
+		repo, ok := store.(*SQLProductRepository)
+		if !ok {
+			t.Skip("o repositório em memória não expõe os registros para alteração")
+		}
+		ctx := context.Background()
+		id := createTestProduct(t, store, models.Product{Name: "Boné " + uniqueWord(), Price: 1990, Description: "Boné"})
+
+		statements := []string{
+			"UPDATE product_audit SET actor = 'outro' WHERE product_id = $1",
+			"DELETE FROM product_audit WHERE product_id = $1",
+		}
+		for _, query := range statements {
+			_, err := repo.conn.ExecContext(ctx, query, id)
+			if err == nil || !strings.Contains(err.Error(), "somente de inserção") {
+				t.Errorf("%s: %v; esperado erro do gatilho", query, err)
+			}
+		}
+
+		if got := auditActions(t, store, AuditOptions{ProductID: id, Actor: "system"}); len(got) != 1 {
+			t.Errorf("registros depois das tentativas: %v; esperado [create]", got)
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"braip/internal/apperror"
+	"braip/internal/models"
+	"context"
+)
+
+// Erros das operações de categorias
+var (
+	ErrCategoryNotFound      = apperror.NotFound("categoria não encontrada")
+	ErrCategoryRequired      = apperror.BadRequest("categoria do produto não informada")
+	ErrCategoryExists        = apperror.Conflict("já existe uma categoria com esse nome")
+	ErrCategoryInUse         = apperror.Conflict("categoria possui produtos ou subcategorias")
+	ErrInvalidParentCategory = apperror.BadRequest("categoria pai inválida")
+)
+
+// CategoryStore define as operações de persistência de categorias.
+// O slug é sempre gerado pelo repositório a partir do nome.
+type CategoryStore interface {
+	GetCategories(ctx context.Context) ([]models.Category, error)
+	GetCategoryByID(ctx context.Context, id int) (*models.Category, error)
+	CreateCategory(ctx context.Context, category models.Category) (int64, error)
+	UpdateCategory(ctx context.Context, id int, category models.Category) error
+	DeleteCategory(ctx context.Context, id int) error
+}
+
+// checkParent verifica se parentID pode ser a categoria pai da categoria id
+// (id = 0 numa categoria nova): a pai precisa existir e não pode ser a própria
+// categoria nem uma de suas subcategorias. parentOf retorna a pai de uma
+// categoria e se ela existe.
+func checkParent(id int, parentID *int, parentOf func(id int) (*int, bool, error)) error {
+	if parentID == nil {
+		return nil
+	}
+
+	for current := parentID; current != nil; {
+		if id != 0 && *current == id {
+			return ErrInvalidParentCategory // Ciclo na hierarquia
+		}
+		next, ok, err := parentOf(*current)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidParentCategory
+		}
+		current = next
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"braip/internal/models"
+	"context"
+	"errors"
+	"testing"
+)
+
+// createTestCategory cria a categoria, falhando o teste em caso de erro
+func createTestCategory(t *testing.T, store ProductStore, name string, parentID *int) int {
+	t.Helper()
+	id, err := store.CreateCategory(context.Background(), models.Category{Name: name, ParentID: parentID})
+	if err != nil {
+		t.Fatalf("CreateCategory(%q): %v", name, err)
+	}
+	return int(id)
+}
+
+func TestCategories(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store ProductStore) {
+		ctx := context.Background()
+		word := uniqueWord()
+
+		// O slug vem do nome: sem acentos, em minúsculas e com hífens
+		roupas := createTestCategory(t, store, "  Roupas   Femininas "+word, nil)
+		category, err := store.GetCategoryByID(ctx, roupas)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if category.Name != "Roupas Femininas "+word || category.Slug != "roupas-femininas-"+word || category.ParentID != nil {
+			t.Errorf("categoria criada: %+v", category)
+		}
+
+		// Nomes com o mesmo slug são a mesma categoria
+		if _, err := store.CreateCategory(ctx, models.Category{Name: "roupas FEMININAS " + word}); !errors.Is(err, ErrCategoryExists) {
+			t.Errorf("categoria com slug repetido: %v; esperado ErrCategoryExists", err)
+		}
+		if _, err := store.CreateCategory(ctx, models.Category{Name: " !! "}); !errors.Is(err, ErrCategoryRequired) {
+			t.Errorf("categoria sem slug: %v; esperado ErrCategoryRequired", err)
+		}
+
+		// Hierarquia: a pai precisa existir e não pode haver ciclos
+		vestidos := createTestCategory(t, store, "Vestidos "+word, &roupas)
+		longos := createTestCategory(t, store, "Vestidos longos "+word, &vestidos)
+		missing := 999999999
+		if _, err := store.CreateCategory(ctx, models.Category{Name: "Órfã " + word, ParentID: &missing}); !errors.Is(err, ErrInvalidParentCategory) {
+			t.Errorf("pai inexistente: %v; esperado ErrInvalidParentCategory", err)
+		}
+		for _, parent := range []int{roupas, longos} {
+			parent := parent
+			err := store.UpdateCategory(ctx, roupas, models.Category{Name: "Roupas Femininas " + word, ParentID: &parent})
+			if !errors.Is(err, ErrInvalidParentCategory) {
+				t.Errorf("roupas como subcategoria de %d: %v; esperado ErrInvalidParentCategory", parent, err)
+			}
+		}
+
+		// Renomear troca o slug; o produto criado pelo nome da categoria a encontra pelo slug
+		if err := store.UpdateCategory(ctx, vestidos, models.Category{Name: "Vestidos e Saias " + word, ParentID: &roupas}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.UpdateCategory(ctx, vestidos, models.Category{Name: "Vestidos longos " + word, ParentID: &roupas}); !errors.Is(err, ErrCategoryExists) {
+			t.Errorf("renomear para o nome de outra categoria: %v; esperado ErrCategoryExists", err)
+		}
+		product := createTestProduct(t, store, models.Product{Name: "Vestido " + word, Price: 9990, Description: "Vestido", Category: "VESTIDOS E SAIAS " + word})
+		p, err := store.GetProductByID(ctx, product)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.CategoryID != vestidos || p.Category != "Vestidos e Saias "+word {
+			t.Errorf("categoria do produto: %d (%s); esperado %d", p.CategoryID, p.Category, vestidos)
+		}
+		if _, err := store.CreateProduct(ctx, models.Product{Name: "Saia " + word, Price: 1, Currency: "BRL", Description: "x", CategoryID: missing}); !errors.Is(err, ErrCategoryNotFound) {
+			t.Errorf("produto com category_id inexistente: %v; esperado ErrCategoryNotFound", err)
+		}
+
+		// Categorias com produtos ou subcategorias não podem ser removidas
+		for _, id := range []int{roupas, vestidos} {
+			if err := store.DeleteCategory(ctx, id); !errors.Is(err, ErrCategoryInUse) {
+				t.Errorf("remover a categoria %d em uso: %v; esperado ErrCategoryInUse", id, err)
+			}
+		}
+		if err := store.DeleteCategory(ctx, longos); err != nil {
+			t.Fatal(err)
+		}
+		if category, err := store.GetCategoryByID(ctx, longos); err != nil || category != nil {
+			t.Errorf("categoria removida: %+v, %v", category, err)
+		}
+		if err := store.DeleteCategory(ctx, longos); !errors.Is(err, ErrCategoryNotFound) {
+			t.Errorf("remover de novo: %v; esperado ErrCategoryNotFound", err)
+		}
+		if err := store.UpdateCategory(ctx, longos, models.Category{Name: "Outra " + word}); !errors.Is(err, ErrCategoryNotFound) {
+			t.Errorf("atualizar removida: %v; esperado ErrCategoryNotFound", err)
+		}
+
+		categories, err := store.GetCategories(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := map[int]bool{}
+		for _, c := range categories {
+			found[c.ID] = true
+		}
+		if !found[roupas] || !found[vestidos] || found[longos] {
+			t.Errorf("GetCategories: %+v", categories)
+		}
+	})
+}
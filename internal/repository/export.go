@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"braip/internal/filter"
+	"braip/internal/models"
+	"context"
+	"log"
+	"sort"
+)
+
+// ExportOptions seleciona os produtos exportados: com Query, os mesmos da busca
+// textual, na ordem de relevância; sem ela, os mesmos da listagem, na ordem de Sort.
+// A exportação não é paginada e inclui apenas os produtos fora da lixeira.
+type ExportOptions struct {
+	Query  string      // Busca textual (vazio = todos os produtos)
+	Filter filter.Expr // Filtro dos produtos (nil = todos)
+	Sort   []SortField // Ordenação sem Query (padrão: id)
+}
+
+// ExportProducts lê os produtos direto do cursor da consulta, chamando fn para
+// cada um, sem carregar o resultado inteiro em memória. Um erro de fn interrompe
+// a leitura e é retornado.
+func (r *SQLProductRepository) ExportProducts(ctx context.Context, opts ExportOptions, fn func(p *models.Product) error) error {
+	from, where, order := productsFrom, []string{"products.deleted_at IS NULL"}, orderByClause(ListOptions{Sort: opts.Sort}.normalize().Sort)
+	var args []interface{}
+	if opts.Query != "" {
+		terms := searchTerms(opts.Query)
+		if len(terms) == 0 {
+			return nil
+		}
+		var score string
+		var err error
+		if from, where, score, args, err = r.searchSource(terms, opts.Filter); err != nil {
+			return err
+		}
+		order = score + " DESC, products.id"
+	} else if opts.Filter != nil {
+		condition, filterArgs, err := r.dialect.compileFilter(opts.Filter)
+		if err != nil {
+			return err
+		}
+		where = append(where, condition)
+		args = append(args, filterArgs...)
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind("SELECT "+productColumns+" FROM "+from+whereClause(where)+" ORDER BY "+order), args...)
+	if err != nil {
+		log.Printf("Erro ao exportar produtos: %v", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			log.Printf("Erro ao processar produto exportado: %v", err)
+			return err
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Erro ao percorrer produtos exportados: %v", err)
+		return err
+	}
+	return nil
+}
+
+// ExportProducts percorre os produtos com a mesma seleção e ordem do repositório SQL
+func (r *MemoryProductRepository) ExportProducts(ctx context.Context, opts ExportOptions, fn func(p *models.Product) error) error {
+	var products []models.Product
+	if opts.Query != "" {
+		for _, res := range r.search(searchTerms(opts.Query), opts.Filter) {
+			products = append(products, res.Product)
+		}
+	} else {
+		products = r.filter(func(p models.Product) bool { return p.DeletedAt == nil && matchFilter(opts.Filter, &p) })
+		fields := ListOptions{Sort: opts.Sort}.normalize().Sort
+		sort.SliceStable(products, func(i, j int) bool {
+			return compareProducts(&products[i], &products[j], fields) < 0
+		})
+	}
+
+	for i := range products {
+		if err := fn(&products[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"braip/internal/filter"
+	"braip/internal/models"
+	"braip/internal/textutil"
+	"fmt"
+	"strings"
+)
+
+// Operadores SQL equivalentes aos operadores de comparação do filtro
+var sqlOps = map[filter.Op]string{
+	filter.Eq:  "=",
+	filter.Ne:  "<>",
+	filter.Gt:  ">",
+	filter.Gte: ">=",
+	filter.Lt:  "<",
+	filter.Lte: "<=",
+}
+
+// compileFilter traduz o filtro em uma condição SQL parametrizada.
+// Os valores nunca são concatenados na consulta: vão sempre como argumentos.
+func (d dialect) compileFilter(expr filter.Expr) (string, []interface{}, error) {
+	switch e := expr.(type) {
+	case filter.And:
+		return d.compileGroup(e, " AND ")
+	case filter.Or:
+		return d.compileGroup(e, " OR ")
+	case filter.Condition:
+		return d.compileCondition(e)
+	default:
+		return "", nil, fmt.Errorf("expressão de filtro não suportada: %T", expr)
+	}
+}
+
+func (d dialect) compileGroup(exprs []filter.Expr, sep string) (string, []interface{}, error) {
+	parts := make([]string, 0, len(exprs))
+	var args []interface{}
+	for _, e := range exprs {
+		sql, exprArgs, err := d.compileFilter(e)
+		if err != nil {
+			return "", nil, err
+		}
+		parts = append(parts, sql)
+		args = append(args, exprArgs...)
+	}
+	return "(" + strings.Join(parts, sep) + ")", args, nil
+}
+
+func (d dialect) compileCondition(c filter.Condition) (string, []interface{}, error) {
+	// has_image não é uma coluna: é derivado de image_url
+	if c.Field == "has_image" {
+		hasImage := c.Value.(bool)
+		if c.Op == filter.Ne {
+			hasImage = !hasImage
+		}
+		if hasImage {
+			return "(image_url IS NOT NULL AND image_url <> '')", nil, nil
+		}
+		return "(image_url IS NULL OR image_url = '')", nil, nil
+	}
+
+	column := productFields[c.Field].column
+
+	// A categoria é comparada pelo slug: "Electronics" e "electronics" são a mesma
+	if c.Field == "category" && c.Op != filter.Contains {
+		c = slugCondition(c)
+		column = "COALESCE(categories.slug, '')"
+	}
+
+	switch c.Op {
+	case filter.Contains:
+		return column + " " + d.like + " ? ESCAPE '\\'", []interface{}{"%" + escapeLike(c.Value.(string)) + "%"}, nil
+
+	case filter.In:
+		var args []interface{}
+		switch values := c.Value.(type) {
+		case []int:
+			for _, v := range values {
+				args = append(args, v)
+			}
+		case []string:
+			for _, v := range values {
+				args = append(args, v)
+			}
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ")
+		return column + " IN (" + placeholders + ")", args, nil
+
+	default:
+		op, ok := sqlOps[c.Op]
+		if !ok {
+			return "", nil, fmt.Errorf("operador de filtro não suportado: %q", c.Op)
+		}
+		return column + " " + op + " ?", []interface{}{c.Value}, nil
+	}
+}
+
+// slugCondition converte os valores de uma condição de categoria para slugs
+func slugCondition(c filter.Condition) filter.Condition {
+	switch v := c.Value.(type) {
+	case string:
+		c.Value = textutil.Slug(v)
+	case []string:
+		slugs := make([]string, len(v))
+		for i, s := range v {
+			slugs[i] = textutil.Slug(s)
+		}
+		c.Value = slugs
+	}
+	return c
+}
+
+// escapeLike escapa os curingas do LIKE para que o termo seja buscado literalmente
+func escapeLike(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(s)
+}
+
+// matchFilter avalia o filtro sobre um produto em memória
+func matchFilter(expr filter.Expr, p *models.Product) bool {
+	switch e := expr.(type) {
+	case nil:
+		return true
+	case filter.And:
+		for _, sub := range e {
+			if !matchFilter(sub, p) {
+				return false
+			}
+		}
+		return true
+	case filter.Or:
+		for _, sub := range e {
+			if matchFilter(sub, p) {
+				return true
+			}
+		}
+		return false
+	case filter.Condition:
+		return matchCondition(e, p)
+	default:
+		return false
+	}
+}
+
+func matchCondition(c filter.Condition, p *models.Product) bool {
+	var value interface{}
+	if c.Field == "has_image" {
+		value = p.ImageURL != ""
+	} else if c.Field == "category" && c.Op != filter.Contains {
+		c = slugCondition(c)
+		value = textutil.Slug(p.Category)
+	} else {
+		value = productFields[c.Field].value(p)
+	}
+
+	switch c.Op {
+	case filter.Contains:
+		return containsFold(value.(string), c.Value.(string))
+	case filter.In:
+		switch values := c.Value.(type) {
+		case []int:
+			for _, v := range values {
+				if value == v {
+					return true
+				}
+			}
+		case []string:
+			for _, v := range values {
+				if value == v {
+					return true
+				}
+			}
+		}
+		return false
+	case filter.Eq:
+		return value == c.Value
+	case filter.Ne:
+		return value != c.Value
+	}
+
+	cmp := compareValues(value, c.Value)
+	switch c.Op {
+	case filter.Gt:
+		return cmp > 0
+	case filter.Gte:
+		return cmp >= 0
+	case filter.Lt:
+		return cmp < 0
+	case filter.Lte:
+		return cmp <= 0
+	}
+	return false
+}
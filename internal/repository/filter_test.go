@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"braip/internal/filter"
+	"braip/internal/models"
+	"context"
+	"strconv"
+	"testing"
+)
+
+// Os preços de moedas diferentes não são comparáveis: o filtro de moeda restringe
+// os filtros e a ordenação por preço a uma moeda
+func TestFilterCurrency(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store ProductStore) {
+		word := uniqueWord()
+		usd := createTestProduct(t, store, models.Product{Name: word + " mochila", Price: 10995, Currency: "USD", Description: "Mochila"})
+		createTestProduct(t, store, models.Product{Name: word + " camisa", Price: 5000, Currency: "BRL", Description: "Camisa"})
+		cheapUSD := createTestProduct(t, store, models.Product{Name: word + " meia", Price: 999, Currency: "USD", Description: "Meia"})
+
+		expr, err := filter.Parse("currency = usd and price >= 1000 and name contains " + word)
+		if err != nil {
+			t.Fatal(err)
+		}
+		page, err := store.GetProducts(context.Background(), ListOptions{Filter: expr})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Products) != 1 || page.Products[0].ID != usd {
+			t.Errorf("produtos em USD a partir de 1000: %v; esperado [%d]", productIDs(page.Products), usd)
+		}
+
+		expr, err = filter.Parse("currency in (usd) and name contains " + word)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort, _ := ParseSort("price")
+		page, err = store.GetProducts(context.Background(), ListOptions{Filter: expr, Sort: sort})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := productIDs(page.Products); len(ids) != 2 || ids[0] != cheapUSD || ids[1] != usd {
+			t.Errorf("produtos em USD por preço: %v; esperado [%d %d]", ids, cheapUSD, usd)
+		}
+	})
+}
+
+// productIDs retorna os IDs dos produtos, na ordem
+func productIDs(products []models.Product) []int {
+	ids := make([]int, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+// As expressões de filtro selecionam os mesmos produtos em todos os backends
+func TestFilterExpressions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store ProductStore) {
+		word := uniqueWord()
+		camisa := createTestProduct(t, store, models.Product{Name: word + " Camisa Polo", Price: 1990, Description: "Algodão", Category: "Roupas", ImageURL: "https://example.com/1.png"})
+		calca := createTestProduct(t, store, models.Product{Name: word + " Calça 50%_off", Price: 5990, Description: "Jeans", Category: "roupas"})
+		fone := createTestProduct(t, store, models.Product{Name: word + " Fone", Price: 9990, Description: "Sem fio", Category: "Eletrônicos", ImageURL: "https://example.com/3.png"})
+
+		tests := []struct {
+			expr string
+			want []int
+		}{
+			{"price >= 1990 and price < 9990", []int{camisa, calca}},
+			{"price = 1990 or price = 9990", []int{camisa, fone}},
+			{"price > 5000 and (category = eletronicos or name contains polo)", []int{fone}},
+			{"(price > 5000 and category = eletronicos) or name contains polo", []int{camisa, fone}},
+			{"id in (" + strconv.Itoa(camisa) + ", " + strconv.Itoa(fone) + ")", []int{camisa, fone}},
+			{"id != " + strconv.Itoa(calca), []int{camisa, fone}},
+			// Textos sem diferenciar maiúsculas; categoria comparada pelo slug
+			{"name contains CAMISA", []int{camisa}},
+			{"category = ROUPAS", []int{camisa, calca}},
+			{"category in (roupas, outros)", []int{camisa, calca}},
+			{"category != roupas", []int{fone}},
+			// % e _ são caracteres comuns em contains, não curingas
+			{"name contains \"50%_\"", []int{calca}},
+			{"name contains \"a%o\"", nil},
+			{"has_image = true", []int{camisa, fone}},
+			{"has_image = false", []int{calca}},
+			{"has_image != true", []int{calca}},
+			// eq compara o texto exato; contains é que ignora maiúsculas
+			{"description = Jeans", []int{calca}},
+			{"description = jeans", nil},
+			{"created_at >= 2000-01-01 and updated_at < 2100-01-01", []int{camisa, calca, fone}},
+		}
+		for _, tt := range tests {
+			expr, err := filter.Parse(tt.expr + " and name contains " + word)
+			if err != nil {
+				t.Fatalf("Parse(%s): %v", tt.expr, err)
+			}
+			page, err := store.GetProducts(context.Background(), ListOptions{Filter: expr})
+			if err != nil {
+				t.Fatalf("%s: %v", tt.expr, err)
+			}
+			if ids := productIDs(page.Products); !equalIDs(ids, tt.want) {
+				t.Errorf("%s: %v; esperado %v", tt.expr, ids, tt.want)
+			}
+		}
+	})
+}
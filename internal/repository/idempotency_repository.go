@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"braip/internal/models"
+	"context"
+)
+
+// IdempotencyStore guarda as respostas das requisições feitas com Idempotency-Key.
+// Os registros expirados (ExpiresAt) são tratados como inexistentes e apagados
+// pelo próprio backend.
+type IdempotencyStore interface {
+	// ReserveIdempotencyKey grava o registro, ainda sem resposta, se a chave estiver livre
+	// (reserved true). Se a chave já estiver em uso, retorna o registro existente.
+	ReserveIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) (existing *models.IdempotencyRecord, reserved bool, err error)
+
+	// CompleteIdempotencyKey guarda a resposta (Status, Header e Body) na chave reservada,
+	// com o novo prazo de expiração (ExpiresAt)
+	CompleteIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) error
+
+	// ReleaseIdempotencyKey apaga a chave, para que a requisição possa ser repetida
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+}
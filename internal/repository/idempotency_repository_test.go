@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"braip/internal/models"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyKeyLifecycle(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store ProductStore) {
+		ctx := context.Background()
+		key := uniqueWord()
+		created := now()
+		reservation := models.IdempotencyRecord{Key: key, RequestHash: "a", CreatedAt: created, ExpiresAt: created.Add(time.Minute)}
+
+		if existing, reserved, err := store.ReserveIdempotencyKey(ctx, reservation); err != nil || !reserved || existing != nil {
+			t.Fatalf("primeira reserva: (%v, %t, %v); esperado a chave reservada", existing, reserved, err)
+		}
+
+		// Em andamento: a segunda reserva recebe o registro sem resposta
+		existing, reserved, err := store.ReserveIdempotencyKey(ctx, reservation)
+		if err != nil || reserved || existing == nil || existing.Completed() {
+			t.Fatalf("reserva em andamento: (%+v, %t, %v); esperado o registro sem resposta", existing, reserved, err)
+		}
+
+		// A resposta só é guardada pela requisição que reservou a chave (mesmo hash)
+		expires := created.Add(time.Hour)
+		response := models.IdempotencyRecord{Key: key, RequestHash: "a", Status: 201,
+			Header: map[string][]string{"Location": {"/products/1"}}, Body: []byte(`{"id": 1}`), ExpiresAt: expires}
+		other := response
+		other.RequestHash, other.Status = "b", 200
+		if err := store.CompleteIdempotencyKey(ctx, other); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.CompleteIdempotencyKey(ctx, response); err != nil {
+			t.Fatal(err)
+		}
+
+		existing, reserved, err = store.ReserveIdempotencyKey(ctx, reservation)
+		if err != nil || reserved || existing == nil {
+			t.Fatalf("reserva concluída: (%+v, %t, %v); esperado o registro guardado", existing, reserved, err)
+		}
+		if existing.Status != 201 || string(existing.Body) != `{"id": 1}` || len(existing.Header["Location"]) != 1 || existing.Header["Location"][0] != "/products/1" {
+			t.Errorf("registro = %+v; esperado a resposta guardada", existing)
+		}
+		// Concluída, a chave vale pelo TTL, e não mais pela reserva
+		if !existing.ExpiresAt.Equal(expires) {
+			t.Errorf("ExpiresAt = %v; esperado %v", existing.ExpiresAt, expires)
+		}
+
+		if err := store.ReleaseIdempotencyKey(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+		if existing, reserved, err := store.ReserveIdempotencyKey(ctx, reservation); err != nil || !reserved {
+			t.Fatalf("reserva depois de liberar: (%v, %t, %v); esperado a chave reservada", existing, reserved, err)
+		}
+		store.ReleaseIdempotencyKey(ctx, key)
+	})
+}
+
+func TestIdempotencyKeyExpires(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store ProductStore) {
+		ctx := context.Background()
+		key := uniqueWord()
+		created := now()
+		expired := models.IdempotencyRecord{Key: key, RequestHash: "a", CreatedAt: created.Add(-time.Hour), ExpiresAt: created.Add(-time.Second)}
+		if _, reserved, err := store.ReserveIdempotencyKey(ctx, expired); err != nil || !reserved {
+			t.Fatalf("reserva: (%t, %v); esperado a chave reservada", reserved, err)
+		}
+
+		// Uma reserva expirada (ex.: o servidor caiu antes de concluí-la) não bloqueia a chave
+		reservation := models.IdempotencyRecord{Key: key, RequestHash: "b", CreatedAt: created, ExpiresAt: created.Add(time.Minute)}
+		if existing, reserved, err := store.ReserveIdempotencyKey(ctx, reservation); err != nil || !reserved {
+			t.Fatalf("reserva de chave expirada: (%+v, %t, %v); esperado a chave reservada de novo", existing, reserved, err)
+		}
+		store.ReleaseIdempotencyKey(ctx, key)
+	})
+}
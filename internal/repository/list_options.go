@@ -0,0 +1,321 @@
+package repository
+
+import (
+	"braip/internal/apperror"
+	"braip/internal/filter"
+	"braip/internal/models"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Limites de paginação da listagem de produtos
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// ErrInvalidCursor indica um cursor de paginação malformado ou gerado para outra ordenação
+var ErrInvalidCursor = apperror.BadRequest("cursor de paginação inválido")
+
+// SortField é um campo de ordenação; Desc indica ordem decrescente
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ListOptions controla filtro, paginação, ordenação e seleção de campos da listagem de produtos.
+// Quando Cursor é informado, Offset é ignorado e a página começa logo após o cursor.
+type ListOptions struct {
+	Limit  int
+	Offset int
+	Cursor string
+	Sort   []SortField
+	Fields []string    // Campos a retornar (vazio = todos)
+	Filter filter.Expr // Filtro dos produtos (nil = todos)
+
+	Trashed bool // Lista os produtos da lixeira em vez dos ativos
+}
+
+// ProductPage é uma página da listagem de produtos
+type ProductPage struct {
+	Products   []models.Product
+	Total      int    // Total de produtos que satisfazem a consulta, sem paginação
+	NextCursor string // Cursor da próxima página (vazio se esta for a última)
+}
+
+// productField descreve um campo de produto exposto para ordenação e seleção
+type productField struct {
+	column   string
+	sortable bool
+	numeric  bool
+	temporal bool                                // Data e hora (time.Time)
+	value    func(p *models.Product) interface{} // Valor do campo (memória e cursor)
+	scanDest func(p *models.Product) interface{} // Destino do Scan no SQL
+}
+
+// productFields são os campos que podem ser usados em sort= e fields=
+var productFields = map[string]productField{
+	"id": {column: "products.id", sortable: true, numeric: true,
+		value:    func(p *models.Product) interface{} { return p.ID },
+		scanDest: func(p *models.Product) interface{} { return &p.ID }},
+	"name": {column: "products.name", sortable: true,
+		value:    func(p *models.Product) interface{} { return p.Name },
+		scanDest: func(p *models.Product) interface{} { return &p.Name }},
+	"price": {column: "products.price", sortable: true, numeric: true,
+		value:    func(p *models.Product) interface{} { return p.Price },
+		scanDest: func(p *models.Product) interface{} { return &p.Price }},
+	"currency": {column: "products.currency",
+		value:    func(p *models.Product) interface{} { return p.Currency },
+		scanDest: func(p *models.Product) interface{} { return &p.Currency }},
+	"description": {column: "products.description",
+		value:    func(p *models.Product) interface{} { return p.Description },
+		scanDest: func(p *models.Product) interface{} { return &p.Description }},
+	"category_id": {column: "products.category_id", numeric: true,
+		value:    func(p *models.Product) interface{} { return p.CategoryID },
+		scanDest: func(p *models.Product) interface{} { return nullInt{&p.CategoryID} }},
+	"category": {column: "COALESCE(categories.name, '')", sortable: true,
+		value:    func(p *models.Product) interface{} { return p.Category },
+		scanDest: func(p *models.Product) interface{} { return &p.Category }},
+	"image_url": {column: "products.image_url",
+		value:    func(p *models.Product) interface{} { return p.ImageURL },
+		scanDest: func(p *models.Product) interface{} { return nullString{&p.ImageURL} }},
+	"version": {column: "products.version", numeric: true,
+		value:    func(p *models.Product) interface{} { return p.Version },
+		scanDest: func(p *models.Product) interface{} { return &p.Version }},
+	"created_at": {column: "products.created_at", sortable: true, temporal: true,
+		value:    func(p *models.Product) interface{} { return p.CreatedAt },
+		scanDest: func(p *models.Product) interface{} { return utcTime{&p.CreatedAt} }},
+	"updated_at": {column: "products.updated_at", sortable: true, temporal: true,
+		value:    func(p *models.Product) interface{} { return p.UpdatedAt },
+		scanDest: func(p *models.Product) interface{} { return utcTime{&p.UpdatedAt} }},
+	"deleted_at": {column: "products.deleted_at",
+		value:    func(p *models.Product) interface{} { return p.DeletedAt },
+		scanDest: func(p *models.Product) interface{} { return nullTime{&p.DeletedAt} }},
+}
+
+// ParseSort interpreta uma ordenação no formato "price,-name"
+// (o prefixo "-" indica ordem decrescente)
+func ParseSort(s string) ([]SortField, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var sort []SortField
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		field := SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if f, ok := productFields[field.Field]; !ok || !f.sortable {
+			return nil, fmt.Errorf("campo de ordenação inválido: %q", part)
+		}
+		sort = append(sort, field)
+	}
+	return sort, nil
+}
+
+// ParseFields interpreta uma lista de campos no formato "id,name,price"
+func ParseFields(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if _, ok := productFields[field]; !ok {
+			return nil, fmt.Errorf("campo inválido: %q", field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// normalize aplica os limites de paginação e garante que a ordenação termine
+// pelo ID, que é único e torna a ordem (e o cursor) determinística
+func (o ListOptions) normalize() ListOptions {
+	if o.Limit <= 0 {
+		o.Limit = DefaultLimit
+	}
+	if o.Limit > MaxLimit {
+		o.Limit = MaxLimit
+	}
+	if o.Offset < 0 || o.Cursor != "" {
+		o.Offset = 0
+	}
+
+	// Campos depois do ID não alteram a ordem, pois o ID já é único
+	sort := make([]SortField, 0, len(o.Sort)+1)
+	for _, s := range o.Sort {
+		sort = append(sort, s)
+		if s.Field == "id" {
+			o.Sort = sort
+			return o
+		}
+	}
+	o.Sort = append(sort, SortField{Field: "id"})
+	return o
+}
+
+// columns retorna os campos a carregar: os selecionados mais os necessários
+// para a ordenação e para o cursor
+func (o ListOptions) columns() []string {
+	if len(o.Fields) == 0 {
+		return []string{"id", "name", "price", "currency", "description", "category_id", "category", "image_url", "version", "created_at", "updated_at", "deleted_at"}
+	}
+
+	seen := make(map[string]bool)
+	var columns []string
+	add := func(field string) {
+		if !seen[field] {
+			seen[field] = true
+			columns = append(columns, field)
+		}
+	}
+	add("id")
+	for _, s := range o.Sort {
+		add(s.Field)
+	}
+	for _, f := range o.Fields {
+		add(f)
+	}
+	return columns
+}
+
+// sortKey identifica a ordenação, para que um cursor não seja usado com outra ordem
+func sortKey(sort []SortField) string {
+	parts := make([]string, len(sort))
+	for i, s := range sort {
+		parts[i] = s.Field
+		if s.Desc {
+			parts[i] = "-" + s.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// cursor é o conteúdo (opaco para o cliente) do cursor de paginação
+type cursor struct {
+	Sort   string            `json:"s"`
+	Values []json.RawMessage `json:"v"`
+}
+
+// encodeCursor gera o cursor que aponta para logo após o produto informado
+func encodeCursor(sort []SortField, p *models.Product) string {
+	c := cursor{Sort: sortKey(sort)}
+	for _, s := range sort {
+		raw, _ := json.Marshal(productFields[s.Field].value(p))
+		c.Values = append(c.Values, raw)
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor lê o cursor e retorna os valores dos campos de ordenação
+func decodeCursor(s string, sort []SortField) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != sortKey(sort) || len(c.Values) != len(sort) {
+		return nil, ErrInvalidCursor
+	}
+
+	values := make([]interface{}, len(sort))
+	for i, s := range sort {
+		if productFields[s.Field].numeric {
+			var n int
+			if err := json.Unmarshal(c.Values[i], &n); err != nil {
+				return nil, ErrInvalidCursor
+			}
+			values[i] = n
+		} else if productFields[s.Field].temporal {
+			var t time.Time
+			if err := json.Unmarshal(c.Values[i], &t); err != nil {
+				return nil, ErrInvalidCursor
+			}
+			values[i] = t.UTC()
+		} else {
+			var str string
+			if err := json.Unmarshal(c.Values[i], &str); err != nil {
+				return nil, ErrInvalidCursor
+			}
+			values[i] = str
+		}
+	}
+	return values, nil
+}
+
+// nullString permite ler colunas de texto opcionais (NULL vira string vazia)
+type nullString struct {
+	dst *string
+}
+
+// Scan implementa sql.Scanner
+func (n nullString) Scan(value interface{}) error {
+	var ns sql.NullString
+	if err := ns.Scan(value); err != nil {
+		return err
+	}
+	*n.dst = ns.String
+	return nil
+}
+
+// nullInt permite ler colunas inteiras opcionais (NULL vira zero)
+type nullInt struct {
+	dst *int
+}
+
+// Scan implementa sql.Scanner
+func (n nullInt) Scan(value interface{}) error {
+	var ni sql.NullInt64
+	if err := ni.Scan(value); err != nil {
+		return err
+	}
+	*n.dst = int(ni.Int64)
+	return nil
+}
+
+// utcTime lê colunas de data e hora convertendo-as para UTC, seja qual for o fuso devolvido pelo driver
+type utcTime struct {
+	dst *time.Time
+}
+
+// Scan implementa sql.Scanner
+func (u utcTime) Scan(value interface{}) error {
+	var nt sql.NullTime
+	if err := nt.Scan(value); err != nil {
+		return err
+	}
+	*u.dst = nt.Time.UTC()
+	return nil
+}
+
+// nullTime lê colunas de data e hora opcionais (NULL vira nil), em UTC
+type nullTime struct {
+	dst **time.Time
+}
+
+// Scan implementa sql.Scanner
+func (n nullTime) Scan(value interface{}) error {
+	var nt sql.NullTime
+	if err := nt.Scan(value); err != nil {
+		return err
+	}
+	*n.dst = nil
+	if nt.Valid {
+		t := nt.Time.UTC()
+		*n.dst = &t
+	}
+	return nil
+}
+
+// now é o instante gravado em created_at e updated_at: em UTC e com a precisão
+// de microssegundos do PostgreSQL, para que o valor lido seja igual ao gravado
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"braip/internal/filter"
+	"braip/internal/models"
+	"context"
+	"errors"
+	"testing"
+)
+
+// nameFilter seleciona apenas os produtos do teste, pela palavra única no nome
+func nameFilter(t *testing.T, word string) filter.Expr {
+	t.Helper()
+	expr, err := filter.Parse("name contains " + word)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return expr
+}
+
+// allPages percorre as páginas seguindo o cursor e retorna os IDs na ordem recebida
+func allPages(t *testing.T, store ProductStore, opts ListOptions) []int {
+	t.Helper()
+	var ids []int
+	for pages := 0; ; pages++ {
+		if pages > 20 {
+			t.Fatal("paginação não terminou")
+		}
+		page, err := store.GetProducts(context.Background(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Products) > opts.Limit {
+			t.Fatalf("página com %d produtos; limite %d", len(page.Products), opts.Limit)
+		}
+		ids = append(ids, productIDs(page.Products)...)
+		if page.NextCursor == "" {
+			return ids
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+func equalIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCursorPagination(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store ProductStore) {
+		word := uniqueWord()
+		// Preços repetidos: o ID desempata e mantém a ordem determinística
+		a := createTestProduct(t, store, models.Product{Name: word + " a", Price: 3000, Description: "x"})
+		b := createTestProduct(t, store, models.Product{Name: word + " b", Price: 1000, Description: "x"})
+		c := createTestProduct(t, store, models.Product{Name: word + " c", Price: 3000, Description: "x"})
+		d := createTestProduct(t, store, models.Product{Name: word + " d", Price: 2000, Description: "x"})
+		e := createTestProduct(t, store, models.Product{Name: word + " e", Price: 1000, Description: "x"})
+
+		tests := []struct {
+			sort string
+			want []int
+		}{
+			{"", []int{a, b, c, d, e}},
+			{"-price", []int{a, c, d, b, e}},
+			{"price,-name", []int{e, b, d, c, a}},
+			{"-id", []int{e, d, c, b, a}},
+		}
+		for _, tt := range tests {
+			sort, err := ParseSort(tt.sort)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, limit := range []int{1, 2, 5, 10} {
+				ids := allPages(t, store, ListOptions{Limit: limit, Sort: sort, Filter: nameFilter(t, word)})
+				if !equalIDs(ids, tt.want) {
+					t.Errorf("sort=%q, limit=%d: %v; esperado %v", tt.sort, limit, ids, tt.want)
+				}
+			}
+		}
+
+		// Um produto criado no meio da paginação não repete nem pula os já listados
+		opts := ListOptions{Limit: 2, Filter: nameFilter(t, word)}
+		page, err := store.GetProducts(context.Background(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := createTestProduct(t, store, models.Product{Name: word + " f", Price: 500, Description: "x"})
+		opts.Cursor = page.NextCursor
+		if ids := append(productIDs(page.Products), allPages(t, store, opts)...); !equalIDs(ids, []int{a, b, c, d, e, f}) {
+			t.Errorf("paginação com produto novo: %v", ids)
+		}
+	})
+}
+
+func TestPaginationEdgeCases(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store ProductStore) {
+		ctx := context.Background()
+		word := uniqueWord()
+		for _, name := range []string{"a", "b", "c"} {
+			createTestProduct(t, store, models.Product{Name: word + " " + name, Price: 1000, Description: "x"})
+		}
+		expr := nameFilter(t, word)
+
+		// O total ignora a paginação; um offset além do fim retorna uma página vazia
+		page, err := store.GetProducts(ctx, ListOptions{Limit: 2, Offset: 5, Filter: expr})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != 3 || len(page.Products) != 0 || page.NextCursor != "" {
+			t.Errorf("offset além do fim: total %d, %d produtos, cursor %q", page.Total, len(page.Products), page.NextCursor)
+		}
+
+		// A última página exata não tem próximo cursor
+		page, err = store.GetProducts(ctx, ListOptions{Limit: 3, Filter: expr})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Products) != 3 || page.NextCursor != "" {
+			t.Errorf("página completa: %d produtos, cursor %q", len(page.Products), page.NextCursor)
+		}
+
+		// Cursores malformados ou de outra ordenação são recusados
+		page, err = store.GetProducts(ctx, ListOptions{Limit: 1, Filter: expr})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort, _ := ParseSort("-price")
+		for _, opts := range []ListOptions{
+			{Cursor: "não é base64!"},
+			{Cursor: "e30"}, // {}
+			{Cursor: page.NextCursor, Sort: sort},
+		} {
+			opts.Filter = expr
+			if _, err := store.GetProducts(ctx, opts); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("cursor %q com sort %v: erro %v; esperado ErrInvalidCursor", opts.Cursor, opts.Sort, err)
+			}
+		}
+
+		// Com o cursor, o offset é ignorado
+		next, err := store.GetProducts(ctx, ListOptions{Limit: 1, Offset: 2, Cursor: page.NextCursor, Filter: expr})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(next.Products) != 1 || next.Products[0].Name != word+" b" {
+			t.Errorf("cursor com offset: %+v; esperado o segundo produto", next.Products)
+		}
+	})
+}
+
+// Os campos pedidos são carregados e a paginação continua pelo ID, mesmo sem ele em fields
+func TestFieldSelection(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store ProductStore) {
+		word := uniqueWord()
+		createTestProduct(t, store, models.Product{Name: word + " a", Price: 1000, Description: "Descrição", ImageURL: "https://example.com/a.png"})
+		createTestProduct(t, store, models.Product{Name: word + " b", Price: 2000, Description: "Descrição"})
+
+		fields, err := ParseFields("name, price")
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := ListOptions{Limit: 1, Fields: fields, Filter: nameFilter(t, word)}
+		page, err := store.GetProducts(context.Background(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := page.Products[0]
+		if p.Name != word+" a" || p.Price != 1000 {
+			t.Errorf("produto com fields=name,price: %+v", p)
+		}
+		// No SQL, as demais colunas nem são lidas; em memória, a API é que as descarta
+		if _, ok := store.(*SQLProductRepository); ok && (p.Description != "" || p.ImageURL != "" || p.Version != 0) {
+			t.Errorf("colunas não pedidas carregadas: %+v", p)
+		}
+
+		opts.Cursor = page.NextCursor
+		if page, err = store.GetProducts(context.Background(), opts); err != nil || len(page.Products) != 1 || page.Products[0].Name != word+" b" {
+			t.Errorf("segunda página com fields=name,price: %+v, %v", page, err)
+		}
+	})
+}
+
+func TestParseSortAndFields(t *testing.T) {
+	sort, err := ParseSort(" price , -name ")
+	if err != nil || len(sort) != 2 || sort[0] != (SortField{Field: "price"}) || sort[1] != (SortField{Field: "name", Desc: true}) {
+		t.Errorf("ParseSort = %v, %v", sort, err)
+	}
+	// Campos que não existem ou não são ordenáveis
+	for _, s := range []string{"senha", "description", "price,", "--price"} {
+		if _, err := ParseSort(s); err == nil {
+			t.Errorf("ParseSort(%q) aceito", s)
+		}
+	}
+	for _, s := range []string{"senha", "id,", "name,,price"} {
+		if _, err := ParseFields(s); err == nil {
+			t.Errorf("ParseFields(%q) aceito", s)
+		}
+	}
+
+	// O ID fecha a ordenação; o que vem depois dele não muda a ordem
+	opts := ListOptions{Sort: []SortField{{Field: "price"}, {Field: "id", Desc: true}, {Field: "name"}}, Limit: MaxLimit + 1}.normalize()
+	if sortKey(opts.Sort) != "price,-id" || opts.Limit != MaxLimit {
+		t.Errorf("normalize: sort %q, limit %d", sortKey(opts.Sort), opts.Limit)
+	}
+	if opts := (ListOptions{}).normalize(); sortKey(opts.Sort) != "id" || opts.Limit != DefaultLimit {
+		t.Errorf("normalize sem opções: sort %q, limit %d", sortKey(opts.Sort), opts.Limit)
+	}
+}
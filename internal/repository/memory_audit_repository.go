@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"braip/internal/models"
+	"context"
+)
+
+// recordChange registra na auditoria a alteração de um produto e, se o preço mudou,
+// o novo preço no histórico. before e after são os produtos guardados (apenas com o
+// category_id) ou nil. Deve ser chamado com o lock obtido.
+func (r *MemoryProductRepository) recordChange(ctx context.Context, action string, before, after *models.Product) error {
+	productID := 0
+	for _, p := range []*models.Product{before, after} {
+		if p != nil {
+			productID = p.ID
+			*p = r.withCategory(*p)
+		}
+	}
+
+	entry, err := newAuditEntry(ctx, action, productID, before, after)
+	if err != nil {
+		return err
+	}
+	entry.ID = int64(len(r.auditEntries) + 1)
+	r.auditEntries = append(r.auditEntries, entry)
+
+	if priceChanged(before, after) {
+		r.priceHistory = append(r.priceHistory, newPriceChange(before, after, entry.CreatedAt))
+	}
+	return nil
+}
+
+// GetAuditEntries retorna uma página da auditoria, do registro mais recente para o mais antigo
+func (r *MemoryProductRepository) GetAuditEntries(ctx context.Context, opts AuditOptions) (*AuditPage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	opts = opts.normalize()
+	page := &AuditPage{}
+	for i := len(r.auditEntries) - 1; i >= 0; i-- {
+		entry := r.auditEntries[i]
+		if !opts.matches(&entry) {
+			continue
+		}
+		if page.Total >= opts.Offset && len(page.Entries) < opts.Limit {
+			page.Entries = append(page.Entries, entry)
+		}
+		page.Total++
+	}
+	return page, nil
+}
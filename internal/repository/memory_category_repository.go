@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"braip/internal/models"
+	"braip/internal/textutil"
+	"context"
+	"sort"
+)
+
+// GetCategories retorna todas as categorias, ordenadas pelo nome
+func (r *MemoryProductRepository) GetCategories(ctx context.Context) ([]models.Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	categories := make([]models.Category, 0, len(r.categories))
+	for _, c := range r.categories {
+		categories = append(categories, c)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Name != categories[j].Name {
+			return categories[i].Name < categories[j].Name
+		}
+		return categories[i].ID < categories[j].ID
+	})
+	return categories, nil
+}
+
+// GetCategoryByID retorna uma categoria pelo ID ou nil se ela não existir
+func (r *MemoryProductRepository) GetCategoryByID(ctx context.Context, id int) (*models.Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	category, ok := r.categories[id]
+	if !ok {
+		return nil, nil // Categoria não encontrada
+	}
+	return &category, nil
+}
+
+// CreateCategory insere uma nova categoria, gerando o slug a partir do nome
+func (r *MemoryProductRepository) CreateCategory(ctx context.Context, category models.Category) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	category.Name = textutil.Clean(category.Name)
+	category.Slug = textutil.Slug(category.Name)
+	if err := r.checkCategory(0, category.Slug, category.ParentID); err != nil {
+		return 0, err
+	}
+
+	category.ID = r.nextCategoryID
+	r.categories[category.ID] = category
+	r.nextCategoryID++
+
+	return int64(category.ID), nil
+}
+
+// UpdateCategory atualiza o nome (e o slug) e a categoria pai de uma categoria
+func (r *MemoryProductRepository) UpdateCategory(ctx context.Context, id int, category models.Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.categories[id]; !ok {
+		return ErrCategoryNotFound
+	}
+
+	category.Name = textutil.Clean(category.Name)
+	category.Slug = textutil.Slug(category.Name)
+	if err := r.checkCategory(id, category.Slug, category.ParentID); err != nil {
+		return err
+	}
+
+	category.ID = id
+	r.categories[id] = category
+	return nil
+}
+
+// DeleteCategory remove uma categoria sem produtos nem subcategorias
+func (r *MemoryProductRepository) DeleteCategory(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.categories[id]; !ok {
+		return ErrCategoryNotFound
+	}
+	for _, p := range r.products {
+		if p.CategoryID == id {
+			return ErrCategoryInUse
+		}
+	}
+	for _, c := range r.categories {
+		if c.ParentID != nil && *c.ParentID == id {
+			return ErrCategoryInUse
+		}
+	}
+
+	delete(r.categories, id)
+	return nil
+}
+
+// checkCategory valida o slug (não vazio e não usado por outra categoria) e a categoria pai.
+// Deve ser chamado com o lock obtido.
+func (r *MemoryProductRepository) checkCategory(id int, slug string, parentID *int) error {
+	if slug == "" {
+		return ErrCategoryRequired
+	}
+	if other, ok := r.categoryBySlug(slug); ok && other.ID != id {
+		return ErrCategoryExists
+	}
+
+	return checkParent(id, parentID, func(id int) (*int, bool, error) {
+		category, ok := r.categories[id]
+		return category.ParentID, ok, nil
+	})
+}
+
+// resolveCategory retorna o ID da categoria de um produto, criando-a pelo nome
+// se necessário, com a mesma regra do repositório SQL. Deve ser chamado com o lock de escrita.
+func (r *MemoryProductRepository) resolveCategory(product models.Product) (int, error) {
+	if product.CategoryID != 0 {
+		if _, ok := r.categories[product.CategoryID]; !ok {
+			return 0, ErrCategoryNotFound
+		}
+		return product.CategoryID, nil
+	}
+
+	slug := textutil.Slug(product.Category)
+	if slug == "" {
+		return 0, ErrCategoryRequired
+	}
+	if category, ok := r.categoryBySlug(slug); ok {
+		return category.ID, nil
+	}
+
+	category := models.Category{ID: r.nextCategoryID, Slug: slug, Name: textutil.Clean(product.Category)}
+	r.categories[category.ID] = category
+	r.nextCategoryID++
+	return category.ID, nil
+}
+
+// categoryBySlug procura uma categoria pelo slug. Deve ser chamado com o lock obtido.
+func (r *MemoryProductRepository) categoryBySlug(slug string) (models.Category, bool) {
+	for _, c := range r.categories {
+		if c.Slug == slug {
+			return c, true
+		}
+	}
+	return models.Category{}, false
+}
+
+// withCategory preenche o nome da categoria do produto. Deve ser chamado com o lock obtido.
+func (r *MemoryProductRepository) withCategory(p models.Product) models.Product {
+	p.Category = r.categories[p.CategoryID].Name
+	return p
+}
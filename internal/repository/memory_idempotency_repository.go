@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"braip/internal/models"
+	"context"
+	"sync"
+)
+
+// memoryIdempotency guarda as chaves de idempotência do backend em memória. Fica fora
+// do estado copiado por WithTransaction: as chaves não fazem parte das transações.
+type memoryIdempotency struct {
+	mu      sync.Mutex
+	records map[string]models.IdempotencyRecord
+}
+
+// ReserveIdempotencyKey apaga as chaves expiradas e grava o registro se a chave
+// estiver livre; caso contrário, retorna o registro existente
+func (r *MemoryProductRepository) ReserveIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) (*models.IdempotencyRecord, bool, error) {
+	r.idempotency.mu.Lock()
+	defer r.idempotency.mu.Unlock()
+
+	current := now()
+	for key, stored := range r.idempotency.records {
+		if !stored.ExpiresAt.After(current) {
+			delete(r.idempotency.records, key)
+		}
+	}
+
+	if existing, ok := r.idempotency.records[record.Key]; ok {
+		return &existing, false, nil
+	}
+	record.Status, record.Header, record.Body = 0, nil, nil
+	r.idempotency.records[record.Key] = record
+	return nil, true, nil
+}
+
+// CompleteIdempotencyKey guarda a resposta na chave reservada pela mesma requisição
+func (r *MemoryProductRepository) CompleteIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) error {
+	r.idempotency.mu.Lock()
+	defer r.idempotency.mu.Unlock()
+
+	stored, ok := r.idempotency.records[record.Key]
+	if !ok || stored.RequestHash != record.RequestHash || stored.Completed() {
+		return nil
+	}
+	stored.Status, stored.Header, stored.Body, stored.ExpiresAt = record.Status, record.Header, record.Body, record.ExpiresAt
+	r.idempotency.records[record.Key] = stored
+	return nil
+}
+
+// ReleaseIdempotencyKey apaga a chave
+func (r *MemoryProductRepository) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	r.idempotency.mu.Lock()
+	defer r.idempotency.mu.Unlock()
+	delete(r.idempotency.records, key)
+	return nil
+}
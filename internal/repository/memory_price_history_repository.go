@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"braip/internal/models"
+	"context"
+	"sort"
+)
+
+// GetPriceHistory retorna os preços de um produto, do mais antigo para o mais recente
+func (r *MemoryProductRepository) GetPriceHistory(ctx context.Context, productID int) ([]models.PriceChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var history []models.PriceChange
+	for _, c := range r.priceHistory {
+		if c.ProductID == productID {
+			history = append(history, c)
+		}
+	}
+	return history, nil
+}
+
+// GetPriceDrops retorna os produtos com queda de preço de pelo menos opts.MinPercent
+// desde opts.Since, com a mesma semântica do repositório SQL
+func (r *MemoryProductRepository) GetPriceDrops(ctx context.Context, opts PriceDropOptions) ([]models.PriceDrop, error) {
+	opts = opts.normalize()
+
+	products := r.filter(func(p models.Product) bool { return p.DeletedAt == nil })
+
+	// Preço no início do período, na moeda atual do produto: o último definido
+	// até opts.Since ou, para produtos criados depois, o primeiro preço
+	r.mu.RLock()
+	currency := make(map[int]string, len(products))
+	for _, p := range products {
+		currency[p.ID] = p.Currency
+	}
+	start := make(map[int]int)
+	for _, c := range r.priceHistory {
+		if c.Currency != currency[c.ProductID] {
+			continue
+		}
+		if _, ok := start[c.ProductID]; !ok || !c.ChangedAt.After(opts.Since) {
+			start[c.ProductID] = c.Price
+		}
+	}
+	r.mu.RUnlock()
+
+	var drops []models.PriceDrop
+	for _, p := range products {
+		previous, ok := start[p.ID]
+		if !ok || previous <= p.Price || float64(previous-p.Price)*100 < opts.MinPercent*float64(previous) {
+			continue
+		}
+		drops = append(drops, models.PriceDrop{Product: p, PreviousPrice: previous, DropPercent: dropPercent(previous, p.Price)})
+	}
+
+	sort.SliceStable(drops, func(i, j int) bool {
+		a, b := drops[i], drops[j]
+		// Compara as quedas relativas sem arredondamento: (a.prev-a.price)/a.prev > (b.prev-b.price)/b.prev
+		return (a.PreviousPrice-a.Price)*b.PreviousPrice > (b.PreviousPrice-b.Price)*a.PreviousPrice
+	})
+	if len(drops) > opts.Limit {
+		drops = drops[:opts.Limit]
+	}
+	return drops, nil
+}
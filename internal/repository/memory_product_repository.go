@@ -0,0 +1,423 @@
+package repository
+
+import (
+	"braip/internal/audit"
+	"braip/internal/models"
+	"braip/internal/textutil"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryProductRepository implementa ProductStore guardando os produtos em memória.
+// Os dados são perdidos ao encerrar o processo, por isso ele é indicado apenas
+// para testes e servidores de demonstração.
+type MemoryProductRepository struct {
+	mu       sync.RWMutex
+	products map[int]models.Product // Produtos guardados apenas com o category_id
+	nextID   int
+
+	categories     map[int]models.Category
+	nextCategoryID int
+
+	auditEntries []models.AuditEntry  // Auditoria, na ordem em que as alterações aconteceram
+	priceHistory []models.PriceChange // Histórico de preços, na ordem em que mudaram
+
+	idempotency *memoryIdempotency // Chaves de idempotência, com lock próprio
+}
+
+// NewMemoryProductRepository cria um repositório em memória vazio
+func NewMemoryProductRepository() *MemoryProductRepository {
+	return &MemoryProductRepository{
+		products:       make(map[int]models.Product),
+		nextID:         1,
+		categories:     make(map[int]models.Category),
+		nextCategoryID: 1,
+		idempotency:    &memoryIdempotency{records: make(map[string]models.IdempotencyRecord)},
+	}
+}
+
+// GetProducts retorna uma página de produtos, com a mesma semântica de
+// filtro, paginação, ordenação e cursor do repositório SQL
+func (r *MemoryProductRepository) GetProducts(ctx context.Context, opts ListOptions) (*ProductPage, error) {
+	opts = opts.normalize()
+
+	products := r.filter(func(p models.Product) bool {
+		return (p.DeletedAt != nil) == opts.Trashed && matchFilter(opts.Filter, &p)
+	})
+	sort.SliceStable(products, func(i, j int) bool {
+		return compareProducts(&products[i], &products[j], opts.Sort) < 0
+	})
+	page := &ProductPage{Total: len(products)}
+
+	// Paginação por cursor: descarta os produtos até o cursor (inclusive)
+	if opts.Cursor != "" {
+		values, err := decodeCursor(opts.Cursor, opts.Sort)
+		if err != nil {
+			return nil, err
+		}
+		start := sort.Search(len(products), func(i int) bool {
+			return compareToCursor(&products[i], opts.Sort, values) > 0
+		})
+		products = products[start:]
+	}
+
+	if opts.Offset >= len(products) {
+		return page, nil
+	}
+	products = products[opts.Offset:]
+
+	if len(products) > opts.Limit {
+		products = products[:opts.Limit]
+		page.NextCursor = encodeCursor(opts.Sort, &products[opts.Limit-1])
+	}
+	page.Products = products
+
+	return page, nil
+}
+
+// CreateProduct insere um novo produto gerando o próximo ID disponível
+func (r *MemoryProductRepository) CreateProduct(ctx context.Context, product models.Product) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkName(product.Name, 0); err != nil {
+		return 0, err
+	}
+	categoryID, err := r.resolveCategory(product)
+	if err != nil {
+		return 0, err
+	}
+
+	product.ID, product.Version = r.nextID, 1
+	product.CategoryID, product.Category = categoryID, ""
+	product.CreatedAt = now()
+	product.UpdatedAt = product.CreatedAt
+	r.products[product.ID] = product
+	r.nextID++
+
+	if err := r.recordChange(ctx, audit.ActionCreate, nil, &product); err != nil {
+		return 0, err
+	}
+	return int64(product.ID), nil
+}
+
+// ImportProduct insere um produto mantendo o ID informado, ignorando-o se o ID já existir
+func (r *MemoryProductRepository) ImportProduct(ctx context.Context, product models.Product) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Como no SQL, o nome repetido é recusado mesmo quando o ID já existe
+	if err := r.checkName(product.Name, product.ID); err != nil {
+		return false, err
+	}
+	if _, ok := r.products[product.ID]; ok {
+		return false, nil
+	}
+
+	categoryID, err := r.resolveCategory(product)
+	if err != nil {
+		return false, err
+	}
+	product.CategoryID, product.Category = categoryID, ""
+	product.Version = 1
+	product.CreatedAt = now()
+	product.UpdatedAt = product.CreatedAt
+	r.products[product.ID] = product
+
+	// Evita que os próximos IDs gerados colidam com o importado
+	if product.ID >= r.nextID {
+		r.nextID = product.ID + 1
+	}
+
+	return true, r.recordChange(ctx, audit.ActionCreate, nil, &product)
+}
+
+// GetProductByID retorna um produto pelo ID ou nil se ele não existir ou estiver na lixeira
+func (r *MemoryProductRepository) GetProductByID(ctx context.Context, id int) (*models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	product, ok := r.products[id]
+	if !ok || product.DeletedAt != nil {
+		return nil, nil // Produto não encontrado ou na lixeira
+	}
+	product = r.withCategory(product)
+	return &product, nil
+}
+
+// UpdateProduct atualiza um produto existente; retorna ErrProductNotFound se ele não existir
+func (r *MemoryProductRepository) UpdateProduct(ctx context.Context, id int, product models.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, err := r.stored(id, product.Version)
+	if err != nil {
+		return err
+	}
+	if err := r.checkName(product.Name, id); err != nil {
+		return err
+	}
+
+	categoryID, err := r.resolveCategory(product)
+	if err != nil {
+		return err
+	}
+	product.ID, product.Version = id, old.Version+1
+	product.CategoryID, product.Category = categoryID, ""
+	product.CreatedAt, product.UpdatedAt = old.CreatedAt, now()
+	r.products[id] = product
+
+	return r.recordChange(ctx, audit.ActionUpdate, &old, &product)
+}
+
+// PatchProduct altera apenas os campos informados no patch; retorna ErrProductNotFound se o produto não existir
+func (r *MemoryProductRepository) PatchProduct(ctx context.Context, id int, patch models.ProductPatch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, err := r.stored(id, patch.Version)
+	if err != nil {
+		return err
+	}
+	before := product
+
+	if patch.Name != nil {
+		if err := r.checkName(*patch.Name, id); err != nil {
+			return err
+		}
+		product.Name = *patch.Name
+	}
+	if patch.Price != nil {
+		product.Price = *patch.Price
+	}
+	if patch.Currency != nil {
+		product.Currency = *patch.Currency
+	}
+	if patch.Description != nil {
+		product.Description = *patch.Description
+	}
+	if patch.CategoryID != nil || patch.Category != nil {
+		categoryID, err := r.resolveCategory(patchCategory(patch))
+		if err != nil {
+			return err
+		}
+		product.CategoryID = categoryID
+	}
+	if patch.ImageURL != nil {
+		product.ImageURL = *patch.ImageURL
+	}
+	// Como no SQL, a versão muda (e a alteração é registrada) sempre que o patch informa algum campo
+	if patch == (models.ProductPatch{Version: patch.Version}) {
+		return nil
+	}
+	product.Version++
+	product.UpdatedAt = now()
+	r.products[id] = product
+
+	return r.recordChange(ctx, audit.ActionUpdate, &before, &product)
+}
+
+// DeleteProduct move um produto para a lixeira; retorna ErrProductNotFound se ele não existir
+func (r *MemoryProductRepository) DeleteProduct(ctx context.Context, id int, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, err := r.stored(id, version)
+	if err != nil {
+		return err
+	}
+	before := product
+	deletedAt := now()
+	product.DeletedAt, product.UpdatedAt = &deletedAt, deletedAt
+	product.Version++
+	r.products[id] = product
+	return r.recordChange(ctx, audit.ActionDelete, &before, &product)
+}
+
+// RestoreProduct tira um produto da lixeira; retorna ErrProductNotInTrash se ele não estiver nela
+// e *NameInUseError se outro produto passou a usar o mesmo nome
+func (r *MemoryProductRepository) RestoreProduct(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, ok := r.products[id]
+	if !ok || product.DeletedAt == nil {
+		return ErrProductNotInTrash
+	}
+	if err := r.checkName(product.Name, id); err != nil {
+		return err
+	}
+	before := product
+	product.DeletedAt, product.UpdatedAt = nil, now()
+	product.Version++
+	r.products[id] = product
+	return r.recordChange(ctx, audit.ActionRestore, &before, &product)
+}
+
+// PurgeProducts remove de vez os produtos que estão na lixeira desde antes de deletedBefore
+func (r *MemoryProductRepository) PurgeProducts(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ids []int
+	for id, p := range r.products {
+		if p.DeletedAt != nil && p.DeletedAt.Before(deletedBefore) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids) // Registra na auditoria na mesma ordem do SQL
+
+	for _, id := range ids {
+		before := r.products[id]
+		delete(r.products, id)
+		if err := r.recordChange(ctx, audit.ActionPurge, &before, nil); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(ids)), nil
+}
+
+// stored retorna o produto guardado (fora da lixeira), verificando a versão
+// esperada quando informada. Deve ser chamado com o lock obtido.
+func (r *MemoryProductRepository) stored(id int, version int) (models.Product, error) {
+	product, ok := r.products[id]
+	if !ok || product.DeletedAt != nil {
+		return models.Product{}, ErrProductNotFound
+	}
+	if version != 0 && product.Version != version {
+		return models.Product{}, ErrVersionMismatch
+	}
+	return product, nil
+}
+
+// WithTransaction executa fn sobre uma cópia dos dados, que substitui os dados do
+// repositório apenas se fn não retornar erro. As demais operações esperam a
+// transação terminar, como as escritas no SQLite.
+func (r *MemoryProductRepository) WithTransaction(ctx context.Context, fn func(store ProductStore) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx := &MemoryProductRepository{
+		products:       make(map[int]models.Product, len(r.products)),
+		nextID:         r.nextID,
+		categories:     make(map[int]models.Category, len(r.categories)),
+		nextCategoryID: r.nextCategoryID,
+		auditEntries:   append([]models.AuditEntry(nil), r.auditEntries...),
+		priceHistory:   append([]models.PriceChange(nil), r.priceHistory...),
+		idempotency:    r.idempotency,
+	}
+	for id, p := range r.products {
+		tx.products[id] = p
+	}
+	for id, c := range r.categories {
+		tx.categories[id] = c
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	r.products, r.nextID = tx.products, tx.nextID
+	r.categories, r.nextCategoryID = tx.categories, tx.nextCategoryID
+	r.auditEntries, r.priceHistory = tx.auditEntries, tx.priceHistory
+	return nil
+}
+
+// ProductIDByName retorna o ID do produto fora da lixeira (e de ID diferente de exceptID)
+// que usa o nome, comparado sem diferenciar maiúsculas, acentos e espaços; 0 se nenhum usar
+func (r *MemoryProductRepository) ProductIDByName(ctx context.Context, name string, exceptID int) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.productIDByName(name, exceptID), nil
+}
+
+// productIDByName é ProductIDByName com o lock já obtido
+func (r *MemoryProductRepository) productIDByName(name string, exceptID int) int {
+	key := textutil.Key(name)
+	for _, p := range r.products {
+		if p.ID != exceptID && p.DeletedAt == nil && textutil.Key(p.Name) == key {
+			return p.ID
+		}
+	}
+	return 0
+}
+
+// checkName retorna *NameInUseError se outro produto fora da lixeira já usar o nome.
+// Deve ser chamado com o lock obtido.
+func (r *MemoryProductRepository) checkName(name string, id int) error {
+	if other := r.productIDByName(name, id); other != 0 {
+		return &NameInUseError{ProductID: other}
+	}
+	return nil
+}
+
+// filter retorna, ordenados pelo ID, os produtos que satisfazem o predicado
+func (r *MemoryProductRepository) filter(match func(models.Product) bool) []models.Product {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var products []models.Product
+	for _, p := range r.products {
+		p = r.withCategory(p)
+		if match(p) {
+			products = append(products, p)
+		}
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+
+	return products
+}
+
+// compareProducts compara dois produtos segundo a ordenação informada
+func compareProducts(a, b *models.Product, fields []SortField) int {
+	for _, s := range fields {
+		f := productFields[s.Field]
+		if c := compareValues(f.value(a), f.value(b)); c != 0 {
+			if s.Desc {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+// compareToCursor compara um produto com os valores guardados no cursor
+func compareToCursor(p *models.Product, fields []SortField, values []interface{}) int {
+	for i, s := range fields {
+		if c := compareValues(productFields[s.Field].value(p), values[i]); c != 0 {
+			if s.Desc {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+// compareValues compara dois valores de campo (inteiros, textos ou datas)
+func compareValues(a, b interface{}) int {
+	switch av := a.(type) {
+	case int:
+		bv := b.(int)
+		switch {
+		case av < bv:
+			return -1
+		case av > bv:
+			return 1
+		}
+	case string:
+		return strings.Compare(av, b.(string))
+	case time.Time:
+		return av.Compare(b.(time.Time))
+	}
+	return 0
+}
+
+// containsFold reproduz o LIKE '%termo%' do SQLite, que ignora maiúsculas e minúsculas
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"braip/internal/models"
+	"context"
+	"math"
+	"time"
+)
+
+// PriceDropOptions define a consulta de produtos com queda de preço
+type PriceDropOptions struct {
+	MinPercent float64   // Queda mínima, em %, em relação ao preço no início do período
+	Since      time.Time // Início do período
+	Limit      int
+}
+
+// PriceHistoryStore define as consultas ao histórico de preços. O histórico é
+// gravado pelo próprio ProductStore sempre que um produto é criado ou muda de preço.
+type PriceHistoryStore interface {
+	// GetPriceHistory retorna os preços de um produto, do mais antigo para o mais recente
+	GetPriceHistory(ctx context.Context, productID int) ([]models.PriceChange, error)
+
+	// GetPriceDrops retorna os produtos (fora da lixeira) cujo preço atual está pelo
+	// menos opts.MinPercent abaixo do preço no início do período, da maior queda para a menor.
+	// Para produtos criados durante o período, a comparação é com o primeiro preço.
+	// Só são comparados preços na moeda atual do produto.
+	GetPriceDrops(ctx context.Context, opts PriceDropOptions) ([]models.PriceDrop, error)
+}
+
+// priceChanged informa se a alteração deve gerar um ponto no histórico de preços:
+// o produto foi criado ou teve o preço (ou a moeda) alterado
+func priceChanged(before, after *models.Product) bool {
+	return after != nil && (before == nil || before.Price != after.Price || before.Currency != after.Currency)
+}
+
+// newPriceChange monta o ponto do histórico de preços de uma alteração
+func newPriceChange(before, after *models.Product, changedAt time.Time) models.PriceChange {
+	change := models.PriceChange{ProductID: after.ID, Price: after.Price, Currency: after.Currency, ChangedAt: changedAt}
+	if before != nil {
+		previous := before.Price
+		change.PreviousPrice = &previous
+	}
+	return change
+}
+
+// dropPercent é a queda, em % e com duas casas, do preço anterior para o atual
+func dropPercent(previous, current int) float64 {
+	return math.Round(float64(previous-current)*10000/float64(previous)) / 100
+}
+
+// normalize aplica o limite de resultados
+func (o PriceDropOptions) normalize() PriceDropOptions {
+	if o.Limit <= 0 {
+		o.Limit = DefaultLimit
+	}
+	if o.Limit > MaxLimit {
+		o.Limit = MaxLimit
+	}
+	return o
+}
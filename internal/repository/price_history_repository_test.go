@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"braip/internal/models"
+	"context"
+	"testing"
+	"time"
+)
+
+// setPrice altera o preço do produto, falhando o teste em caso de erro
+func setPrice(t *testing.T, store ProductStore, id, price int) {
+	t.Helper()
+	if err := store.PatchProduct(context.Background(), id, models.ProductPatch{Price: &price}); err != nil {
+		t.Fatalf("PatchProduct(%d, preço %d): %v", id, price, err)
+	}
+}
+
+// instant retorna o momento atual, separado das escritas anteriores e seguintes
+func instant() time.Time {
+	time.Sleep(2 * time.Millisecond)
+	defer time.Sleep(2 * time.Millisecond)
+	return time.Now().UTC()
+}
+
+func TestGetPriceDrops(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store ProductStore) {
+		word := uniqueWord()
+		beforeCreation := instant()
+		bag := createTestProduct(t, store, models.Product{Name: word + " mochila", Price: 10000, Description: "Mochila"})
+		shirt := createTestProduct(t, store, models.Product{Name: word + " camisa", Price: 8000, Description: "Camisa"})
+		afterCreation := instant()
+		setPrice(t, store, bag, 8000)   // 20% abaixo do preço inicial
+		setPrice(t, store, shirt, 7000) // 12,5% abaixo do preço inicial
+		afterDrops := instant()
+		setPrice(t, store, bag, 6000) // 25% abaixo de 8000; 40% abaixo de 10000
+
+		tests := []struct {
+			name  string
+			since time.Time
+			min   float64
+			want  map[int]int // Produto -> preço no início do período
+		}{
+			{"produtos criados no período comparam com o primeiro preço", beforeCreation, 10, map[int]int{bag: 10000, shirt: 8000}},
+			{"preço no início do período", afterCreation, 10, map[int]int{bag: 10000, shirt: 8000}},
+			{"queda mínima fracionária, no limite", afterCreation, 12.5, map[int]int{bag: 10000, shirt: 8000}},
+			{"queda abaixo do mínimo", afterCreation, 12.6, map[int]int{bag: 10000}},
+			{"período depois das quedas", afterDrops, 10, map[int]int{bag: 8000}},
+			{"no limite da queda do período", afterDrops, 25, map[int]int{bag: 8000}},
+			{"acima da queda do período", afterDrops, 25.01, map[int]int{}},
+			{"período depois de todas as alterações", instant(), 0, map[int]int{}},
+		}
+		for _, tt := range tests {
+			drops, err := store.GetPriceDrops(context.Background(), PriceDropOptions{MinPercent: tt.min, Since: tt.since, Limit: 1000})
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			got := make(map[int]int)
+			var order []int
+			for _, d := range drops {
+				if d.ID == bag || d.ID == shirt {
+					got[d.ID] = d.PreviousPrice
+					order = append(order, d.ID)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("%s: quedas %v; esperado %v", tt.name, got, tt.want)
+				continue
+			}
+			for id, previous := range tt.want {
+				if got[id] != previous {
+					t.Errorf("%s: quedas %v; esperado %v", tt.name, got, tt.want)
+				}
+			}
+			// Da maior queda para a menor: a mochila caiu 40%, a camisa 12,5%
+			if len(order) == 2 && order[0] != bag {
+				t.Errorf("%s: ordem %v; esperado a mochila primeiro", tt.name, order)
+			}
+		}
+	})
+}
@@ -1,225 +1,85 @@
 package repository
 
 import (
-	"braip/internal/database"
+	"braip/internal/apperror"
 	"braip/internal/models"
-	"database/sql"
-	"log"
+	"context"
+	"fmt"
+	"time"
 )
 
-// GetProducts retorna todos os produtos do banco de dados
-func GetProducts() ([]models.Product, error) {
-	db, err := db.OpenDB()
-	if err != nil {
-		log.Printf("Erro ao conectar ao banco de dados: %v", err)
-		return nil, err
-	}
-	defer db.Close()
-
-	rows, err := db.Query("SELECT id, name, price, description, category, image_url FROM products")
-	if err != nil {
-		log.Printf("Erro ao buscar produtos: %v", err)
-		return nil, err
-	}
-	defer rows.Close()
-
-	var products []models.Product
-	for rows.Next() {
-		var p models.Product
-		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Description, &p.Category, &p.ImageURL); err != nil {
-			log.Printf("Erro ao processar produto: %v", err)
-			return nil, err
-		}
-		products = append(products, p)
-	}
-
-	return products, nil
-}
-
-// CreateProduct insere um novo produto no banco de dados
-func CreateProduct(product models.Product) (int64, error) {
-	db, err := db.OpenDB()
-	if err != nil {
-		log.Printf("Erro ao conectar ao banco de dados: %v", err)
-		return 0, err
-	}
-	defer db.Close()
-
-	result, err := db.Exec(
-		"INSERT INTO products (name, price, description, category, image_url) VALUES (?, ?, ?, ?, ?)",
-		product.Name, product.Price, product.Description, product.Category, product.ImageURL,
-	)
-	if err != nil {
-		log.Printf("Erro ao salvar produto: %v", err)
-		return 0, err
-	}
-
-	// Captura o ID gerado
-	id, err := result.LastInsertId()
-	if err != nil {
-		log.Printf("Erro ao obter ID do produto: %v", err)
-		return 0, err
-	}
-
-	return id, nil
-}
-
-// GetProductByID retorna um produto pelo ID
-func GetProductByID(id int) (*models.Product, error) {
-	db, err := db.OpenDB()
-	if err != nil {
-		log.Printf("Erro ao conectar ao banco de dados: %v", err)
-		return nil, err
-	}
-	defer db.Close()
-
-	var product models.Product
-	row := db.QueryRow("SELECT id, name, price, description, category, image_url FROM products WHERE id = ?", id)
-	err = row.Scan(&product.ID, &product.Name, &product.Price, &product.Description, &product.Category, &product.ImageURL)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil // Produto não encontrado
-		}
-		log.Printf("Erro ao buscar produto: %v", err)
-		return nil, err
-	}
-
-	return &product, nil
-}
-
-// UpdateProduct atualiza um produto no banco de dados
-func UpdateProduct(id int, product models.Product) error {
-	db, err := db.OpenDB()
-	if err != nil {
-		log.Printf("Erro ao conectar ao banco de dados: %v", err)
-		return err
-	}
-	defer db.Close()
-
-	_, err = db.Exec("UPDATE products SET name = ?, price = ?, description = ?, category = ?, image_url = ? WHERE id = ?",
-		product.Name, product.Price, product.Description, product.Category, product.ImageURL, id)
-	if err != nil {
-		log.Printf("Erro ao atualizar produto: %v", err)
-		return err
-	}
+// Erros das operações de produtos
+var (
+	ErrProductNotFound   = apperror.NotFound("produto não encontrado")
+	ErrProductExists     = apperror.PreconditionFailed("já existe um produto com esse ID")
+	ErrVersionMismatch   = apperror.PreconditionFailed("o produto foi alterado por outra requisição")
+	ErrProductNotInTrash = apperror.NotFound("produto não encontrado na lixeira")
+)
 
-	return nil
+// NameInUseError é o erro das escritas que dariam a um produto o nome de outro produto
+// fora da lixeira. Os nomes são comparados sem diferenciar maiúsculas, acentos e
+// espaços repetidos (textutil.Key).
+type NameInUseError struct {
+	ProductID int // Produto que já usa o nome; 0 se não for conhecido
 }
 
-// DeleteProduct remove um produto do banco de dados
-func DeleteProduct(id int) error {
-	db, err := db.OpenDB()
-	if err != nil {
-		log.Printf("Erro ao conectar ao banco de dados: %v", err)
-		return err
-	}
-	defer db.Close()
-
-	_, err = db.Exec("DELETE FROM products WHERE id = ?", id)
-	if err != nil {
-		log.Printf("Erro ao excluir produto: %v", err)
-		return err
+// Error implementa a interface error
+func (e *NameInUseError) Error() string {
+	if e.ProductID == 0 {
+		return "já existe um produto com esse nome"
 	}
-
-	return nil
+	return fmt.Sprintf("já existe um produto com esse nome (ID %d)", e.ProductID)
 }
 
-// SearchProductsByNameAndCategory busca produtos por nome e categoria
-func SearchProductsByNameAndCategory(name, category string) ([]models.Product, error) {
-	db, err := db.OpenDB()
-	if err != nil {
-		log.Printf("Erro ao conectar ao banco de dados: %v", err)
-		return nil, err
-	}
-	defer db.Close()
-
-	query := `SELECT id, name, price, description, category, image_url 
-			  FROM products WHERE name LIKE ? AND category LIKE ?`
-	rows, err := db.Query(query, "%"+name+"%", "%"+category+"%")
-	if err != nil {
-		log.Printf("Erro ao buscar produtos: %v", err)
-		return nil, err
-	}
-	defer rows.Close()
-
-	var products []models.Product
-	for rows.Next() {
-		var p models.Product
-		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Description, &p.Category, &p.ImageURL); err != nil {
-			log.Printf("Erro ao processar produto: %v", err)
-			return nil, err
-		}
-		products = append(products, p)
-	}
-
-	return products, nil
+// Kind classifica o erro como conflito para a camada api
+func (e *NameInUseError) Kind() apperror.Kind {
+	return apperror.KindConflict
 }
 
-// SearchProductsByCategory busca produtos por categoria
-func SearchProductsByCategory(category string) ([]models.Product, error) {
-	db, err := db.OpenDB()
-	if err != nil {
-		log.Printf("Erro ao conectar ao banco de dados: %v", err)
-		return nil, err
-	}
-	defer db.Close()
-
-	query := `SELECT id, name, price, description, category, image_url 
-			  FROM products WHERE category LIKE ?`
-	rows, err := db.Query(query, "%"+category+"%")
-	if err != nil {
-		log.Printf("Erro ao buscar produtos: %v", err)
-		return nil, err
-	}
-	defer rows.Close()
-
-	var products []models.Product
-	for rows.Next() {
-		var p models.Product
-		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Description, &p.Category, &p.ImageURL); err != nil {
-			log.Printf("Erro ao processar produto: %v", err)
-			return nil, err
-		}
-		products = append(products, p)
-	}
-
-	return products, nil
+// ProductStore define as operações de persistência de produtos.
+// As escritas recebem a versão esperada do produto (product.Version, patch.Version
+// ou version): diferente de zero, a escrita só acontece se o produto ainda estiver
+// nessa versão, caso contrário retorna ErrVersionMismatch.
+// DeleteProduct apenas move o produto para a lixeira: produtos excluídos não aparecem
+// nas leituras nem nas buscas (exceto em GetProducts com opts.Trashed) até serem
+// restaurados com RestoreProduct ou removidos de vez com PurgeProducts.
+// Cada alteração de produto é registrada na auditoria (AuditStore) junto com a
+// própria alteração, atribuída ao autor do contexto (audit.WithActor); criações e
+// mudanças de preço também entram no histórico de preços (PriceHistoryStore).
+// Dois produtos fora da lixeira não podem ter o mesmo nome: as escritas (inclusive
+// RestoreProduct) que causariam a repetição retornam *NameInUseError.
+// Cada backend de armazenamento (SQLite, PostgreSQL, memória, ...) implementa essa interface,
+// permitindo trocar o banco sem alterar as camadas de serviço e API.
+type ProductStore interface {
+	// Os produtos referenciam categorias: todo backend de produtos também guarda as categorias
+	CategoryStore
+	AuditStore
+	PriceHistoryStore
+	IdempotencyStore
+
+	GetProducts(ctx context.Context, opts ListOptions) (*ProductPage, error)
+	CreateProduct(ctx context.Context, product models.Product) (int64, error)
+	ImportProduct(ctx context.Context, product models.Product) (bool, error)
+	GetProductByID(ctx context.Context, id int) (*models.Product, error)
+	UpdateProduct(ctx context.Context, id int, product models.Product) error
+	PatchProduct(ctx context.Context, id int, patch models.ProductPatch) error
+	DeleteProduct(ctx context.Context, id int, version int) error
+	RestoreProduct(ctx context.Context, id int) error
+	PurgeProducts(ctx context.Context, deletedBefore time.Time) (int64, error)
+	SearchProducts(ctx context.Context, q string, opts ListOptions) (*SearchPage, error)
+	ExportProducts(ctx context.Context, opts ExportOptions, fn func(p *models.Product) error) error
+	ProductIDByName(ctx context.Context, name string, exceptID int) (int, error)
+
+	// WithTransaction executa fn com um ProductStore cujas operações fazem parte de uma
+	// única transação, confirmada se fn não retornar erro e desfeita caso contrário.
+	// Cada escrita feita em fn continua atômica: se falhar, apenas ela é desfeita e as
+	// anteriores continuam na transação. Outras escritas esperam a transação terminar
+	// (no SQLite e no backend em memória) ou seguem o isolamento do banco (PostgreSQL).
+	WithTransaction(ctx context.Context, fn func(store ProductStore) error) error
 }
 
-// SearchProductsByImage busca produtos com ou sem imagem
-func SearchProductsByImage(hasImage bool) ([]models.Product, error) {
-	db, err := db.OpenDB()
-	if err != nil {
-		log.Printf("Erro ao conectar ao banco de dados: %v", err)
-		return nil, err
-	}
-	defer db.Close()
-
-	var query string
-	if hasImage {
-		query = `SELECT id, name, price, description, category, image_url 
-				  FROM products WHERE image_url IS NOT NULL AND image_url != ''`
-	} else {
-		query = `SELECT id, name, price, description, category, image_url 
-				  FROM products WHERE image_url IS NULL OR image_url = ''`
-	}
-
-	rows, err := db.Query(query)
-	if err != nil {
-		log.Printf("Erro ao buscar produtos: %v", err)
-		return nil, err
-	}
-	defer rows.Close()
-
-	var products []models.Product
-	for rows.Next() {
-		var p models.Product
-		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Description, &p.Category, &p.ImageURL); err != nil {
-			log.Printf("Erro ao processar produto: %v", err)
-			return nil, err
-		}
-		products = append(products, p)
-	}
-
-	return products, nil
-}
\ No newline at end of file
+// Garante em tempo de compilação que os backends implementam ProductStore
+var (
+	_ ProductStore = (*SQLProductRepository)(nil)
+	_ ProductStore = (*MemoryProductRepository)(nil)
+)
@@ -0,0 +1,404 @@
+package repository
+
+import (
+	"braip/internal/filter"
+	"braip/internal/models"
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// As escritas em produtos inexistentes ou na lixeira retornam ErrProductNotFound,
+// em vez de não alterar nada e não informar o erro
+func TestWritesToMissingProduct(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store ProductStore) {
+		ctx := context.Background()
+		word := uniqueWord()
+		trashed := createTestProduct(t, store, models.Product{Name: "Boné " + word, Price: 1990, Description: "Boné"})
+		if err := store.DeleteProduct(ctx, trashed, 0); err != nil {
+			t.Fatal(err)
+		}
+
+		name := "Outro " + word
+		update := models.Product{Name: name, Price: 1, Currency: "BRL", Description: "x", Category: "Testes de integração"}
+		for _, id := range []int{1 << 30, trashed} {
+			if err := store.UpdateProduct(ctx, id, update); !errors.Is(err, ErrProductNotFound) {
+				t.Errorf("UpdateProduct(%d): %v; esperado ErrProductNotFound", id, err)
+			}
+			if err := store.PatchProduct(ctx, id, models.ProductPatch{Name: &name}); !errors.Is(err, ErrProductNotFound) {
+				t.Errorf("PatchProduct(%d): %v; esperado ErrProductNotFound", id, err)
+			}
+			if err := store.DeleteProduct(ctx, id, 0); !errors.Is(err, ErrProductNotFound) {
+				t.Errorf("DeleteProduct(%d): %v; esperado ErrProductNotFound", id, err)
+			}
+		}
+		if id, err := store.ProductIDByName(ctx, name, 0); err != nil || id != 0 {
+			t.Errorf("escrita recusada gravou o produto %d (%v)", id, err)
+		}
+	})
+}
+
+// ImportProduct cria o produto com o ID informado (PUT com If-None-Match: * e upsert)
+// sem reutilizar IDs e sem atrapalhar os IDs gerados depois
+func TestImportProductWithID(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store ProductStore) {
+		ctx := context.Background()
+		word := uniqueWord()
+		existing := createTestProduct(t, store, models.Product{Name: "Meia " + word, Price: 990, Description: "Meia"})
+
+		id := existing + 1000
+		product := models.Product{ID: id, Name: "Cinto " + word, Price: 4990, Currency: "BRL", Description: "Couro", Category: "Testes de integração"}
+		inserted, err := store.ImportProduct(ctx, product)
+		if err != nil || !inserted {
+			t.Fatalf("ImportProduct(%d) = %v, %v; esperado inserido", id, inserted, err)
+		}
+		t.Cleanup(func() { store.DeleteProduct(context.Background(), id, 0) })
+
+		got, err := store.GetProductByID(ctx, id)
+		if err != nil || got == nil || got.Name != product.Name || got.Version != 1 {
+			t.Fatalf("GetProductByID(%d) = %+v, %v", id, got, err)
+		}
+
+		// O ID já usado não é substituído
+		product.Name = "Cinto largo " + word
+		for _, taken := range []int{id, existing} {
+			product.ID = taken
+			if inserted, err := store.ImportProduct(ctx, product); err != nil || inserted {
+				t.Errorf("ImportProduct(%d) com ID em uso = %v, %v; esperado não inserido", taken, inserted, err)
+			}
+		}
+		if got, _ := store.GetProductByID(ctx, id); got == nil || got.Name != "Cinto "+word {
+			t.Errorf("produto substituído pelo ImportProduct: %+v", got)
+		}
+
+		// Os próximos IDs gerados continuam depois do maior ID
+		next := createTestProduct(t, store, models.Product{Name: "Gravata " + word, Price: 2990, Description: "Seda"})
+		if next <= id {
+			t.Errorf("ID gerado depois da importação = %d; esperado maior que %d", next, id)
+		}
+	})
+}
+
+// Cada escrita incrementa a versão; com a versão esperada informada, a escrita só
+// acontece se o produto ainda estiver nela
+func TestVersionMismatch(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store ProductStore) {
+		ctx := context.Background()
+		word := uniqueWord()
+		id := createTestProduct(t, store, models.Product{Name: "Boné " + word, Price: 1990, Description: "Boné"})
+
+		update := models.Product{Name: "Boné azul " + word, Price: 2990, Currency: "BRL", Description: "Boné", Category: "Testes de integração", Version: 1}
+		if err := store.UpdateProduct(ctx, id, update); err != nil {
+			t.Fatal(err)
+		}
+		price := 990
+		if err := store.PatchProduct(ctx, id, models.ProductPatch{Price: &price, Version: 2}); err != nil {
+			t.Fatal(err)
+		}
+		// Sem versão, a escrita vale para qualquer versão
+		if err := store.PatchProduct(ctx, id, models.ProductPatch{Price: &price}); err != nil {
+			t.Fatal(err)
+		}
+
+		stalePrice := 1
+		update.Version = 3
+		if err := store.UpdateProduct(ctx, id, update); !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("UpdateProduct com versão antiga: %v; esperado ErrVersionMismatch", err)
+		}
+		if err := store.PatchProduct(ctx, id, models.ProductPatch{Price: &stalePrice, Version: 3}); !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("PatchProduct com versão antiga: %v; esperado ErrVersionMismatch", err)
+		}
+		if err := store.DeleteProduct(ctx, id, 5); !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("DeleteProduct com versão futura: %v; esperado ErrVersionMismatch", err)
+		}
+
+		got, err := store.GetProductByID(ctx, id)
+		if err != nil || got == nil {
+			t.Fatalf("GetProductByID(%d) = %v, %v", id, got, err)
+		}
+		if got.Version != 4 || got.Price != 990 || got.Name != "Boné azul "+word {
+			t.Errorf("produto depois das escritas: %+v", got)
+		}
+		if err := store.DeleteProduct(ctx, id, 4); err != nil {
+			t.Errorf("DeleteProduct na versão atual: %v", err)
+		}
+	})
+}
+
+// tick espera o relógio avançar e retorna o instante atual, para separar as escritas no tempo
+func tick() time.Time {
+	time.Sleep(2 * time.Millisecond)
+	return now()
+}
+
+// created_at é gravado na criação e updated_at em cada alteração, sempre em UTC
+func TestTimestamps(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store ProductStore) {
+		ctx := context.Background()
+		word := uniqueWord()
+		get := func(id int) *models.Product {
+			t.Helper()
+			p, err := store.GetProductByID(ctx, id)
+			if err != nil || p == nil {
+				t.Fatalf("GetProductByID(%d) = %v, %v", id, p, err)
+			}
+			return p
+		}
+
+		start := tick()
+		id := createTestProduct(t, store, models.Product{Name: "Boné " + word, Price: 1990, Description: "Boné"})
+		other := createTestProduct(t, store, models.Product{Name: "Meia " + word, Price: 990, Description: "Meia"})
+		created := get(id)
+		if created.CreatedAt.Before(start) || !created.UpdatedAt.Equal(created.CreatedAt) || created.CreatedAt.Location() != time.UTC {
+			t.Errorf("produto criado: created_at %v, updated_at %v", created.CreatedAt, created.UpdatedAt)
+		}
+
+		writes := []struct {
+			name  string
+			write func() error
+		}{
+			{"UpdateProduct", func() error {
+				return store.UpdateProduct(ctx, id, models.Product{Name: "Boné azul " + word, Price: 2990, Currency: "BRL", Description: "Boné", Category: "Testes de integração"})
+			}},
+			{"PatchProduct", func() error {
+				price := 990
+				return store.PatchProduct(ctx, id, models.ProductPatch{Price: &price})
+			}},
+			{"DeleteProduct", func() error { return store.DeleteProduct(ctx, id, 0) }},
+			{"RestoreProduct", func() error { return store.RestoreProduct(ctx, id) }},
+		}
+		byID, err := filter.NewCondition("id", filter.Eq, strconv.Itoa(id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		previous := created.UpdatedAt
+		for _, w := range writes {
+			mark := tick()
+			if err := w.write(); err != nil {
+				t.Fatalf("%s: %v", w.name, err)
+			}
+			p, err := store.GetProducts(ctx, ListOptions{Trashed: w.name == "DeleteProduct", Filter: byID})
+			if err != nil || len(p.Products) != 1 {
+				t.Fatalf("%s: %+v, %v", w.name, p, err)
+			}
+			got := p.Products[0]
+			if !got.CreatedAt.Equal(created.CreatedAt) || got.UpdatedAt.Before(mark) || got.UpdatedAt.Location() != time.UTC {
+				t.Errorf("%s: created_at %v, updated_at %v; esperado created_at %v e updated_at depois de %v", w.name, got.CreatedAt, got.UpdatedAt, created.CreatedAt, mark)
+			}
+			previous = got.UpdatedAt
+		}
+
+		// Escritas recusadas não mudam updated_at
+		tick()
+		price := 1
+		if err := store.PatchProduct(ctx, id, models.ProductPatch{Price: &price, Version: 1}); !errors.Is(err, ErrVersionMismatch) {
+			t.Fatalf("PatchProduct com versão antiga: %v", err)
+		}
+		if got := get(id); !got.UpdatedAt.Equal(previous) {
+			t.Errorf("updated_at mudou com a escrita recusada: %v; esperado %v", got.UpdatedAt, previous)
+		}
+
+		// updated_at >= instante: apenas os produtos alterados a partir dele (sincronização incremental)
+		since, err := filter.NewCondition("updated_at", filter.Gte, previous.Format(time.RFC3339Nano))
+		if err != nil {
+			t.Fatal(err)
+		}
+		page, err := store.GetProducts(ctx, ListOptions{Filter: filter.Combine(since, nameFilter(t, word))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := productIDs(page.Products); !equalIDs(ids, []int{id}) {
+			t.Errorf("alterados desde %v: %v; esperado [%d] (sem %d)", previous, ids, id, other)
+		}
+	})
+}
+
+// Produtos excluídos vão para a lixeira: somem das leituras e buscas até serem restaurados
+func TestSoftDelete(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store ProductStore) {
+		ctx := context.Background()
+		word := uniqueWord()
+		trashed := createTestProduct(t, store, models.Product{Name: "Boné " + word, Price: 1990, Description: "Boné"})
+		kept := createTestProduct(t, store, models.Product{Name: "Meia " + word, Price: 990, Description: "Meia"})
+		if err := store.DeleteProduct(ctx, trashed, 0); err != nil {
+			t.Fatal(err)
+		}
+
+		if p, err := store.GetProductByID(ctx, trashed); err != nil || p != nil {
+			t.Errorf("GetProductByID do produto na lixeira = %+v, %v", p, err)
+		}
+		page, err := store.GetProducts(ctx, ListOptions{Filter: nameFilter(t, word)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := productIDs(page.Products); !equalIDs(ids, []int{kept}) || page.Total != 1 {
+			t.Errorf("GetProducts: %v (total %d); esperado [%d]", ids, page.Total, kept)
+		}
+		if ids := searchIDs(t, store, "boné "+word, ListOptions{}); len(ids) != 0 {
+			t.Errorf("SearchProducts encontrou o produto na lixeira: %v", ids)
+		}
+		var exported []int
+		err = store.ExportProducts(ctx, ExportOptions{Filter: nameFilter(t, word)}, func(p *models.Product) error {
+			exported = append(exported, p.ID)
+			return nil
+		})
+		if err != nil || !equalIDs(exported, []int{kept}) {
+			t.Errorf("ExportProducts: %v, %v; esperado [%d]", exported, err, kept)
+		}
+
+		// Na lixeira, com a data da exclusão
+		trash, err := store.GetProducts(ctx, ListOptions{Trashed: true, Filter: nameFilter(t, word)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trash.Products) != 1 || trash.Products[0].ID != trashed || trash.Products[0].DeletedAt == nil {
+			t.Fatalf("lixeira: %+v; esperado o produto %d", trash.Products, trashed)
+		}
+
+		for _, id := range []int{kept, 1 << 30} {
+			if err := store.RestoreProduct(ctx, id); !errors.Is(err, ErrProductNotInTrash) {
+				t.Errorf("RestoreProduct(%d) fora da lixeira: %v; esperado ErrProductNotInTrash", id, err)
+			}
+		}
+		if err := store.RestoreProduct(ctx, trashed); err != nil {
+			t.Fatal(err)
+		}
+		if p, err := store.GetProductByID(ctx, trashed); err != nil || p == nil || p.DeletedAt != nil {
+			t.Errorf("produto restaurado = %+v, %v", p, err)
+		}
+		if ids := searchIDs(t, store, "boné "+word, ListOptions{}); !equalIDs(ids, []int{trashed}) {
+			t.Errorf("busca depois da restauração: %v; esperado [%d]", ids, trashed)
+		}
+	})
+}
+
+// PurgeProducts remove de vez apenas os produtos que estão na lixeira desde antes da data
+func TestPurgeProducts(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store ProductStore) {
+		ctx := context.Background()
+		word := uniqueWord()
+		old := createTestProduct(t, store, models.Product{Name: "Boné " + word, Price: 1990, Description: "Boné"})
+		recent := createTestProduct(t, store, models.Product{Name: "Meia " + word, Price: 990, Description: "Meia"})
+		kept := createTestProduct(t, store, models.Product{Name: "Cinto " + word, Price: 4990, Description: "Cinto"})
+
+		if err := store.DeleteProduct(ctx, old, 0); err != nil {
+			t.Fatal(err)
+		}
+		cutoff := tick()
+		if err := store.DeleteProduct(ctx, recent, 0); err != nil {
+			t.Fatal(err)
+		}
+
+		// O banco PostgreSQL é compartilhado: a lixeira pode ter produtos de outras
+		// execuções, então a contagem só é conferida como mínimo
+		purged, err := store.PurgeProducts(ctx, cutoff)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if purged < 1 {
+			t.Errorf("PurgeProducts = %d; esperado ao menos 1", purged)
+		}
+
+		trash, err := store.GetProducts(ctx, ListOptions{Trashed: true, Filter: nameFilter(t, word)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := productIDs(trash.Products); !equalIDs(ids, []int{recent}) {
+			t.Errorf("lixeira depois da remoção: %v; esperado [%d]", ids, recent)
+		}
+		if err := store.RestoreProduct(ctx, old); !errors.Is(err, ErrProductNotInTrash) {
+			t.Errorf("RestoreProduct do produto removido: %v; esperado ErrProductNotInTrash", err)
+		}
+		if p, err := store.GetProductByID(ctx, kept); err != nil || p == nil {
+			t.Errorf("produto fora da lixeira removido: %+v, %v", p, err)
+		}
+
+		// O histórico de preços do produto removido continua disponível
+		history, err := store.GetPriceHistory(ctx, old)
+		if err != nil || len(history) == 0 {
+			t.Errorf("histórico de preços do produto removido: %v, %v", history, err)
+		}
+	})
+}
+
+// Dentro de WithTransaction, uma escrita que falha é desfeita sozinha (savepoint) e as
+// demais são confirmadas juntas; um erro de fn desfaz a transação inteira, auditoria inclusive
+func TestWithTransaction(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store ProductStore) {
+		ctx := context.Background()
+		word := uniqueWord()
+		existing := createTestProduct(t, store, models.Product{Name: "Boné " + word, Price: 1990, Description: "Boné"})
+
+		var created int
+		err := store.WithTransaction(ctx, func(tx ProductStore) error {
+			id, err := tx.CreateProduct(ctx, models.Product{Name: "Meia " + word, Price: 990, Currency: "BRL", Description: "Meia", Category: "Testes de integração"})
+			if err != nil {
+				return err
+			}
+			created = int(id)
+
+			// Nome repetido e versão antiga falham sem desfazer o que veio antes
+			var inUse *NameInUseError
+			if _, err := tx.CreateProduct(ctx, models.Product{Name: "MEIA " + word, Price: 1, Currency: "BRL", Description: "x", Category: "Testes de integração"}); !errors.As(err, &inUse) {
+				t.Errorf("nome repetido na transação: %v; esperado NameInUseError", err)
+			}
+			price := 2990
+			if err := tx.PatchProduct(ctx, existing, models.ProductPatch{Price: &price, Version: 5}); !errors.Is(err, ErrVersionMismatch) {
+				t.Errorf("versão antiga na transação: %v; esperado ErrVersionMismatch", err)
+			}
+			if err := tx.PatchProduct(ctx, existing, models.ProductPatch{Price: &price}); err != nil {
+				return err
+			}
+
+			// A transação lê as próprias escritas
+			if p, err := tx.GetProductByID(ctx, existing); err != nil || p == nil || p.Price != 2990 {
+				t.Errorf("leitura dentro da transação: %+v, %v", p, err)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { store.DeleteProduct(context.Background(), created, 0) })
+
+		page, err := store.GetProducts(ctx, ListOptions{Filter: nameFilter(t, word), Sort: []SortField{{Field: "id"}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := productIDs(page.Products); !equalIDs(ids, []int{existing, created}) || page.Products[0].Price != 2990 || page.Products[0].Version != 2 {
+			t.Errorf("depois da transação confirmada: %+v", page.Products)
+		}
+
+		// Um erro de fn desfaz todas as escritas da transação
+		rollback := errors.New("desfazer")
+		var discarded int
+		err = store.WithTransaction(ctx, func(tx ProductStore) error {
+			id, err := tx.CreateProduct(ctx, models.Product{Name: "Cinto " + word, Price: 4990, Currency: "BRL", Description: "Cinto", Category: "Testes de integração"})
+			if err != nil {
+				return err
+			}
+			discarded = int(id)
+			if err := tx.DeleteProduct(ctx, existing, 0); err != nil {
+				return err
+			}
+			return rollback
+		})
+		if !errors.Is(err, rollback) {
+			t.Fatalf("WithTransaction = %v; esperado o erro de fn", err)
+		}
+		if p, err := store.GetProductByID(ctx, discarded); err != nil || p != nil {
+			t.Errorf("produto criado na transação desfeita: %+v, %v", p, err)
+		}
+		if p, err := store.GetProductByID(ctx, existing); err != nil || p == nil || p.Version != 2 {
+			t.Errorf("produto removido na transação desfeita: %+v, %v", p, err)
+		}
+		if actions := auditActions(t, store, AuditOptions{ProductID: discarded}); len(actions) != 0 {
+			t.Errorf("auditoria da transação desfeita: %v", actions)
+		}
+		if id, err := store.ProductIDByName(ctx, "Cinto "+word, 0); err != nil || id != 0 {
+			t.Errorf("nome da transação desfeita em uso pelo produto %d (%v)", id, err)
+		}
+	})
+}
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"braip/internal/database"
+	"braip/internal/filter"
+	"braip/internal/models"
+	"braip/internal/textutil"
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Marcadores usados para destacar os termos encontrados na busca textual
+const (
+	highlightStart = "<mark>"
+	highlightEnd   = "</mark>"
+)
+
+// SearchResult é um produto encontrado pela busca textual
+type SearchResult struct {
+	Product              models.Product
+	Score                float64 // Relevância: quanto maior, mais relevante
+	NameHighlight        string  // Nome com os termos encontrados destacados
+	DescriptionHighlight string  // Trecho da descrição com os termos encontrados destacados
+}
+
+// SearchPage é uma página de resultados da busca textual
+type SearchPage struct {
+	Results []SearchResult
+	Total   int
+}
+
+// searchTerms extrai as palavras da busca, sem acentos e em minúsculas.
+// Apenas letras e dígitos são mantidos, o que impede o uso da sintaxe do motor de busca.
+func searchTerms(q string) []string {
+	return textutil.Words(textutil.Fold(q))
+}
+
+// SearchProducts faz a busca textual em nome e descrição, ordenando por relevância.
+// Cada termo é buscado como prefixo ("cam" encontra "camisa") e todos precisam aparecer.
+// O filtro, o limit e o offset de opts também são aplicados.
+func (r *SQLProductRepository) SearchProducts(ctx context.Context, q string, opts ListOptions) (*SearchPage, error) {
+	opts = opts.normalize()
+	terms := searchTerms(q)
+	if len(terms) == 0 {
+		return &SearchPage{}, nil
+	}
+
+	from, where, score, args, err := r.searchSource(terms, opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &SearchPage{}
+	countQuery := "SELECT COUNT(*) FROM " + from + whereClause(where)
+	if err := r.db.QueryRowContext(ctx, r.dialect.rebind(countQuery), args...).Scan(&page.Total); err != nil {
+		log.Printf("Erro ao contar resultados da busca: %v", err)
+		return nil, err
+	}
+
+	var selectQuery string
+	if r.dialect.name == db.DriverPostgres {
+		selectQuery = "SELECT " + productColumns + ", " + score + " AS score," +
+			" ts_headline('braip_unaccent', products.name, query, 'HighlightAll=true, StartSel=" + highlightStart + ", StopSel=" + highlightEnd + "')," +
+			" ts_headline('braip_unaccent', products.description, query, 'MaxWords=16, MinWords=8, StartSel=" + highlightStart + ", StopSel=" + highlightEnd + "')"
+	} else {
+		selectQuery = "SELECT " + productColumns + ", " + score + " AS score, name_highlight, description_highlight"
+	}
+	query := selectQuery + " FROM " + from + whereClause(where) + " ORDER BY score DESC, products.id LIMIT ? OFFSET ?"
+	args = append(args, opts.Limit, opts.Offset)
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		log.Printf("Erro ao buscar produtos: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var res SearchResult
+		dest := append(productScanDest(&res.Product), &res.Score, &res.NameHighlight, &res.DescriptionHighlight)
+		if err := rows.Scan(dest...); err != nil {
+			log.Printf("Erro ao processar resultado da busca: %v", err)
+			return nil, err
+		}
+		page.Results = append(page.Results, res)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Erro ao percorrer resultados da busca: %v", err)
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// searchSource monta a origem (FROM), as condições e a expressão da relevância da busca
+// textual pelos termos, com o filtro. Cada banco tem a sua implementação de busca
+// textual: FTS5 no SQLite e tsvector/tsquery no PostgreSQL.
+func (r *SQLProductRepository) searchSource(terms []string, expr filter.Expr) (from string, where []string, score string, args []interface{}, err error) {
+	where = []string{"products.deleted_at IS NULL"}
+	if r.dialect.name == db.DriverPostgres {
+		from = productsFrom + ", to_tsquery('braip_unaccent', ?) AS query"
+		where = append(where, "search_vector @@ query")
+		score = "ts_rank(search_vector, query)"
+		prefixes := make([]string, len(terms))
+		for i, term := range terms {
+			prefixes[i] = term + ":*"
+		}
+		args = append(args, strings.Join(prefixes, " & "))
+	} else {
+		from = `(SELECT rowid, bm25(products_fts, 10.0, 1.0) AS rank,
+				highlight(products_fts, 0, '` + highlightStart + `', '` + highlightEnd + `') AS name_highlight,
+				snippet(products_fts, 1, '` + highlightStart + `', '` + highlightEnd + `', '…', 16) AS description_highlight
+			FROM products_fts WHERE products_fts MATCH ?) AS fts
+			JOIN products ON products.id = fts.rowid
+			LEFT JOIN categories ON categories.id = products.category_id`
+		// O bm25 do FTS5 é negativo (menor = mais relevante): invertido para que maior seja melhor
+		score = "-rank"
+		prefixes := make([]string, len(terms))
+		for i, term := range terms {
+			prefixes[i] = `"` + term + `"*`
+		}
+		args = append(args, strings.Join(prefixes, " "))
+	}
+
+	if expr != nil {
+		condition, filterArgs, err := r.dialect.compileFilter(expr)
+		if err != nil {
+			return "", nil, "", nil, err
+		}
+		where = append(where, condition)
+		args = append(args, filterArgs...)
+	}
+	return from, where, score, args, nil
+}
+
+// SearchProducts faz a busca textual em memória, com a mesma semântica do banco:
+// todos os termos precisam aparecer (como prefixo de alguma palavra) no nome ou na descrição
+func (r *MemoryProductRepository) SearchProducts(ctx context.Context, q string, opts ListOptions) (*SearchPage, error) {
+	opts = opts.normalize()
+	results := r.search(searchTerms(q), opts.Filter)
+
+	page := &SearchPage{Total: len(results)}
+	if opts.Offset < len(results) {
+		results = results[opts.Offset:]
+		if len(results) > opts.Limit {
+			results = results[:opts.Limit]
+		}
+		page.Results = results
+	}
+	return page, nil
+}
+
+// search retorna todos os produtos fora da lixeira que satisfazem a busca e o filtro,
+// dos mais relevantes para os menos relevantes
+func (r *MemoryProductRepository) search(terms []string, expr filter.Expr) []SearchResult {
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var results []SearchResult
+	for _, p := range r.filter(func(p models.Product) bool { return p.DeletedAt == nil && matchFilter(expr, &p) }) {
+		nameHighlight, nameHits, nameTerms := highlightTerms(p.Name, terms)
+		descriptionHighlight, descriptionHits, descriptionTerms := highlightTerms(p.Description, terms)
+
+		// Todos os termos precisam aparecer em pelo menos um dos campos
+		found := true
+		for _, term := range terms {
+			if !nameTerms[term] && !descriptionTerms[term] {
+				found = false
+				break
+			}
+		}
+		if !found {
+			continue
+		}
+
+		results = append(results, SearchResult{
+			Product:              p,
+			Score:                float64(10*nameHits + descriptionHits), // Nome pesa mais que a descrição
+			NameHighlight:        nameHighlight,
+			DescriptionHighlight: descriptionHighlight,
+		})
+	}
+
+	// Mais relevantes primeiro; empates pelo ID
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Product.ID < results[j].Product.ID
+	})
+	return results
+}
+
+// highlightTerms destaca as palavras do texto que começam com algum dos termos.
+// Retorna o texto destacado, o número de palavras destacadas e os termos encontrados.
+func highlightTerms(text string, terms []string) (string, int, map[string]bool) {
+	found := make(map[string]bool)
+	hits := 0
+
+	var b strings.Builder
+	word := []rune{}
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		w := string(word)
+		folded := textutil.Fold(w)
+		matched := false
+		for _, term := range terms {
+			if strings.HasPrefix(folded, term) {
+				found[term] = true
+				matched = true
+			}
+		}
+		if matched {
+			hits++
+			b.WriteString(highlightStart + w + highlightEnd)
+		} else {
+			b.WriteString(w)
+		}
+		word = word[:0]
+	}
+
+	for _, c := range text {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) {
+			word = append(word, c)
+			continue
+		}
+		flush()
+		b.WriteRune(c)
+	}
+	flush()
+
+	return b.String(), hits, found
+}
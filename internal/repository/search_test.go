@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"braip/internal/models"
+	"context"
+	"strings"
+	"testing"
+)
+
+// searchIDs retorna os IDs dos resultados da busca, na ordem
+func searchIDs(t *testing.T, store ProductStore, q string, opts ListOptions) []int {
+	t.Helper()
+	page, err := store.SearchProducts(context.Background(), q, opts)
+	if err != nil {
+		t.Fatalf("SearchProducts(%q): %v", q, err)
+	}
+	ids := make([]int, len(page.Results))
+	for i, res := range page.Results {
+		ids[i] = res.Product.ID
+		if i > 0 && res.Score > page.Results[i-1].Score {
+			t.Errorf("SearchProducts(%q): relevâncias fora de ordem", q)
+		}
+	}
+	return ids
+}
+
+// O nome pesa mais que a descrição na relevância, em todos os backends
+func TestSearchRanking(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store ProductStore) {
+		word := uniqueWord()
+		inDescription := createTestProduct(t, store, models.Product{Name: "Casaco " + word, Price: 2230, Description: "Combina com a jaqueta jeans"})
+		inName := createTestProduct(t, store, models.Product{Name: "Jaqueta " + word, Price: 5599, Description: "Couro legítimo"})
+		inBoth := createTestProduct(t, store, models.Product{Name: "Jaqueta Jeans " + word, Price: 9990, Description: "Jaqueta de algodão"})
+		camisa := createTestProduct(t, store, models.Product{Name: "Camisa " + word, Price: 1990, Description: "Algodão"})
+
+		// Prefixos, sem acentos e sem diferenciar maiúsculas: os termos do nome vêm
+		// antes dos que só aparecem na descrição
+		for _, q := range []string{"jaqueta " + word, "JAQ " + word} {
+			ids := searchIDs(t, store, q, ListOptions{})
+			if len(ids) != 3 || ids[2] != inDescription || !(ids[0] == inName && ids[1] == inBoth || ids[0] == inBoth && ids[1] == inName) {
+				t.Errorf("busca %q: %v; esperado %d e %d antes de %d", q, ids, inName, inBoth, inDescription)
+			}
+		}
+
+		// Todos os termos precisam aparecer, no nome ou na descrição
+		tests := []struct {
+			q    string
+			want []int
+		}{
+			{"algodão jaq " + word, []int{inBoth}},
+			{"camisa algodao " + word, []int{camisa}},
+			{"jeans couro " + word, []int{}},
+			{"inexistente " + word, []int{}},
+		}
+		for _, tt := range tests {
+			if ids := searchIDs(t, store, tt.q, ListOptions{}); !equalIDs(ids, tt.want) {
+				t.Errorf("busca %q: %v; esperado %v", tt.q, ids, tt.want)
+			}
+		}
+
+		// limit e offset paginam sem mudar o total
+		all := searchIDs(t, store, "jaqueta "+word, ListOptions{})
+		page, err := store.SearchProducts(context.Background(), "jaqueta "+word, ListOptions{Limit: 1, Offset: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != 3 || len(page.Results) != 1 || page.Results[0].Product.ID != all[1] {
+			t.Errorf("segunda página da busca: total %d, %d resultados", page.Total, len(page.Results))
+		}
+
+		// Os termos encontrados vêm destacados
+		page, err = store.SearchProducts(context.Background(), "algodão jaq "+word, ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Results) != 1 ||
+			!strings.Contains(page.Results[0].NameHighlight, highlightStart+"Jaqueta"+highlightEnd) ||
+			!strings.Contains(page.Results[0].DescriptionHighlight, highlightStart+"algodão"+highlightEnd) {
+			t.Errorf("destaques: %+v", page.Results)
+		}
+	})
+}
+
+// O índice de busca acompanha as alterações e a lixeira
+func TestSearchFollowsWrites(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store ProductStore) {
+		ctx := context.Background()
+		word := uniqueWord()
+		id := createTestProduct(t, store, models.Product{Name: "Camisa " + word, Price: 1990, Description: "Algodão"})
+
+		name := "Regata " + word
+		if err := store.PatchProduct(ctx, id, models.ProductPatch{Name: &name}); err != nil {
+			t.Fatal(err)
+		}
+		if ids := searchIDs(t, store, "camisa "+word, ListOptions{}); len(ids) != 0 {
+			t.Errorf("nome antigo ainda encontrado: %v", ids)
+		}
+		if ids := searchIDs(t, store, "regata "+word, ListOptions{}); !equalIDs(ids, []int{id}) {
+			t.Errorf("nome novo: %v; esperado [%d]", ids, id)
+		}
+
+		if err := store.DeleteProduct(ctx, id, 0); err != nil {
+			t.Fatal(err)
+		}
+		if ids := searchIDs(t, store, "regata "+word, ListOptions{}); len(ids) != 0 {
+			t.Errorf("produto na lixeira encontrado: %v", ids)
+		}
+		if err := store.RestoreProduct(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+		if ids := searchIDs(t, store, "regata "+word, ListOptions{}); !equalIDs(ids, []int{id}) {
+			t.Errorf("produto restaurado: %v; esperado [%d]", ids, id)
+		}
+	})
+}
+
+// A sintaxe do motor de busca não passa para a consulta
+func TestSearchIgnoresEngineSyntax(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store ProductStore) {
+		word := uniqueWord()
+		id := createTestProduct(t, store, models.Product{Name: "Camisa " + word, Price: 1990, Description: "Algodão"})
+
+		// Operadores viram termos comuns: "OR", "NEAR", "name" e "x" também precisam aparecer
+		tests := []struct {
+			q    string
+			want []int
+		}{
+			{`"camisa" ` + word + `*`, []int{id}},
+			{`camisa:* ` + word, []int{id}},
+			{`camisa & ` + word, []int{id}},
+			{`camisa OR ` + word, []int{}},
+			{`NEAR(camisa ` + word + `)`, []int{}},
+			{`name:camisa ` + word, []int{}},
+			{`camisa & ` + word + ` | !x`, []int{}},
+		}
+		for _, tt := range tests {
+			if ids := searchIDs(t, store, tt.q, ListOptions{}); !equalIDs(ids, tt.want) {
+				t.Errorf("busca %q: %v; esperado %v", tt.q, ids, tt.want)
+			}
+		}
+
+		if ids := searchIDs(t, store, `"*()`, ListOptions{}); len(ids) != 0 {
+			t.Errorf("busca sem palavras: %v", ids)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"braip/internal/audit"
+	"braip/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+)
+
+// productForAudit lê o produto, esteja ele na lixeira ou não, para registrá-lo na auditoria.
+// Retorna nil se o produto não existir.
+func (r *SQLProductRepository) productForAudit(ctx context.Context, q querier, id int) (*models.Product, error) {
+	row := q.QueryRowContext(ctx, r.dialect.rebind("SELECT "+productColumns+" FROM "+productsFrom+" WHERE products.id = ?"), id)
+	product, err := scanProduct(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Erro ao buscar produto para a auditoria: %v", err)
+		return nil, err
+	}
+	return product, nil
+}
+
+// recordChange registra na auditoria a alteração do produto feita na transação q e,
+// se o preço mudou, o novo preço no histórico. O estado depois da alteração é lido
+// na própria transação (exceto na remoção definitiva).
+func (r *SQLProductRepository) recordChange(ctx context.Context, q querier, action string, productID int, before *models.Product) error {
+	var after *models.Product
+	if action != audit.ActionPurge {
+		var err error
+		if after, err = r.productForAudit(ctx, q, productID); err != nil {
+			return err
+		}
+	}
+
+	entry, err := newAuditEntry(ctx, action, productID, before, after)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx, r.dialect.rebind(`
+		INSERT INTO product_audit (product_id, action, actor, source, request_id, before_data, after_data, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`),
+		entry.ProductID, entry.Action, entry.Actor, entry.Source, entry.RequestID,
+		nullJSON(entry.Before), nullJSON(entry.After), entry.CreatedAt,
+	)
+	if err != nil {
+		log.Printf("Erro ao registrar auditoria do produto %d: %v", productID, err)
+		return err
+	}
+
+	if priceChanged(before, after) {
+		return r.recordPrice(ctx, q, newPriceChange(before, after, entry.CreatedAt))
+	}
+	return nil
+}
+
+// GetAuditEntries retorna uma página da auditoria, do registro mais recente para o mais antigo
+func (r *SQLProductRepository) GetAuditEntries(ctx context.Context, opts AuditOptions) (*AuditPage, error) {
+	opts = opts.normalize()
+
+	var where []string
+	var args []interface{}
+	condition := func(c string, value interface{}) {
+		where = append(where, c)
+		args = append(args, value)
+	}
+	if opts.ProductID != 0 {
+		condition("product_id = ?", opts.ProductID)
+	}
+	if opts.Actor != "" {
+		condition("actor = ?", opts.Actor)
+	}
+	if opts.Source != "" {
+		condition("source = ?", opts.Source)
+	}
+	if opts.Action != "" {
+		condition("action = ?", opts.Action)
+	}
+	if !opts.Since.IsZero() {
+		condition("created_at >= ?", opts.Since.UTC())
+	}
+	if !opts.Until.IsZero() {
+		condition("created_at < ?", opts.Until.UTC())
+	}
+
+	page := &AuditPage{}
+	if err := r.db.QueryRowContext(ctx, r.dialect.rebind("SELECT COUNT(*) FROM product_audit"+whereClause(where)), args...).Scan(&page.Total); err != nil {
+		log.Printf("Erro ao contar registros de auditoria: %v", err)
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(
+		"SELECT id, product_id, action, actor, source, request_id, before_data, after_data, created_at FROM product_audit"+
+			whereClause(where)+" ORDER BY id DESC LIMIT ? OFFSET ?"),
+		append(args, opts.Limit, opts.Offset)...)
+	if err != nil {
+		log.Printf("Erro ao buscar registros de auditoria: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e models.AuditEntry
+		var before, after sql.NullString
+		if err := rows.Scan(&e.ID, &e.ProductID, &e.Action, &e.Actor, &e.Source, &e.RequestID, &before, &after, utcTime{&e.CreatedAt}); err != nil {
+			log.Printf("Erro ao processar registro de auditoria: %v", err)
+			return nil, err
+		}
+		e.Before, e.After = rawJSON(before), rawJSON(after)
+		page.Entries = append(page.Entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Erro ao percorrer registros de auditoria: %v", err)
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// nullJSON grava o JSON "null" como NULL
+func nullJSON(raw json.RawMessage) interface{} {
+	if string(raw) == "null" {
+		return nil
+	}
+	return string(raw)
+}
+
+// rawJSON converte uma coluna JSON opcional de volta (NULL vira null)
+func rawJSON(s sql.NullString) json.RawMessage {
+	if !s.Valid {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(s.String)
+}
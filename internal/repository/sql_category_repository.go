@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"braip/internal/models"
+	"braip/internal/textutil"
+	"context"
+	"database/sql"
+	"log"
+)
+
+// GetCategories retorna todas as categorias, ordenadas pelo nome
+func (r *SQLProductRepository) GetCategories(ctx context.Context) ([]models.Category, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, slug, name, parent_id FROM categories ORDER BY name, id")
+	if err != nil {
+		log.Printf("Erro ao buscar categorias: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []models.Category
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			log.Printf("Erro ao processar categoria: %v", err)
+			return nil, err
+		}
+		categories = append(categories, *category)
+	}
+	return categories, rows.Err()
+}
+
+// GetCategoryByID retorna uma categoria pelo ID ou nil se ela não existir
+func (r *SQLProductRepository) GetCategoryByID(ctx context.Context, id int) (*models.Category, error) {
+	row := r.db.QueryRowContext(ctx, r.dialect.rebind("SELECT id, slug, name, parent_id FROM categories WHERE id = ?"), id)
+	category, err := scanCategory(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Categoria não encontrada
+		}
+		log.Printf("Erro ao buscar categoria: %v", err)
+		return nil, err
+	}
+	return category, nil
+}
+
+// CreateCategory insere uma nova categoria, gerando o slug a partir do nome
+func (r *SQLProductRepository) CreateCategory(ctx context.Context, category models.Category) (int64, error) {
+	category.Name = textutil.Clean(category.Name)
+	slug := textutil.Slug(category.Name)
+	if err := r.checkCategory(ctx, 0, slug, category.ParentID); err != nil {
+		return 0, err
+	}
+
+	query := "INSERT INTO categories (slug, name, parent_id) VALUES (?, ?, ?)"
+	args := []interface{}{slug, category.Name, category.ParentID}
+
+	if r.dialect.returningID {
+		var id int64
+		if err := r.db.QueryRowContext(ctx, r.dialect.rebind(query+" RETURNING id"), args...).Scan(&id); err != nil {
+			log.Printf("Erro ao salvar categoria: %v", err)
+			return 0, err
+		}
+		return id, nil
+	}
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Erro ao salvar categoria: %v", err)
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// UpdateCategory atualiza o nome (e o slug) e a categoria pai de uma categoria
+func (r *SQLProductRepository) UpdateCategory(ctx context.Context, id int, category models.Category) error {
+	existing, err := r.GetCategoryByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrCategoryNotFound
+	}
+
+	category.Name = textutil.Clean(category.Name)
+	slug := textutil.Slug(category.Name)
+	if err := r.checkCategory(ctx, id, slug, category.ParentID); err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, r.dialect.rebind("UPDATE categories SET slug = ?, name = ?, parent_id = ? WHERE id = ?"),
+		slug, category.Name, category.ParentID, id)
+	if err != nil {
+		log.Printf("Erro ao atualizar categoria: %v", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteCategory remove uma categoria sem produtos nem subcategorias
+func (r *SQLProductRepository) DeleteCategory(ctx context.Context, id int) error {
+	existing, err := r.GetCategoryByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrCategoryNotFound
+	}
+
+	var inUse bool
+	err = r.db.QueryRowContext(ctx, r.dialect.rebind(`SELECT
+		EXISTS (SELECT 1 FROM products WHERE category_id = ?) OR
+		EXISTS (SELECT 1 FROM categories WHERE parent_id = ?)`), id, id).Scan(&inUse)
+	if err != nil {
+		log.Printf("Erro ao verificar o uso da categoria: %v", err)
+		return err
+	}
+	if inUse {
+		return ErrCategoryInUse
+	}
+
+	if _, err := r.db.ExecContext(ctx, r.dialect.rebind("DELETE FROM categories WHERE id = ?"), id); err != nil {
+		log.Printf("Erro ao excluir categoria: %v", err)
+		return err
+	}
+	return nil
+}
+
+// checkCategory valida o slug (não vazio e não usado por outra categoria) e a categoria pai
+func (r *SQLProductRepository) checkCategory(ctx context.Context, id int, slug string, parentID *int) error {
+	if slug == "" {
+		return ErrCategoryRequired
+	}
+
+	var other int
+	err := r.db.QueryRowContext(ctx, r.dialect.rebind("SELECT id FROM categories WHERE slug = ?"), slug).Scan(&other)
+	if err == nil && other != id {
+		return ErrCategoryExists
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	return checkParent(id, parentID, func(id int) (*int, bool, error) {
+		category, err := r.GetCategoryByID(ctx, id)
+		if err != nil || category == nil {
+			return nil, false, err
+		}
+		return category.ParentID, true, nil
+	})
+}
+
+// resolveCategory retorna o ID da categoria de um produto. Sem category_id, a
+// categoria é procurada pelo slug do nome informado e criada se ainda não existir.
+// Roda na transação (q) da escrita do produto.
+func (r *SQLProductRepository) resolveCategory(ctx context.Context, q querier, product models.Product) (int, error) {
+	if product.CategoryID != 0 {
+		var id int
+		err := q.QueryRowContext(ctx, r.dialect.rebind("SELECT id FROM categories WHERE id = ?"), product.CategoryID).Scan(&id)
+		if err == sql.ErrNoRows {
+			return 0, ErrCategoryNotFound
+		}
+		return id, err
+	}
+
+	slug := textutil.Slug(product.Category)
+	if slug == "" {
+		return 0, ErrCategoryRequired
+	}
+
+	// ON CONFLICT evita erro se outra requisição criar a mesma categoria ao mesmo tempo
+	_, err := q.ExecContext(ctx, r.dialect.rebind("INSERT INTO categories (slug, name) VALUES (?, ?) ON CONFLICT(slug) DO NOTHING"),
+		slug, textutil.Clean(product.Category))
+	if err != nil {
+		log.Printf("Erro ao criar categoria: %v", err)
+		return 0, err
+	}
+
+	var id int
+	if err := q.QueryRowContext(ctx, r.dialect.rebind("SELECT id FROM categories WHERE slug = ?"), slug).Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// scanCategory lê uma linha com as colunas id, slug, name e parent_id
+func scanCategory(s scanner) (*models.Category, error) {
+	var c models.Category
+	var parentID sql.NullInt64
+	if err := s.Scan(&c.ID, &c.Slug, &c.Name, &parentID); err != nil {
+		return nil, err
+	}
+	if parentID.Valid {
+		id := int(parentID.Int64)
+		c.ParentID = &id
+	}
+	return &c, nil
+}
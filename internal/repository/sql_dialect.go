@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"braip/internal/database"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// dialect reúne as diferenças de SQL entre os bancos suportados.
+// As consultas do repositório são escritas com placeholders "?" e
+// adaptadas para cada banco através de rebind.
+type dialect struct {
+	name string
+
+	// like é o operador usado nas buscas textuais sem diferenciar maiúsculas e minúsculas
+	like string
+
+	// returningID indica se o INSERT deve usar RETURNING id em vez de LastInsertId
+	returningID bool
+
+	// syncSequence é executado após inserir produtos com ID explícito, para que
+	// os próximos IDs gerados pelo banco não colidam com os importados
+	syncSequence string
+}
+
+var (
+	sqliteDialect = dialect{
+		name: db.DriverSQLite,
+		like: "LIKE", // O LIKE do SQLite já ignora maiúsculas e minúsculas (ASCII)
+	}
+
+	postgresDialect = dialect{
+		name:         db.DriverPostgres,
+		like:         "ILIKE",
+		returningID:  true,
+		syncSequence: "SELECT setval(pg_get_serial_sequence('products', 'id'), COALESCE(MAX(id), 1)) FROM products",
+	}
+)
+
+// dialectFor retorna o dialeto correspondente ao driver informado
+func dialectFor(driver string) (dialect, error) {
+	switch driver {
+	case db.DriverSQLite:
+		return sqliteDialect, nil
+	case db.DriverPostgres:
+		return postgresDialect, nil
+	default:
+		return dialect{}, fmt.Errorf("driver de banco de dados não suportado: %q", driver)
+	}
+}
+
+// rebind troca os placeholders "?" da consulta pelo formato esperado pelo banco
+func (d dialect) rebind(query string) string {
+	return db.Rebind(d.name, query)
+}
+
+// isUniqueViolation informa se o erro é a violação do índice único index, criado sobre
+// column. O PostgreSQL identifica o índice violado; o SQLite, a coluna (tabela.coluna).
+func (d dialect) isUniqueViolation(err error, index, column string) bool {
+	if err == nil {
+		return false
+	}
+	if d.name == db.DriverPostgres {
+		var pqErr *pq.Error
+		return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == index
+	}
+	return strings.Contains(err.Error(), "UNIQUE constraint failed: "+column)
+}
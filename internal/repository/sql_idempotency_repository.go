@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"braip/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+)
+
+// ReserveIdempotencyKey apaga as chaves expiradas e grava o registro se a chave
+// estiver livre; caso contrário, retorna o registro existente
+func (r *SQLProductRepository) ReserveIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) (*models.IdempotencyRecord, bool, error) {
+	if _, err := r.db.ExecContext(ctx, r.dialect.rebind("DELETE FROM idempotency_keys WHERE expires_at <= ?"), now()); err != nil {
+		log.Printf("Erro ao apagar chaves de idempotência expiradas: %v", err)
+		return nil, false, err
+	}
+
+	// A chave pode expirar entre o INSERT e o SELECT: nesse caso, tenta reservá-la de novo
+	for {
+		result, err := r.db.ExecContext(ctx, r.dialect.rebind(
+			"INSERT INTO idempotency_keys (idempotency_key, request_hash, created_at, expires_at) VALUES (?, ?, ?, ?) ON CONFLICT (idempotency_key) DO NOTHING"),
+			record.Key, record.RequestHash, record.CreatedAt, record.ExpiresAt)
+		if err != nil {
+			log.Printf("Erro ao reservar chave de idempotência: %v", err)
+			return nil, false, err
+		}
+		if inserted, err := result.RowsAffected(); err != nil || inserted == 1 {
+			return nil, err == nil, err
+		}
+
+		existing, err := r.getIdempotencyKey(ctx, record.Key)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		return existing, false, err
+	}
+}
+
+// getIdempotencyKey lê o registro da chave (sql.ErrNoRows se ela não existir ou tiver expirado)
+func (r *SQLProductRepository) getIdempotencyKey(ctx context.Context, key string) (*models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	var header sql.NullString
+	err := r.db.QueryRowContext(ctx, r.dialect.rebind(
+		"SELECT idempotency_key, request_hash, status, header, body, created_at, expires_at FROM idempotency_keys WHERE idempotency_key = ? AND expires_at > ?"),
+		key, now()).Scan(&record.Key, &record.RequestHash, &record.Status, &header, &record.Body, utcTime{&record.CreatedAt}, utcTime{&record.ExpiresAt})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Erro ao buscar chave de idempotência: %v", err)
+		}
+		return nil, err
+	}
+	if header.Valid {
+		if err := json.Unmarshal([]byte(header.String), &record.Header); err != nil {
+			return nil, err
+		}
+	}
+	return &record, nil
+}
+
+// CompleteIdempotencyKey guarda a resposta na chave reservada pela mesma requisição
+func (r *SQLProductRepository) CompleteIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) error {
+	header, err := json.Marshal(record.Header)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, r.dialect.rebind(
+		"UPDATE idempotency_keys SET status = ?, header = ?, body = ?, expires_at = ? WHERE idempotency_key = ? AND request_hash = ? AND status = 0"),
+		record.Status, string(header), record.Body, record.ExpiresAt, record.Key, record.RequestHash)
+	if err != nil {
+		log.Printf("Erro ao guardar resposta da chave de idempotência: %v", err)
+	}
+	return err
+}
+
+// ReleaseIdempotencyKey apaga a chave
+func (r *SQLProductRepository) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, r.dialect.rebind("DELETE FROM idempotency_keys WHERE idempotency_key = ?"), key)
+	if err != nil {
+		log.Printf("Erro ao liberar chave de idempotência: %v", err)
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"braip/internal/models"
+	"context"
+	"database/sql"
+	"log"
+)
+
+// recordPrice grava um ponto do histórico de preços na transação q
+func (r *SQLProductRepository) recordPrice(ctx context.Context, q querier, change models.PriceChange) error {
+	_, err := q.ExecContext(ctx, r.dialect.rebind("INSERT INTO price_history (product_id, price, currency, previous_price, changed_at) VALUES (?, ?, ?, ?, ?)"),
+		change.ProductID, change.Price, change.Currency, change.PreviousPrice, change.ChangedAt)
+	if err != nil {
+		log.Printf("Erro ao registrar histórico de preços do produto %d: %v", change.ProductID, err)
+	}
+	return err
+}
+
+// GetPriceHistory retorna os preços de um produto, do mais antigo para o mais recente
+func (r *SQLProductRepository) GetPriceHistory(ctx context.Context, productID int) ([]models.PriceChange, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(
+		"SELECT product_id, price, currency, previous_price, changed_at FROM price_history WHERE product_id = ? ORDER BY changed_at, id"), productID)
+	if err != nil {
+		log.Printf("Erro ao buscar histórico de preços: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []models.PriceChange
+	for rows.Next() {
+		var c models.PriceChange
+		var previous sql.NullInt64
+		if err := rows.Scan(&c.ProductID, &c.Price, &c.Currency, &previous, utcTime{&c.ChangedAt}); err != nil {
+			log.Printf("Erro ao processar histórico de preços: %v", err)
+			return nil, err
+		}
+		if previous.Valid {
+			p := int(previous.Int64)
+			c.PreviousPrice = &p
+		}
+		history = append(history, c)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Erro ao percorrer histórico de preços: %v", err)
+		return nil, err
+	}
+	return history, nil
+}
+
+// GetPriceDrops retorna os produtos com queda de preço de pelo menos opts.MinPercent desde opts.Since.
+// O preço no início do período é o último definido até opts.Since ou, para produtos
+// criados depois, o primeiro preço do histórico, considerando apenas a moeda atual do produto.
+// A queda mínima é fracionária (12.5%): o CAST evita que o PostgreSQL infira o
+// parâmetro como inteiro a partir de start.price.
+func (r *SQLProductRepository) GetPriceDrops(ctx context.Context, opts PriceDropOptions) ([]models.PriceDrop, error) {
+	opts = opts.normalize()
+
+	query := `
+		SELECT ` + productColumns + `, start.price
+		FROM ` + productsFrom + `
+		JOIN (
+			SELECT p.id, COALESCE(
+				(SELECT h.price FROM price_history h WHERE h.product_id = p.id AND h.currency = p.currency AND h.changed_at <= ? ORDER BY h.changed_at DESC, h.id DESC LIMIT 1),
+				(SELECT h.price FROM price_history h WHERE h.product_id = p.id AND h.currency = p.currency ORDER BY h.changed_at, h.id LIMIT 1)
+			) AS price
+			FROM products p
+			WHERE p.deleted_at IS NULL
+		) start ON start.id = products.id
+		WHERE start.price > products.price AND (start.price - products.price) * 100.0 >= CAST(? AS DOUBLE PRECISION) * start.price
+		ORDER BY (start.price - products.price) * 1.0 / start.price DESC, products.id
+		LIMIT ?`
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(query), opts.Since.UTC(), opts.MinPercent, opts.Limit)
+	if err != nil {
+		log.Printf("Erro ao buscar quedas de preço: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var drops []models.PriceDrop
+	for rows.Next() {
+		var d models.PriceDrop
+		dest := append(productScanDest(&d.Product), &d.PreviousPrice)
+		if err := rows.Scan(dest...); err != nil {
+			log.Printf("Erro ao processar queda de preço: %v", err)
+			return nil, err
+		}
+		d.DropPercent = dropPercent(d.PreviousPrice, d.Price)
+		drops = append(drops, d)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Erro ao percorrer quedas de preço: %v", err)
+		return nil, err
+	}
+	return drops, nil
+}
@@ -0,0 +1,577 @@
+package repository
+
+import (
+	"braip/internal/audit"
+	"braip/internal/models"
+	"braip/internal/textutil"
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+)
+
+// Colunas lidas em todas as consultas de produtos; o nome da categoria vem da tabela categories
+const productColumns = "products.id, products.name, products.price, products.currency, products.description, products.category_id, categories.name, products.image_url, products.version, products.created_at, products.updated_at, products.deleted_at"
+
+// Tabelas das consultas de produtos
+const productsFrom = "products LEFT JOIN categories ON categories.id = products.category_id"
+
+// SQLProductRepository implementa ProductStore sobre a tabela de produtos de um banco SQL.
+// Ele é criado uma única vez a partir do pool compartilhado (*sql.DB) e
+// reaproveita as conexões entre as requisições. As diferenças entre SQLite e
+// PostgreSQL ficam isoladas no dialeto.
+type SQLProductRepository struct {
+	db      querier // Onde as consultas rodam: o pool ou, dentro de WithTransaction, a transação
+	conn    *sql.DB // Pool de conexões, usado para abrir as transações
+	tx      *sql.Tx // Transação de WithTransaction; nil fora dela
+	dialect dialect
+}
+
+// NewSQLProductRepository cria um repositório para o driver informado (sqlite ou postgres)
+func NewSQLProductRepository(db *sql.DB, driver string) (*SQLProductRepository, error) {
+	d, err := dialectFor(driver)
+	if err != nil {
+		return nil, err
+	}
+	return &SQLProductRepository{db: db, conn: db, dialect: d}, nil
+}
+
+// GetProducts retorna uma página de produtos. O filtro, a paginação, a ordenação
+// e a seleção de campos são aplicados diretamente na consulta SQL.
+func (r *SQLProductRepository) GetProducts(ctx context.Context, opts ListOptions) (*ProductPage, error) {
+	opts = opts.normalize()
+
+	where := []string{"products.deleted_at IS NULL"}
+	if opts.Trashed {
+		where[0] = "products.deleted_at IS NOT NULL"
+	}
+	var args []interface{}
+
+	if opts.Filter != nil {
+		condition, filterArgs, err := r.dialect.compileFilter(opts.Filter)
+		if err != nil {
+			return nil, err
+		}
+		where = append(where, condition)
+		args = append(args, filterArgs...)
+	}
+
+	// Total de produtos que satisfazem o filtro, sem paginação
+	page := &ProductPage{}
+	countQuery := "SELECT COUNT(*) FROM " + productsFrom + whereClause(where)
+	if err := r.db.QueryRowContext(ctx, r.dialect.rebind(countQuery), args...).Scan(&page.Total); err != nil {
+		log.Printf("Erro ao contar produtos: %v", err)
+		return nil, err
+	}
+
+	// Paginação por cursor: continua logo após o último produto da página anterior
+	if opts.Cursor != "" {
+		values, err := decodeCursor(opts.Cursor, opts.Sort)
+		if err != nil {
+			return nil, err
+		}
+		condition, conditionArgs := keysetCondition(opts.Sort, values)
+		where = append(where, condition)
+		args = append(args, conditionArgs...)
+	}
+
+	columns := opts.columns()
+	selected := make([]string, len(columns))
+	for i, c := range columns {
+		selected[i] = productFields[c].column
+	}
+	query := "SELECT " + strings.Join(selected, ", ") + " FROM " + productsFrom + whereClause(where) +
+		" ORDER BY " + orderByClause(opts.Sort) + " LIMIT ? OFFSET ?"
+	// Busca um produto a mais para saber se existe uma próxima página
+	args = append(args, opts.Limit+1, opts.Offset)
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		log.Printf("Erro ao buscar produtos: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p models.Product
+		dest := make([]interface{}, len(columns))
+		for i, column := range columns {
+			dest[i] = productFields[column].scanDest(&p)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			log.Printf("Erro ao processar produto: %v", err)
+			return nil, err
+		}
+		page.Products = append(page.Products, p)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Erro ao percorrer produtos: %v", err)
+		return nil, err
+	}
+
+	if len(page.Products) > opts.Limit {
+		page.Products = page.Products[:opts.Limit]
+		page.NextCursor = encodeCursor(opts.Sort, &page.Products[opts.Limit-1])
+	}
+
+	return page, nil
+}
+
+// CreateProduct insere um novo produto no banco de dados, registrando a criação na auditoria
+func (r *SQLProductRepository) CreateProduct(ctx context.Context, product models.Product) (int64, error) {
+	var id int64
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := r.checkName(ctx, tx, product.Name, 0); err != nil {
+			return err
+		}
+		categoryID, err := r.resolveCategory(ctx, tx, product)
+		if err != nil {
+			return err
+		}
+
+		createdAt := now()
+		query := "INSERT INTO products (name, name_key, price, currency, description, category_id, image_url, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+		args := []interface{}{product.Name, textutil.Key(product.Name), product.Price, product.Currency, product.Description, categoryID, product.ImageURL, createdAt, createdAt}
+
+		// O PostgreSQL não implementa LastInsertId: o ID gerado vem do RETURNING
+		if r.dialect.returningID {
+			if err := tx.QueryRowContext(ctx, r.dialect.rebind(query+" RETURNING id"), args...).Scan(&id); err != nil {
+				log.Printf("Erro ao salvar produto: %v", err)
+				return r.writeError(err)
+			}
+		} else {
+			result, err := tx.ExecContext(ctx, query, args...)
+			if err != nil {
+				log.Printf("Erro ao salvar produto: %v", err)
+				return r.writeError(err)
+			}
+
+			// Captura o ID gerado
+			if id, err = result.LastInsertId(); err != nil {
+				log.Printf("Erro ao obter ID do produto: %v", err)
+				return err
+			}
+		}
+
+		return r.recordChange(ctx, tx, audit.ActionCreate, int(id), nil)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// ImportProduct insere um produto mantendo o ID informado, ignorando-o se o ID já existir
+func (r *SQLProductRepository) ImportProduct(ctx context.Context, product models.Product) (bool, error) {
+	var inserted int64
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := r.checkName(ctx, tx, product.Name, product.ID); err != nil {
+			return err
+		}
+		categoryID, err := r.resolveCategory(ctx, tx, product)
+		if err != nil {
+			return err
+		}
+
+		createdAt := now()
+		result, err := tx.ExecContext(ctx, r.dialect.rebind(`
+			INSERT INTO products (id, name, name_key, price, currency, description, category_id, image_url, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(id) DO NOTHING`), // Usa ON CONFLICT para evitar duplicação
+			product.ID, product.Name, textutil.Key(product.Name), product.Price, product.Currency, product.Description, categoryID, product.ImageURL, createdAt, createdAt,
+		)
+		if err != nil {
+			log.Printf("Erro ao importar produto %d: %v", product.ID, err)
+			return r.writeError(err)
+		}
+
+		if inserted, err = result.RowsAffected(); err != nil || inserted == 0 {
+			return err
+		}
+
+		if r.dialect.syncSequence != "" {
+			if _, err := tx.ExecContext(ctx, r.dialect.syncSequence); err != nil {
+				log.Printf("Erro ao sincronizar a sequência de IDs: %v", err)
+				return err
+			}
+		}
+
+		return r.recordChange(ctx, tx, audit.ActionCreate, product.ID, nil)
+	})
+	if err != nil {
+		return false, err
+	}
+	return inserted > 0, nil
+}
+
+// GetProductByID retorna um produto pelo ID; produtos na lixeira não são retornados
+func (r *SQLProductRepository) GetProductByID(ctx context.Context, id int) (*models.Product, error) {
+	row := r.db.QueryRowContext(ctx, r.dialect.rebind("SELECT "+productColumns+" FROM "+productsFrom+" WHERE products.id = ? AND products.deleted_at IS NULL"), id)
+	product, err := scanProduct(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Produto não encontrado
+		}
+		log.Printf("Erro ao buscar produto: %v", err)
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// UpdateProduct atualiza um produto no banco de dados; retorna ErrProductNotFound se ele não existir
+func (r *SQLProductRepository) UpdateProduct(ctx context.Context, id int, product models.Product) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := r.productForAudit(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err := r.checkName(ctx, tx, product.Name, id); err != nil {
+			return err
+		}
+
+		categoryID, err := r.resolveCategory(ctx, tx, product)
+		if err != nil {
+			return err
+		}
+
+		query, args := versioned("UPDATE products SET name = ?, name_key = ?, price = ?, currency = ?, description = ?, category_id = ?, image_url = ?, version = version + 1, updated_at = ? WHERE id = ? AND deleted_at IS NULL",
+			[]interface{}{product.Name, textutil.Key(product.Name), product.Price, product.Currency, product.Description, categoryID, product.ImageURL, now(), id}, product.Version)
+		result, err := tx.ExecContext(ctx, r.dialect.rebind(query), args...)
+		if err != nil {
+			log.Printf("Erro ao atualizar produto: %v", err)
+			return r.writeError(err)
+		}
+		if err := r.checkAffected(ctx, tx, result, id, product.Version); err != nil {
+			return err
+		}
+
+		return r.recordChange(ctx, tx, audit.ActionUpdate, id, before)
+	})
+}
+
+// PatchProduct altera apenas as colunas dos campos informados no patch;
+// retorna ErrProductNotFound se o produto não existir
+func (r *SQLProductRepository) PatchProduct(ctx context.Context, id int, patch models.ProductPatch) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		var set []string
+		var args []interface{}
+		column := func(name string, value interface{}) {
+			set = append(set, name+" = ?")
+			args = append(args, value)
+		}
+
+		if patch.Name != nil {
+			if err := r.checkName(ctx, tx, *patch.Name, id); err != nil {
+				return err
+			}
+			column("name", *patch.Name)
+			column("name_key", textutil.Key(*patch.Name))
+		}
+		if patch.Price != nil {
+			column("price", *patch.Price)
+		}
+		if patch.Currency != nil {
+			column("currency", *patch.Currency)
+		}
+		if patch.Description != nil {
+			column("description", *patch.Description)
+		}
+		if patch.CategoryID != nil || patch.Category != nil {
+			categoryID, err := r.resolveCategory(ctx, tx, patchCategory(patch))
+			if err != nil {
+				return err
+			}
+			column("category_id", categoryID)
+		}
+		if patch.ImageURL != nil {
+			column("image_url", *patch.ImageURL)
+		}
+
+		// Patch vazio: nada a alterar (nem a registrar), mas o produto precisa
+		// existir e estar na versão esperada
+		if len(set) == 0 {
+			return r.checkVersion(ctx, tx, id, patch.Version)
+		}
+
+		before, err := r.productForAudit(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		query, args := versioned("UPDATE products SET "+strings.Join(set, ", ")+", version = version + 1, updated_at = ? WHERE id = ? AND deleted_at IS NULL",
+			append(args, now(), id), patch.Version)
+		result, err := tx.ExecContext(ctx, r.dialect.rebind(query), args...)
+		if err != nil {
+			log.Printf("Erro ao atualizar produto: %v", err)
+			return r.writeError(err)
+		}
+		if err := r.checkAffected(ctx, tx, result, id, patch.Version); err != nil {
+			return err
+		}
+
+		return r.recordChange(ctx, tx, audit.ActionUpdate, id, before)
+	})
+}
+
+// patchCategory monta o produto usado para resolver a categoria informada no patch
+func patchCategory(patch models.ProductPatch) models.Product {
+	var product models.Product
+	if patch.CategoryID != nil {
+		product.CategoryID = *patch.CategoryID
+	} else {
+		product.Category = *patch.Category
+	}
+	return product
+}
+
+// DeleteProduct move um produto para a lixeira; retorna ErrProductNotFound se ele não existir
+func (r *SQLProductRepository) DeleteProduct(ctx context.Context, id int, version int) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := r.productForAudit(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		deletedAt := now()
+		query, args := versioned("UPDATE products SET deleted_at = ?, updated_at = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL",
+			[]interface{}{deletedAt, deletedAt, id}, version)
+		result, err := tx.ExecContext(ctx, r.dialect.rebind(query), args...)
+		if err != nil {
+			log.Printf("Erro ao excluir produto: %v", err)
+			return err
+		}
+		if err := r.checkAffected(ctx, tx, result, id, version); err != nil {
+			return err
+		}
+
+		return r.recordChange(ctx, tx, audit.ActionDelete, id, before)
+	})
+}
+
+// RestoreProduct tira um produto da lixeira; retorna ErrProductNotInTrash se ele não estiver nela
+// e *NameInUseError se outro produto passou a usar o mesmo nome
+func (r *SQLProductRepository) RestoreProduct(ctx context.Context, id int) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := r.productForAudit(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if before != nil && before.DeletedAt != nil {
+			if err := r.checkName(ctx, tx, before.Name, id); err != nil {
+				return err
+			}
+		}
+
+		result, err := tx.ExecContext(ctx,
+			r.dialect.rebind("UPDATE products SET deleted_at = NULL, updated_at = ?, version = version + 1 WHERE id = ? AND deleted_at IS NOT NULL"),
+			now(), id)
+		if err != nil {
+			log.Printf("Erro ao restaurar produto: %v", err)
+			return r.writeError(err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrProductNotInTrash
+		}
+
+		return r.recordChange(ctx, tx, audit.ActionRestore, id, before)
+	})
+}
+
+// PurgeProducts remove de vez os produtos que estão na lixeira desde antes de deletedBefore
+// e retorna quantos foram removidos. Cada remoção fica registrada na auditoria.
+func (r *SQLProductRepository) PurgeProducts(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var purged int64
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, r.dialect.rebind("SELECT "+productColumns+" FROM "+productsFrom+
+			" WHERE products.deleted_at IS NOT NULL AND products.deleted_at < ? ORDER BY products.id"), deletedBefore.UTC())
+		if err != nil {
+			log.Printf("Erro ao buscar produtos da lixeira: %v", err)
+			return err
+		}
+		var products []*models.Product
+		for rows.Next() {
+			product, err := scanProduct(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			products = append(products, product)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, product := range products {
+			if _, err := tx.ExecContext(ctx, r.dialect.rebind("DELETE FROM products WHERE id = ?"), product.ID); err != nil {
+				log.Printf("Erro ao esvaziar a lixeira: %v", err)
+				return err
+			}
+			if err := r.recordChange(ctx, tx, audit.ActionPurge, product.ID, product); err != nil {
+				return err
+			}
+		}
+		purged = int64(len(products))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
+
+// versioned restringe o comando à versão esperada do produto, quando informada
+func versioned(query string, args []interface{}, version int) (string, []interface{}) {
+	if version == 0 {
+		return query, args
+	}
+	return query + " AND version = ?", append(args, version)
+}
+
+// checkAffected explica por que o comando não alterou nenhuma linha:
+// o produto não existe (ErrProductNotFound) ou está em outra versão (ErrVersionMismatch)
+func (r *SQLProductRepository) checkAffected(ctx context.Context, q querier, result sql.Result, id int, version int) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+	if version == 0 {
+		return ErrProductNotFound
+	}
+	return r.checkVersion(ctx, q, id, version)
+}
+
+// checkVersion verifica se o produto existe (fora da lixeira) e, se version for
+// diferente de zero, se está nessa versão
+func (r *SQLProductRepository) checkVersion(ctx context.Context, q querier, id int, version int) error {
+	var current int
+	err := q.QueryRowContext(ctx, r.dialect.rebind("SELECT version FROM products WHERE id = ? AND deleted_at IS NULL"), id).Scan(&current)
+	if err == sql.ErrNoRows {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if version != 0 && current != version {
+		return ErrVersionMismatch
+	}
+	return nil
+}
+
+// ProductIDByName retorna o ID do produto fora da lixeira (e de ID diferente de exceptID)
+// que usa o nome, comparado sem diferenciar maiúsculas, acentos e espaços; 0 se nenhum usar
+func (r *SQLProductRepository) ProductIDByName(ctx context.Context, name string, exceptID int) (int, error) {
+	id, err := r.productIDByName(ctx, r.db, name, exceptID)
+	if err != nil {
+		log.Printf("Erro ao verificar nome do produto: %v", err)
+	}
+	return id, err
+}
+
+func (r *SQLProductRepository) productIDByName(ctx context.Context, q querier, name string, exceptID int) (int, error) {
+	var id int
+	err := q.QueryRowContext(ctx, r.dialect.rebind("SELECT id FROM products WHERE name_key = ? AND id <> ? AND deleted_at IS NULL"),
+		textutil.Key(name), exceptID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// checkName retorna *NameInUseError se outro produto fora da lixeira já usar o nome.
+// Roda na transação (q) da escrita; o índice único do banco continua garantindo a
+// regra quando duas escritas concorrentes passam pela verificação ao mesmo tempo.
+func (r *SQLProductRepository) checkName(ctx context.Context, q querier, name string, id int) error {
+	other, err := r.productIDByName(ctx, q, name, id)
+	if err != nil {
+		return err
+	}
+	if other != 0 {
+		return &NameInUseError{ProductID: other}
+	}
+	return nil
+}
+
+// writeError converte a violação do índice único de nomes em *NameInUseError
+func (r *SQLProductRepository) writeError(err error) error {
+	if r.dialect.isUniqueViolation(err, "products_name_key_idx", "products.name_key") {
+		return &NameInUseError{}
+	}
+	return err
+}
+
+// whereClause junta as condições com AND
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// orderByClause monta o ORDER BY a partir dos campos de ordenação
+func orderByClause(sort []SortField) string {
+	parts := make([]string, len(sort))
+	for i, s := range sort {
+		parts[i] = productFields[s.Field].column
+		if s.Desc {
+			parts[i] += " DESC"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// keysetCondition monta a condição que seleciona os produtos posteriores ao cursor.
+// Para a ordenação (a, -b, id) ela equivale a:
+//
+//	a > ? OR (a = ? AND b < ?) OR (a = ? AND b = ? AND id > ?)
+func keysetCondition(sort []SortField, values []interface{}) (string, []interface{}) {
+	var alternatives []string
+	var args []interface{}
+	for i, s := range sort {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, productFields[sort[j].Field].column+" = ?")
+			args = append(args, values[j])
+		}
+		op := " > ?"
+		if s.Desc {
+			op = " < ?"
+		}
+		parts = append(parts, productFields[s.Field].column+op)
+		args = append(args, values[i])
+		alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", args
+}
+
+// scanner é implementado tanto por *sql.Row quanto por *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanProduct lê uma linha com as colunas de productColumns
+func scanProduct(s scanner) (*models.Product, error) {
+	var p models.Product
+	if err := s.Scan(productScanDest(&p)...); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// productScanDest retorna os destinos do Scan das colunas de productColumns.
+// image_url é opcional e pode estar nulo no banco; a categoria também, em
+// produtos antigos cuja categoria não pôde ser normalizada.
+func productScanDest(p *models.Product) []interface{} {
+	return []interface{}{&p.ID, &p.Name, &p.Price, &p.Currency, &p.Description, nullInt{&p.CategoryID}, nullString{&p.Category}, nullString{&p.ImageURL}, &p.Version,
+		utcTime{&p.CreatedAt}, utcTime{&p.UpdatedAt}, nullTime{&p.DeletedAt}}
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+)
+
+// querier é o que as escritas precisam para executar comandos: o pool (*sql.DB)
+// ou uma transação (*sql.Tx)
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// inTx executa fn numa transação, confirmada se fn não retornar erro e desfeita caso contrário.
+// As escritas de produtos e os seus registros de auditoria são gravados juntos assim.
+// Dentro de WithTransaction, fn roda num savepoint da transação em andamento: se falhar,
+// apenas a sua escrita é desfeita e a transação continua utilizável.
+func (r *SQLProductRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if r.tx != nil {
+		return r.inSavepoint(ctx, fn)
+	}
+
+	tx, err := r.conn.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Erro ao iniciar transação: %v", err)
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Erro ao confirmar transação: %v", err)
+		return err
+	}
+	return nil
+}
+
+// inSavepoint executa fn num savepoint da transação de WithTransaction
+func (r *SQLProductRepository) inSavepoint(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if _, err := r.tx.ExecContext(ctx, "SAVEPOINT product_write"); err != nil {
+		log.Printf("Erro ao criar savepoint: %v", err)
+		return err
+	}
+	if err := fn(r.tx); err != nil {
+		if _, rollbackErr := r.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT product_write"); rollbackErr != nil {
+			log.Printf("Erro ao desfazer savepoint: %v", rollbackErr)
+		}
+		r.tx.ExecContext(ctx, "RELEASE SAVEPOINT product_write")
+		return err
+	}
+	if _, err := r.tx.ExecContext(ctx, "RELEASE SAVEPOINT product_write"); err != nil {
+		log.Printf("Erro ao liberar savepoint: %v", err)
+		return err
+	}
+	return nil
+}
+
+// WithTransaction executa fn com um repositório cujas leituras e escritas fazem parte
+// de uma única transação, confirmada se fn não retornar erro e desfeita caso contrário
+func (r *SQLProductRepository) WithTransaction(ctx context.Context, fn func(store ProductStore) error) error {
+	if r.tx != nil {
+		return fn(r) // Já numa transação: as escritas de fn entram nela
+	}
+
+	tx, err := r.conn.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Erro ao iniciar transação: %v", err)
+		return err
+	}
+	if err := fn(&SQLProductRepository{db: tx, conn: r.conn, tx: tx, dialect: r.dialect}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Erro ao confirmar transação: %v", err)
+		return err
+	}
+	return nil
+}
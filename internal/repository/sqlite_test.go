@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"braip/internal/database"
+	"braip/internal/database/migrations"
+	"path/filepath"
+	"testing"
+)
+
+// openSQLite abre o repositório num banco SQLite novo, no diretório temporário do
+// teste, com todas as migrações aplicadas
+func openSQLite(t *testing.T) *SQLProductRepository {
+	t.Helper()
+	conn, err := db.OpenDB(filepath.Join(t.TempDir(), "test.db"), db.DefaultPoolConfig())
+	if err != nil {
+		t.Fatalf("Erro ao abrir o SQLite: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	migrator, err := migrations.New(conn, db.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("Erro ao aplicar as migrações: %v", err)
+	}
+
+	repo, err := NewSQLProductRepository(conn, db.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+// forEachStore executa o teste em cada implementação de ProductStore: em memória,
+// SQLite e, com POSTGRES_TEST_DSN definida, PostgreSQL. O banco PostgreSQL é
+// compartilhado entre as execuções, então os testes devem usar nomes e chaves únicos.
+func forEachStore(t *testing.T, test func(t *testing.T, store ProductStore)) {
+	t.Run("memory", func(t *testing.T) { test(t, NewMemoryProductRepository()) })
+	t.Run("sqlite", func(t *testing.T) { test(t, openSQLite(t)) })
+	t.Run("postgres", func(t *testing.T) { test(t, openPostgres(t)) })
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"braip/internal/models"
+	"braip/internal/suggest"
+	"context"
+	"log"
+)
+
+// SuggestIndexedStore envolve um ProductStore e mantém o índice de sugestões
+// (autocompletar) atualizado a cada criação, atualização, exclusão e restauração
+// de produto e a cada categoria renomeada. As alterações passam pela transação do
+// backend, que lê o produto ou a categoria antes da escrita na mesma transação.
+//
+// O índice fica na memória do processo: só acompanha as escritas feitas por este
+// store. As do importador e as de outras instâncias da API sobre o mesmo banco
+// aparecem nas sugestões apenas depois que o servidor é reiniciado.
+type SuggestIndexedStore struct {
+	ProductStore
+	index      *suggest.Index
+	maxResults int
+}
+
+// Suggester é implementado pelos stores que oferecem sugestões de autocompletar
+type Suggester interface {
+	Suggest(prefix string, opts suggest.Options) []suggest.Suggestion
+}
+
+// NewSuggestIndexedStore carrega no índice todos os produtos já existentes no
+// backend e retorna o store que o mantém sincronizado dali em diante.
+// maxResults limita o número de sugestões retornadas por consulta.
+func NewSuggestIndexedStore(ctx context.Context, store ProductStore, index *suggest.Index, maxResults int) (*SuggestIndexedStore, error) {
+	s := &SuggestIndexedStore{ProductStore: store, index: index, maxResults: maxResults}
+
+	opts := ListOptions{Limit: MaxLimit, Fields: []string{"name", "category"}}
+	for {
+		page, err := store.GetProducts(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range page.Products {
+			s.add(p)
+		}
+		if page.NextCursor == "" {
+			return s, nil
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+// CreateProduct cria o produto e o adiciona ao índice
+func (s *SuggestIndexedStore) CreateProduct(ctx context.Context, product models.Product) (int64, error) {
+	id, err := s.ProductStore.CreateProduct(ctx, product)
+	if err != nil {
+		return 0, err
+	}
+	s.addStored(ctx, int(id))
+	return id, nil
+}
+
+// ImportProduct importa o produto e, se ele foi inserido, o adiciona ao índice
+func (s *SuggestIndexedStore) ImportProduct(ctx context.Context, product models.Product) (bool, error) {
+	inserted, err := s.ProductStore.ImportProduct(ctx, product)
+	if err != nil || !inserted {
+		return inserted, err
+	}
+	s.addStored(ctx, product.ID)
+	return true, nil
+}
+
+// UpdateProduct atualiza o produto e troca o nome e a categoria antigos pelos novos no índice
+func (s *SuggestIndexedStore) UpdateProduct(ctx context.Context, id int, product models.Product) error {
+	return s.WithTransaction(ctx, func(store ProductStore) error {
+		return store.UpdateProduct(ctx, id, product)
+	})
+}
+
+// PatchProduct altera o produto e troca o nome e a categoria antigos pelos novos no índice
+func (s *SuggestIndexedStore) PatchProduct(ctx context.Context, id int, patch models.ProductPatch) error {
+	return s.WithTransaction(ctx, func(store ProductStore) error {
+		return store.PatchProduct(ctx, id, patch)
+	})
+}
+
+// DeleteProduct move o produto para a lixeira e o remove do índice
+func (s *SuggestIndexedStore) DeleteProduct(ctx context.Context, id int, version int) error {
+	return s.WithTransaction(ctx, func(store ProductStore) error {
+		return store.DeleteProduct(ctx, id, version)
+	})
+}
+
+// RestoreProduct tira o produto da lixeira e o adiciona de volta ao índice
+func (s *SuggestIndexedStore) RestoreProduct(ctx context.Context, id int) error {
+	if err := s.ProductStore.RestoreProduct(ctx, id); err != nil {
+		return err
+	}
+	s.addStored(ctx, id)
+	return nil
+}
+
+// UpdateCategory atualiza a categoria e renomeia a sugestão correspondente
+func (s *SuggestIndexedStore) UpdateCategory(ctx context.Context, id int, category models.Category) error {
+	return s.WithTransaction(ctx, func(store ProductStore) error {
+		return store.UpdateCategory(ctx, id, category)
+	})
+}
+
+// WithTransaction executa fn na transação do backend. O índice só é atualizado depois
+// da confirmação, para que escritas desfeitas não deixem sugestões para trás.
+func (s *SuggestIndexedStore) WithTransaction(ctx context.Context, fn func(store ProductStore) error) error {
+	var tx *suggestTx
+	err := s.ProductStore.WithTransaction(ctx, func(store ProductStore) error {
+		tx = &suggestTx{ProductStore: store, old: make(map[int]*models.Product)}
+		return fn(tx)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, id := range tx.ids {
+		if old := tx.old[id]; old != nil {
+			s.remove(*old)
+		}
+		s.addStored(ctx, id)
+	}
+	for _, rename := range tx.renames {
+		s.index.Rename(suggest.KindCategory, rename[0], rename[1])
+	}
+	return nil
+}
+
+// suggestTx registra os produtos e categorias alterados numa transação,
+// para que o índice seja atualizado apenas depois da confirmação
+type suggestTx struct {
+	ProductStore
+	old     map[int]*models.Product // Produto antes da primeira alteração (nil se não existia ou estava na lixeira)
+	ids     []int                   // Produtos alterados, na ordem da primeira alteração
+	renames [][2]string             // Categorias renomeadas: nome antigo e novo
+}
+
+// touch guarda o estado do produto antes da sua primeira alteração na transação.
+// Sem ele, o nome antigo ficaria no índice: um erro na leitura impede a escrita.
+func (t *suggestTx) touch(ctx context.Context, id int) error {
+	if _, ok := t.old[id]; ok {
+		return nil
+	}
+	old, err := t.ProductStore.GetProductByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	t.old[id] = old
+	t.ids = append(t.ids, id)
+	return nil
+}
+
+func (t *suggestTx) CreateProduct(ctx context.Context, product models.Product) (int64, error) {
+	id, err := t.ProductStore.CreateProduct(ctx, product)
+	if err == nil {
+		t.old[int(id)] = nil
+		t.ids = append(t.ids, int(id))
+	}
+	return id, err
+}
+
+func (t *suggestTx) ImportProduct(ctx context.Context, product models.Product) (bool, error) {
+	if err := t.touch(ctx, product.ID); err != nil {
+		return false, err
+	}
+	return t.ProductStore.ImportProduct(ctx, product)
+}
+
+func (t *suggestTx) UpdateProduct(ctx context.Context, id int, product models.Product) error {
+	if err := t.touch(ctx, id); err != nil {
+		return err
+	}
+	return t.ProductStore.UpdateProduct(ctx, id, product)
+}
+
+func (t *suggestTx) PatchProduct(ctx context.Context, id int, patch models.ProductPatch) error {
+	if err := t.touch(ctx, id); err != nil {
+		return err
+	}
+	return t.ProductStore.PatchProduct(ctx, id, patch)
+}
+
+func (t *suggestTx) DeleteProduct(ctx context.Context, id int, version int) error {
+	if err := t.touch(ctx, id); err != nil {
+		return err
+	}
+	return t.ProductStore.DeleteProduct(ctx, id, version)
+}
+
+func (t *suggestTx) RestoreProduct(ctx context.Context, id int) error {
+	if err := t.touch(ctx, id); err != nil {
+		return err
+	}
+	return t.ProductStore.RestoreProduct(ctx, id)
+}
+
+func (t *suggestTx) UpdateCategory(ctx context.Context, id int, category models.Category) error {
+	old, err := t.ProductStore.GetCategoryByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := t.ProductStore.UpdateCategory(ctx, id, category); err != nil {
+		return err
+	}
+	if old != nil {
+		t.renames = append(t.renames, [2]string{old.Name, category.Name})
+	}
+	return nil
+}
+
+// WithTransaction dentro da transação apenas executa fn, registrando as alterações nela
+func (t *suggestTx) WithTransaction(ctx context.Context, fn func(store ProductStore) error) error {
+	return fn(t)
+}
+
+// Suggest retorna as sugestões de nomes e categorias para o prefixo digitado.
+// Sem limite informado, ou acima do máximo configurado, usa o máximo configurado.
+func (s *SuggestIndexedStore) Suggest(prefix string, opts suggest.Options) []suggest.Suggestion {
+	if opts.Limit <= 0 || opts.Limit > s.maxResults {
+		opts.Limit = s.maxResults
+	}
+	return s.index.Suggest(prefix, opts)
+}
+
+// addStored adiciona ao índice o produto como ficou gravado, já com o nome da categoria.
+// Uma falha aqui não desfaz a gravação: o produto só deixa de aparecer nas sugestões.
+func (s *SuggestIndexedStore) addStored(ctx context.Context, id int) {
+	product, err := s.ProductStore.GetProductByID(ctx, id)
+	if err != nil {
+		log.Printf("Erro ao atualizar o índice de sugestões: %v", err)
+		return
+	}
+	if product != nil {
+		s.add(*product)
+	}
+}
+
+func (s *SuggestIndexedStore) add(p models.Product) {
+	s.index.Add(suggest.KindName, p.Name)
+	s.index.Add(suggest.KindCategory, p.Category)
+}
+
+func (s *SuggestIndexedStore) remove(p models.Product) {
+	s.index.Remove(suggest.KindName, p.Name)
+	s.index.Remove(suggest.KindCategory, p.Category)
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"braip/internal/models"
+	"braip/internal/suggest"
+	"context"
+	"errors"
+	"testing"
+)
+
+// suggested informa se o texto está entre as sugestões do tipo para o prefixo
+func suggested(store *SuggestIndexedStore, kind, prefix, text string) bool {
+	for _, s := range store.Suggest(prefix, suggest.Options{Kind: kind}) {
+		if s.Text == text {
+			return true
+		}
+	}
+	return false
+}
+
+// Cada escrita pelo store atualiza as sugestões; as desfeitas não deixam nada para trás
+func TestSuggestIndexedStoreSync(t *testing.T) {
+	forEachStore(t, func(t *testing.T, backend ProductStore) {
+		ctx := context.Background()
+		word := uniqueWord()
+		existing := createTestProduct(t, backend, models.Product{Name: word + " mochila", Price: 1990, Description: "Mochila"})
+
+		// Os produtos já gravados são carregados na criação do store
+		store, err := NewSuggestIndexedStore(ctx, backend, suggest.NewIndex(), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !suggested(store, suggest.KindName, word, word+" mochila") {
+			t.Fatal("produto existente não carregado no índice")
+		}
+
+		id := createTestProduct(t, store, models.Product{Name: word + " camisa", Price: 2990, Description: "Camisa"})
+		if !suggested(store, suggest.KindName, word+" cam", word+" camisa") {
+			t.Error("produto criado não sugerido")
+		}
+
+		product, err := store.GetProductByID(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		product.Name = word + " camiseta"
+		if err := store.UpdateProduct(ctx, id, *product); err != nil {
+			t.Fatal(err)
+		}
+		if suggested(store, suggest.KindName, word, word+" camisa") || !suggested(store, suggest.KindName, word, word+" camiseta") {
+			t.Error("nome antigo ainda sugerido, ou o novo não, depois do PUT")
+		}
+
+		name := word + " regata"
+		if err := store.PatchProduct(ctx, id, models.ProductPatch{Name: &name}); err != nil {
+			t.Fatal(err)
+		}
+		if suggested(store, suggest.KindName, word, word+" camiseta") || !suggested(store, suggest.KindName, word, name) {
+			t.Error("nome antigo ainda sugerido, ou o novo não, depois do PATCH")
+		}
+
+		// Uma escrita recusada não altera as sugestões
+		taken := word + " mochila"
+		if err := store.PatchProduct(ctx, id, models.ProductPatch{Name: &taken}); err == nil {
+			t.Fatal("nome repetido aceito")
+		}
+		if !suggested(store, suggest.KindName, word, name) {
+			t.Error("sugestão removida por uma escrita recusada")
+		}
+
+		if err := store.DeleteProduct(ctx, id, 0); err != nil {
+			t.Fatal(err)
+		}
+		if suggested(store, suggest.KindName, word, name) {
+			t.Error("produto na lixeira ainda sugerido")
+		}
+		if err := store.RestoreProduct(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+		if !suggested(store, suggest.KindName, word, name) {
+			t.Error("produto restaurado não sugerido")
+		}
+
+		// Uma transação desfeita não deixa as suas alterações no índice
+		failed := errors.New("desfazer")
+		err = store.WithTransaction(ctx, func(tx ProductStore) error {
+			other := word + " bermuda"
+			if err := tx.PatchProduct(ctx, existing, models.ProductPatch{Name: &other}); err != nil {
+				return err
+			}
+			return failed
+		})
+		if err != failed {
+			t.Fatalf("WithTransaction: %v", err)
+		}
+		if !suggested(store, suggest.KindName, word, word+" mochila") || suggested(store, suggest.KindName, word, word+" bermuda") {
+			t.Error("alteração de uma transação desfeita refletida nas sugestões")
+		}
+	})
+}
+
+// Categorias renomeadas trocam de nome nas sugestões
+func TestSuggestIndexedStoreCategoryRename(t *testing.T) {
+	forEachStore(t, func(t *testing.T, backend ProductStore) {
+		ctx := context.Background()
+		word := uniqueWord()
+		store, err := NewSuggestIndexedStore(ctx, backend, suggest.NewIndex(), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id := createTestProduct(t, store, models.Product{Name: word + " barraca", Price: 19990, Description: "Barraca", Category: word + " camping"})
+		product, err := store.GetProductByID(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !suggested(store, suggest.KindCategory, word, word+" camping") {
+			t.Fatal("categoria do produto criado não sugerida")
+		}
+
+		if err := store.UpdateCategory(ctx, product.CategoryID, models.Category{Name: word + " acampamento"}); err != nil {
+			t.Fatal(err)
+		}
+		if suggested(store, suggest.KindCategory, word, word+" camping") || !suggested(store, suggest.KindCategory, word, word+" acampamento") {
+			t.Error("categoria renomeada não atualizada nas sugestões")
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"braip/internal/repository"
+	"context"
+)
+
+// AuditService concentra as consultas à auditoria de produtos
+type AuditService struct {
+	repo repository.ProductStore
+}
+
+// NewAuditService cria o serviço de auditoria a partir do backend de armazenamento escolhido
+func NewAuditService(repo repository.ProductStore) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// GetAuditEntries retorna uma página da auditoria, do registro mais recente para o mais antigo
+func (s *AuditService) GetAuditEntries(ctx context.Context, opts repository.AuditOptions) (*repository.AuditPage, error) {
+	return s.repo.GetAuditEntries(ctx, opts)
+}
+
+// GetProductHistory retorna o histórico de alterações de um produto. Produtos
+// removidos de vez continuam com histórico; sem histórico, o produto precisa
+// existir, caso contrário retorna ErrProductNotFound.
+func (s *AuditService) GetProductHistory(ctx context.Context, id int, opts repository.AuditOptions) (*repository.AuditPage, error) {
+	opts.ProductID = id
+	page, err := s.repo.GetAuditEntries(ctx, opts)
+	if err != nil || page.Total > 0 {
+		return page, err
+	}
+
+	product, err := s.repo.GetProductByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, repository.ErrProductNotFound
+	}
+	return page, nil
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"braip/internal/models"
+	"braip/internal/repository"
+	"braip/internal/textutil"
+	"braip/internal/validation"
+	"context"
+)
+
+// CategoryService concentra as regras de negócio de categorias
+type CategoryService struct {
+	repo repository.CategoryStore
+}
+
+// NewCategoryService cria o serviço de categorias a partir do backend de armazenamento escolhido
+func NewCategoryService(repo repository.CategoryStore) *CategoryService {
+	return &CategoryService{repo: repo}
+}
+
+// GetCategories retorna todas as categorias
+func (s *CategoryService) GetCategories(ctx context.Context) ([]models.Category, error) {
+	return s.repo.GetCategories(ctx)
+}
+
+// GetCategoryByID retorna uma categoria pelo ID ou ErrCategoryNotFound se ela não existir
+func (s *CategoryService) GetCategoryByID(ctx context.Context, id int) (*models.Category, error) {
+	category, err := s.repo.GetCategoryByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if category == nil {
+		return nil, repository.ErrCategoryNotFound
+	}
+	return category, nil
+}
+
+// CreateCategory cria uma nova categoria; o slug é gerado a partir do nome
+func (s *CategoryService) CreateCategory(ctx context.Context, category models.Category) (int64, error) {
+	category.Name = textutil.Clean(category.Name)
+	if err := validation.Category(category); err != nil {
+		return 0, err
+	}
+	return s.repo.CreateCategory(ctx, category)
+}
+
+// UpdateCategory atualiza uma categoria
+func (s *CategoryService) UpdateCategory(ctx context.Context, id int, category models.Category) error {
+	category.Name = textutil.Clean(category.Name)
+	if err := validation.Category(category); err != nil {
+		return err
+	}
+	return s.repo.UpdateCategory(ctx, id, category)
+}
+
+// DeleteCategory remove uma categoria sem produtos nem subcategorias
+func (s *CategoryService) DeleteCategory(ctx context.Context, id int) error {
+	return s.repo.DeleteCategory(ctx, id)
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"braip/internal/apperror"
+	"braip/internal/audit"
+	"braip/internal/models"
+	"braip/internal/repository"
+	"context"
+	"time"
+)
+
+// DefaultIdempotencyTTL é por quanto tempo a resposta de uma Idempotency-Key é guardada
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// IdempotencyLease é por quanto tempo a chave fica reservada para a requisição em
+// andamento. Se o processo cair antes de concluí-la ou liberá-la, a reserva expira
+// e a requisição pode ser repetida, em vez de receber 409 até o fim do TTL.
+const IdempotencyLease = time.Minute
+
+// Erros das requisições com Idempotency-Key
+var (
+	ErrIdempotencyKeyReused   = apperror.Unprocessable("a Idempotency-Key já foi usada em outra requisição; use uma chave nova")
+	ErrIdempotencyKeyInFlight = apperror.Conflict("a requisição com essa Idempotency-Key ainda está em andamento; tente de novo em instantes")
+)
+
+// IdempotencyService controla as requisições feitas com Idempotency-Key, para que
+// uma requisição reenviada pelo cliente não seja executada duas vezes
+type IdempotencyService struct {
+	repo  repository.IdempotencyStore
+	ttl   time.Duration // Por quanto tempo a resposta é guardada
+	lease time.Duration // Por quanto tempo a chave fica reservada para a requisição em andamento
+}
+
+// NewIdempotencyService cria o serviço que guarda as respostas por ttl (DefaultIdempotencyTTL se zero).
+// A reserva das requisições em andamento dura IdempotencyLease, ou ttl, se for menor.
+func NewIdempotencyService(repo repository.IdempotencyStore, ttl time.Duration) *IdempotencyService {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	return &IdempotencyService{repo: repo, ttl: ttl, lease: min(ttl, IdempotencyLease)}
+}
+
+// scopedKey é a chave guardada: a do cliente, precedida pelo autor da requisição
+// (X-Actor), para que clientes diferentes possam usar a mesma chave sem conflito.
+// Os nomes de autor não têm "/", o que mantém as chaves de clientes diferentes distintas.
+func scopedKey(ctx context.Context, key string) string {
+	return audit.ActorFrom(ctx).Name + "/" + key
+}
+
+// Begin reserva a chave para a requisição de hash requestHash. Se a requisição já foi
+// executada, retorna o registro com a resposta a repetir; se a chave foi usada com outra
+// requisição, ErrIdempotencyKeyReused; se a primeira requisição ainda não terminou,
+// ErrIdempotencyKeyInFlight. Sem registro nem erro, a requisição deve ser executada
+// e terminada com Complete ou Release; até lá, a chave fica reservada por s.lease.
+// As chaves são separadas por cliente (X-Actor).
+func (s *IdempotencyService) Begin(ctx context.Context, key, requestHash string) (*models.IdempotencyRecord, error) {
+	createdAt := time.Now().UTC().Truncate(time.Microsecond)
+	existing, reserved, err := s.repo.ReserveIdempotencyKey(ctx, models.IdempotencyRecord{
+		Key:         scopedKey(ctx, key),
+		RequestHash: requestHash,
+		CreatedAt:   createdAt,
+		ExpiresAt:   createdAt.Add(s.lease),
+	})
+	switch {
+	case err != nil:
+		return nil, err
+	case reserved:
+		return nil, nil
+	case existing.RequestHash != requestHash:
+		return nil, ErrIdempotencyKeyReused
+	case !existing.Completed():
+		return nil, ErrIdempotencyKeyInFlight
+	}
+	return existing, nil
+}
+
+// Complete guarda a resposta da requisição que reservou a chave, pelo período do TTL
+func (s *IdempotencyService) Complete(ctx context.Context, key, requestHash string, status int, header map[string][]string, body []byte) error {
+	return s.repo.CompleteIdempotencyKey(ctx, models.IdempotencyRecord{
+		Key:         scopedKey(ctx, key),
+		RequestHash: requestHash,
+		Status:      status,
+		Header:      header,
+		Body:        body,
+		ExpiresAt:   time.Now().UTC().Truncate(time.Microsecond).Add(s.ttl),
+	})
+}
+
+// Release libera a chave de uma requisição que falhou, para que ela possa ser repetida
+func (s *IdempotencyService) Release(ctx context.Context, key string) error {
+	return s.repo.ReleaseIdempotencyKey(ctx, scopedKey(ctx, key))
+}
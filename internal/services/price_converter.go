@@ -0,0 +1,156 @@
+package services
+
+import (
+	"braip/internal/apperror"
+	"braip/internal/models"
+	"braip/internal/money"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ErrConversionUnavailable indica uma conversão de moeda pedida a um servidor sem cotações configuradas
+var ErrConversionUnavailable = apperror.BadRequest("conversão de moeda não configurada neste servidor")
+
+// PriceConverter converte os preços dos produtos lidos para a moeda pedida pelo cliente
+type PriceConverter struct {
+	rates money.RateProvider // nil se o servidor não tem cotações configuradas
+}
+
+// NewPriceConverter cria o conversor a partir do provedor de cotações (nil desativa a conversão)
+func NewPriceConverter(rates money.RateProvider) *PriceConverter {
+	return &PriceConverter{rates: rates}
+}
+
+// ConvertProducts converte os preços dos produtos para currency; vazio não converte.
+// Cada produto convertido guarda em Conversion o preço original e a cotação usada.
+func (c *PriceConverter) ConvertProducts(ctx context.Context, products []models.Product, currency string) error {
+	conv, err := c.conversion(ctx, currency)
+	if conv == nil || err != nil {
+		return err
+	}
+	for i := range products {
+		if _, err := conv.product(&products[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConvertDrops converte os preços das quedas de preço para currency, inclusive o preço anterior
+func (c *PriceConverter) ConvertDrops(ctx context.Context, drops []models.PriceDrop, currency string) error {
+	conv, err := c.conversion(ctx, currency)
+	if conv == nil || err != nil {
+		return err
+	}
+	for i := range drops {
+		rate, err := conv.product(&drops[i].Product)
+		if err != nil {
+			return err
+		}
+		if rate != nil {
+			drops[i].PreviousPrice = money.Convert(drops[i].PreviousPrice, *rate)
+		}
+	}
+	return nil
+}
+
+// ConvertHistory converte os preços do histórico para currency; vazio não converte.
+// Cada ponto convertido guarda em Conversion o preço gravado e a cotação usada. O
+// preço anterior é convertido a partir da moeda do ponto anterior, em que foi gravado.
+func (c *PriceConverter) ConvertHistory(ctx context.Context, history []models.PriceChange, currency string) error {
+	conv, err := c.conversion(ctx, currency)
+	if conv == nil || err != nil {
+		return err
+	}
+	previousCurrency := ""
+	for i := range history {
+		change := &history[i]
+		if change.PreviousPrice != nil && previousCurrency != "" {
+			rate, err := conv.rate(previousCurrency)
+			if err != nil {
+				return err
+			}
+			if rate != nil {
+				previous := money.Convert(*change.PreviousPrice, *rate)
+				change.PreviousPrice = &previous
+			}
+		}
+		previousCurrency = change.Currency
+
+		rate, err := conv.rate(change.Currency)
+		if err != nil {
+			return err
+		}
+		if rate == nil {
+			continue
+		}
+		change.Conversion = &models.PriceConversion{
+			OriginalPrice:    change.Price,
+			OriginalCurrency: change.Currency,
+			Rate:             rate.String(),
+			RateDate:         rate.Date,
+		}
+		change.Price, change.Currency = money.Convert(change.Price, *rate), conv.to
+	}
+	return nil
+}
+
+// conversion prepara a conversão para a moeda pedida; retorna nil se nenhuma foi pedida
+func (c *PriceConverter) conversion(ctx context.Context, currency string) (*conversion, error) {
+	if strings.TrimSpace(currency) == "" {
+		return nil, nil
+	}
+	currency = money.Normalize(currency)
+	if !money.Valid(currency) {
+		return nil, apperror.BadRequest(fmt.Sprintf("parâmetro 'currency' inválido: %q", currency))
+	}
+	if c == nil || c.rates == nil {
+		return nil, ErrConversionUnavailable
+	}
+	return &conversion{ctx: ctx, provider: c.rates, to: currency, rates: make(map[string]money.Rate)}, nil
+}
+
+// conversion converte os preços de uma resposta, consultando cada cotação uma única vez
+type conversion struct {
+	ctx      context.Context
+	provider money.RateProvider
+	to       string
+	rates    map[string]money.Rate // Cotações já consultadas, pela moeda de origem
+}
+
+// product converte o preço do produto e retorna a cotação usada (nil se ele já
+// estiver na moeda pedida ou se o preço não foi lido, numa seleção de campos sem price)
+func (c *conversion) product(p *models.Product) (*money.Rate, error) {
+	rate, err := c.rate(p.Currency)
+	if rate == nil || err != nil {
+		return nil, err
+	}
+
+	p.Conversion = &models.PriceConversion{
+		OriginalPrice:    p.Price,
+		OriginalCurrency: p.Currency,
+		Rate:             rate.String(),
+		RateDate:         rate.Date,
+	}
+	p.Price, p.Currency = money.Convert(p.Price, *rate), c.to
+	return rate, nil
+}
+
+// rate retorna a cotação da moeda from para a moeda pedida (nil se from já for a
+// moeda pedida ou estiver vazia)
+func (c *conversion) rate(from string) (*money.Rate, error) {
+	if from == c.to || from == "" {
+		return nil, nil
+	}
+
+	rate, ok := c.rates[from]
+	if !ok {
+		var err error
+		if rate, err = c.provider.Rate(c.ctx, from, c.to); err != nil {
+			return nil, err
+		}
+		c.rates[from] = rate
+	}
+	return &rate, nil
+}
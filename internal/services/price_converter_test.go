@@ -0,0 +1,62 @@
+package services
+
+import (
+	"braip/internal/models"
+	"braip/internal/money"
+	"context"
+	"math/big"
+	"testing"
+)
+
+// fixedRates é um provedor com cotações fixas para a moeda pedida
+type fixedRates map[string]string
+
+func (f fixedRates) Rate(ctx context.Context, from, to string) (money.Rate, error) {
+	v, _ := new(big.Rat).SetString(f[from])
+	return money.Rate{From: from, To: to, Value: v, Date: "2024-05-10"}, nil
+}
+
+func TestConvertHistory(t *testing.T) {
+	converter := NewPriceConverter(fixedRates{"USD": "5", "EUR": "6"})
+	intp := func(n int) *int { return &n }
+
+	// O produto foi gravado em USD, passou para EUR e depois para BRL
+	history := []models.PriceChange{
+		{Price: 1000, Currency: "USD"},
+		{Price: 900, Currency: "EUR", PreviousPrice: intp(1000)},
+		{Price: 5000, Currency: "BRL", PreviousPrice: intp(900)},
+		{Price: 4500, Currency: "BRL", PreviousPrice: intp(5000)},
+	}
+	if err := converter.ConvertHistory(context.Background(), history, "brl"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		price, previous int
+		conversion      *models.PriceConversion
+	}{
+		{5000, 0, &models.PriceConversion{OriginalPrice: 1000, OriginalCurrency: "USD", Rate: "5", RateDate: "2024-05-10"}},
+		{5400, 5000, &models.PriceConversion{OriginalPrice: 900, OriginalCurrency: "EUR", Rate: "6", RateDate: "2024-05-10"}},
+		{5000, 5400, nil}, // Já em BRL; o preço anterior, em EUR, é convertido
+		{4500, 5000, nil},
+	}
+	for i, w := range want {
+		got := history[i]
+		if got.Price != w.price || got.Currency != "BRL" {
+			t.Errorf("ponto %d: preço %d %s; esperado %d BRL", i, got.Price, got.Currency, w.price)
+		}
+		if (got.PreviousPrice == nil) != (i == 0) || (got.PreviousPrice != nil && *got.PreviousPrice != w.previous) {
+			t.Errorf("ponto %d: preço anterior %v; esperado %d", i, got.PreviousPrice, w.previous)
+		}
+		if (got.Conversion == nil) != (w.conversion == nil) || (got.Conversion != nil && *got.Conversion != *w.conversion) {
+			t.Errorf("ponto %d: conversão %+v; esperado %+v", i, got.Conversion, w.conversion)
+		}
+	}
+
+	if err := converter.ConvertHistory(context.Background(), history, "XYZ"); err == nil {
+		t.Error("ConvertHistory com moeda inválida: esperado erro")
+	}
+	if err := NewPriceConverter(nil).ConvertHistory(context.Background(), history, "USD"); err != ErrConversionUnavailable {
+		t.Errorf("ConvertHistory sem cotações: erro = %v; esperado ErrConversionUnavailable", err)
+	}
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"braip/internal/apperror"
+	"braip/internal/models"
+	"braip/internal/repository"
+	"context"
+	"errors"
+)
+
+// BulkMode define o que acontece com o lote quando algum item falha
+type BulkMode string
+
+const (
+	BulkAtomic     BulkMode = "atomic"      // Tudo ou nada: se algum item falhar, nenhum é gravado
+	BulkBestEffort BulkMode = "best-effort" // Os itens válidos são gravados mesmo que outros falhem
+)
+
+// MaxBulkItems é o número máximo de itens de um lote
+const MaxBulkItems = 1000
+
+// ErrBulkRolledBack é o erro dos itens que deram certo num lote atômico desfeito
+// porque outro item falhou
+var ErrBulkRolledBack = apperror.New(apperror.KindFailedDependency, "item não gravado: outro item do lote falhou e o lote foi desfeito")
+
+// errBulkFailed desfaz a transação de um lote atômico com falhas
+var errBulkFailed = errors.New("lote com falhas")
+
+// BulkOperation é a operação de um item do lote. Ela recebe o serviço ligado à
+// transação do lote e retorna o produto como ficou gravado (nil nas remoções).
+type BulkOperation func(ctx context.Context, s *ProductService) (*models.Product, error)
+
+// BulkResult é o resultado de um item do lote
+type BulkResult struct {
+	Product *models.Product
+	Err     error
+}
+
+// RunBulk executa as operações do lote, em ordem, numa única transação. Todos os itens
+// são executados mesmo depois de uma falha, para que o resultado informe os erros de
+// todos eles. No modo atômico, qualquer falha desfaz o lote inteiro (committed false)
+// e os itens que tinham dado certo recebem ErrBulkRolledBack; no best-effort, só os
+// itens com falha deixam de ser gravados. O erro retornado é apenas o da transação.
+func (s *ProductService) RunBulk(ctx context.Context, ops []BulkOperation, mode BulkMode) (results []BulkResult, committed bool, err error) {
+	err = s.repo.WithTransaction(ctx, func(store repository.ProductStore) error {
+		tx := &ProductService{repo: store}
+		results = make([]BulkResult, len(ops))
+		failed := false
+		for i, op := range ops {
+			product, err := op(ctx, tx)
+			results[i] = BulkResult{Product: product, Err: err}
+			if err != nil {
+				failed = true
+			}
+		}
+		if failed && mode == BulkAtomic {
+			return errBulkFailed
+		}
+		return nil
+	})
+
+	if errors.Is(err, errBulkFailed) {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i] = BulkResult{Err: ErrBulkRolledBack}
+			}
+		}
+		return results, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return results, true, nil
+}
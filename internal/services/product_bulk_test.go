@@ -0,0 +1,86 @@
+package services
+
+import (
+	"braip/internal/models"
+	"braip/internal/repository"
+	"context"
+	"errors"
+	"testing"
+)
+
+// create é a operação de lote que cria o produto com o nome informado
+func create(name string) BulkOperation {
+	return func(ctx context.Context, s *ProductService) (*models.Product, error) {
+		id, err := s.CreateProduct(ctx, models.Product{Name: name, Price: 1990, Description: "Produto de teste", Category: "Testes"})
+		if err != nil {
+			return nil, err
+		}
+		return s.GetProductByID(ctx, int(id))
+	}
+}
+
+// storedNames retorna os nomes dos produtos gravados
+func storedNames(t *testing.T, repo repository.ProductStore) map[string]bool {
+	t.Helper()
+	page, err := repo.GetProducts(context.Background(), repository.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]bool{}
+	for _, p := range page.Products {
+		names[p.Name] = true
+	}
+	return names
+}
+
+func TestRunBulk(t *testing.T) {
+	// O segundo item repete o nome do primeiro e o terceiro é inválido
+	ops := []BulkOperation{create("Boné"), create("boné"), create(""), create("Meia")}
+
+	t.Run("atomic", func(t *testing.T) {
+		repo := repository.NewMemoryProductRepository()
+		results, committed, err := NewProductService(repo).RunBulk(context.Background(), ops, BulkAtomic)
+		if err != nil || committed {
+			t.Fatalf("RunBulk = %v, %v; esperado lote desfeito", committed, err)
+		}
+		// Todos os itens são executados; os que deram certo são marcados como desfeitos
+		var inUse *repository.NameInUseError
+		if !errors.Is(results[0].Err, ErrBulkRolledBack) || !errors.As(results[1].Err, &inUse) ||
+			results[2].Err == nil || errors.Is(results[2].Err, ErrBulkRolledBack) || !errors.Is(results[3].Err, ErrBulkRolledBack) {
+			t.Errorf("resultados: %+v", results)
+		}
+		for i, r := range results {
+			if r.Product != nil {
+				t.Errorf("item %d desfeito com produto: %+v", i, r.Product)
+			}
+		}
+		if names := storedNames(t, repo); len(names) != 0 {
+			t.Errorf("produtos gravados num lote desfeito: %v", names)
+		}
+	})
+
+	t.Run("best-effort", func(t *testing.T) {
+		repo := repository.NewMemoryProductRepository()
+		results, committed, err := NewProductService(repo).RunBulk(context.Background(), ops, BulkBestEffort)
+		if err != nil || !committed {
+			t.Fatalf("RunBulk = %v, %v; esperado lote gravado", committed, err)
+		}
+		if results[0].Err != nil || results[0].Product == nil || results[1].Err == nil || results[2].Err == nil || results[3].Err != nil {
+			t.Errorf("resultados: %+v", results)
+		}
+		if names := storedNames(t, repo); len(names) != 2 || !names["Boné"] || !names["Meia"] {
+			t.Errorf("produtos gravados: %v", names)
+		}
+	})
+
+	t.Run("sem falhas", func(t *testing.T) {
+		repo := repository.NewMemoryProductRepository()
+		results, committed, err := NewProductService(repo).RunBulk(context.Background(), []BulkOperation{create("Boné"), create("Meia")}, BulkAtomic)
+		if err != nil || !committed || results[0].Err != nil || results[1].Err != nil {
+			t.Fatalf("RunBulk = %+v, %v, %v", results, committed, err)
+		}
+		if names := storedNames(t, repo); len(names) != 2 {
+			t.Errorf("produtos gravados: %v", names)
+		}
+	})
+}
@@ -1,50 +1,250 @@
 package services
 
 import (
+	"braip/internal/apperror"
+	"braip/internal/filter"
 	"braip/internal/models"
+	"braip/internal/money"
 	"braip/internal/repository"
+	"braip/internal/suggest"
+	"braip/internal/validation"
+	"context"
+	"errors"
+	"time"
 )
 
-// GetProducts retorna todos os produtos
-func GetProducts() ([]models.Product, error) {
-	return repository.GetProducts()
+// ProductService concentra as regras de negócio de produtos
+type ProductService struct {
+	repo      repository.ProductStore
+	suggester repository.Suggester // nil se o store não mantém o índice de sugestões
 }
 
-// CreateProduct cria um novo produto
-func CreateProduct(product models.Product) (int64, error) {
-	id, err := repository.CreateProduct(product)
-	if err != nil {
+// ErrSuggestionsUnavailable indica que o backend não mantém o índice de sugestões
+var ErrSuggestionsUnavailable = apperror.Internal("sugestões indisponíveis neste backend", nil)
+
+// ErrProductInTrash indica uma escrita com o ID de um produto que está na lixeira
+var ErrProductInTrash = apperror.Conflict("o produto com esse ID está na lixeira; restaure-o antes de alterá-lo")
+
+// NewProductService cria o serviço de produtos a partir do backend de armazenamento escolhido
+func NewProductService(repo repository.ProductStore) *ProductService {
+	suggester, _ := repo.(repository.Suggester)
+	return &ProductService{repo: repo, suggester: suggester}
+}
+
+// GetProducts retorna uma página de produtos
+func (s *ProductService) GetProducts(ctx context.Context, opts repository.ListOptions) (*repository.ProductPage, error) {
+	return s.repo.GetProducts(ctx, opts)
+}
+
+// CreateProduct valida e cria um novo produto. Sem moeda, o preço é da moeda padrão (BRL).
+// Dados inválidos resultam em validation.Errors, com um erro por campo, e um nome
+// já usado por outro produto em *repository.NameInUseError.
+func (s *ProductService) CreateProduct(ctx context.Context, product models.Product) (int64, error) {
+	product.Currency = money.Normalize(product.Currency)
+	if err := validation.Product(product); err != nil {
 		return 0, err
 	}
+
+	id, err := s.repo.CreateProduct(ctx, product)
+	if err != nil {
+		return 0, categoryFieldError(err)
+	}
 	return id, nil
 }
 
-// GetProductByID retorna um produto pelo ID
-func GetProductByID(id int) (*models.Product, error) {
-	return repository.GetProductByID(id)
+// GetProductByID retorna um produto pelo ID ou ErrProductNotFound se ele não existir
+func (s *ProductService) GetProductByID(ctx context.Context, id int) (*models.Product, error) {
+	product, err := s.repo.GetProductByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, repository.ErrProductNotFound
+	}
+	return product, nil
+}
+
+// UpdateProduct valida e substitui um produto existente, retornando-o como ficou gravado.
+// Retorna ErrProductNotFound se o produto não existir e ErrVersionMismatch se
+// product.Version for informada e o produto estiver em outra versão.
+func (s *ProductService) UpdateProduct(ctx context.Context, id int, product models.Product) (*models.Product, error) {
+	product.Currency = money.Normalize(product.Currency)
+	if err := validation.Product(product); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateProduct(ctx, id, product); err != nil {
+		return nil, categoryFieldError(err)
+	}
+	return s.GetProductByID(ctx, id)
 }
 
-// UpdateProduct atualiza um produto
-func UpdateProduct(id int, product models.Product) error {
-	return repository.UpdateProduct(id, product)
+// PatchProduct valida e altera apenas os campos informados, retornando o produto como ficou gravado.
+// Retorna ErrProductNotFound se o produto não existir.
+func (s *ProductService) PatchProduct(ctx context.Context, id int, patch models.ProductPatch) (*models.Product, error) {
+	if patch.Currency != nil {
+		currency := money.Normalize(*patch.Currency)
+		patch.Currency = &currency
+	}
+	if err := validation.ProductPatch(patch); err != nil {
+		return nil, err
+	}
+	if err := s.repo.PatchProduct(ctx, id, patch); err != nil {
+		return nil, categoryFieldError(err)
+	}
+	return s.GetProductByID(ctx, id)
 }
 
-// DeleteProduct remove um produto
-func DeleteProduct(id int) error {
-	return repository.DeleteProduct(id)
+// CreateProductWithID cria o produto com o ID informado, apenas se ele ainda não existir
+// (PUT com If-None-Match: *). Retorna ErrProductExists se o ID já estiver em uso.
+func (s *ProductService) CreateProductWithID(ctx context.Context, id int, product models.Product) (*models.Product, error) {
+	product.Currency = money.Normalize(product.Currency)
+	if err := validation.Product(product); err != nil {
+		return nil, err
+	}
+
+	product.ID = id
+	inserted, err := s.repo.ImportProduct(ctx, product)
+	if err != nil {
+		return nil, categoryFieldError(err)
+	}
+	if !inserted {
+		return nil, repository.ErrProductExists
+	}
+	return s.GetProductByID(ctx, id)
 }
 
-// SearchProductsByNameAndCategory busca produtos por nome e categoria
-func SearchProductsByNameAndCategory(name, category string) ([]models.Product, error) {
-	return repository.SearchProductsByNameAndCategory(name, category)
+// UpsertProduct substitui o produto com o ID informado ou o cria, se ele não existir.
+// created indica se o produto foi criado.
+func (s *ProductService) UpsertProduct(ctx context.Context, id int, product models.Product) (stored *models.Product, created bool, err error) {
+	product.Currency = money.Normalize(product.Currency)
+	if err := validation.Product(product); err != nil {
+		return nil, false, err
+	}
+
+	err = s.repo.UpdateProduct(ctx, id, product)
+	if errors.Is(err, repository.ErrProductNotFound) {
+		product.ID = id
+		created, err = s.repo.ImportProduct(ctx, product)
+		if err == nil && !created {
+			// O ID já existe: outra requisição criou o produto entre as duas operações
+			// (e ele é substituído) ou o produto está na lixeira
+			err = s.repo.UpdateProduct(ctx, id, product)
+			if errors.Is(err, repository.ErrProductNotFound) {
+				err = ErrProductInTrash
+			}
+		}
+	}
+	if err != nil {
+		return nil, false, categoryFieldError(err)
+	}
+
+	stored, err = s.GetProductByID(ctx, id)
+	return stored, created, err
 }
 
-// SearchProductsByCategory busca produtos por categoria
-func SearchProductsByCategory(category string) ([]models.Product, error) {
-	return repository.SearchProductsByCategory(category)
+// categoryFieldError converte a categoria inexistente em erro de validação do campo category_id
+func categoryFieldError(err error) error {
+	if errors.Is(err, repository.ErrCategoryNotFound) {
+		return validation.Errors{{Field: "category_id", Code: validation.CodeNotFound, Message: "categoria não encontrada"}}
+	}
+	return err
+}
+
+// DeleteProduct move um produto para a lixeira; retorna ErrProductNotFound se ele não existir.
+// Com version diferente de zero, só remove o produto se ele ainda estiver nessa versão.
+func (s *ProductService) DeleteProduct(ctx context.Context, id int, version int) error {
+	return s.repo.DeleteProduct(ctx, id, version)
+}
+
+// GetTrash retorna uma página dos produtos que estão na lixeira
+func (s *ProductService) GetTrash(ctx context.Context, opts repository.ListOptions) (*repository.ProductPage, error) {
+	opts.Trashed = true
+	return s.repo.GetProducts(ctx, opts)
+}
+
+// RestoreProduct tira um produto da lixeira e o retorna como ficou gravado.
+// Se outro produto passou a usar o mesmo nome enquanto ele estava na lixeira,
+// a restauração é recusada com *repository.NameInUseError (conflito).
+func (s *ProductService) RestoreProduct(ctx context.Context, id int) (*models.Product, error) {
+	if err := s.repo.RestoreProduct(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.GetProductByID(ctx, id)
+}
+
+// PurgeTrash remove de vez os produtos que estão na lixeira há mais tempo que retention
+func (s *ProductService) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.repo.PurgeProducts(ctx, time.Now().Add(-retention))
+}
+
+// GetPriceHistory retorna os preços de um produto, do mais antigo para o mais recente.
+// Produtos removidos de vez continuam com histórico; sem histórico, o produto precisa
+// existir, caso contrário retorna ErrProductNotFound.
+func (s *ProductService) GetPriceHistory(ctx context.Context, id int) ([]models.PriceChange, error) {
+	history, err := s.repo.GetPriceHistory(ctx, id)
+	if err != nil || len(history) > 0 {
+		return history, err
+	}
+
+	product, err := s.repo.GetProductByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, repository.ErrProductNotFound
+	}
+	return history, nil
+}
+
+// GetPriceDrops retorna os produtos cujo preço caiu pelo menos minPercent% nos últimos days dias
+func (s *ProductService) GetPriceDrops(ctx context.Context, minPercent float64, days int, limit int) ([]models.PriceDrop, error) {
+	return s.repo.GetPriceDrops(ctx, repository.PriceDropOptions{
+		MinPercent: minPercent,
+		Since:      time.Now().AddDate(0, 0, -days),
+		Limit:      limit,
+	})
+}
+
+// SearchProducts faz a busca textual em nome e descrição, ordenada por relevância
+func (s *ProductService) SearchProducts(ctx context.Context, q string, opts repository.ListOptions) (*repository.SearchPage, error) {
+	return s.repo.SearchProducts(ctx, q, opts)
+}
+
+// ExportProducts percorre os produtos selecionados por opts, na ordem, chamando fn para
+// cada um sem carregar o resultado inteiro em memória
+func (s *ProductService) ExportProducts(ctx context.Context, opts repository.ExportOptions, fn func(p *models.Product) error) error {
+	return s.repo.ExportProducts(ctx, opts, fn)
+}
+
+// SuggestProducts retorna sugestões de nomes e categorias para o autocompletar
+func (s *ProductService) SuggestProducts(prefix string, opts suggest.Options) ([]suggest.Suggestion, error) {
+	if s.suggester == nil {
+		return nil, ErrSuggestionsUnavailable
+	}
+	return s.suggester.Suggest(prefix, opts), nil
+}
+
+// As buscas abaixo são atalhos das rotas antigas de busca: montam o filtro
+// equivalente e delegam para a listagem de produtos.
+
+// SearchProductsByNameAndCategory busca produtos por parte do nome dentro de uma categoria
+func (s *ProductService) SearchProductsByNameAndCategory(ctx context.Context, name, category string, opts repository.ListOptions) (*repository.ProductPage, error) {
+	opts.Filter = filter.Combine(
+		filter.Condition{Field: "name", Op: filter.Contains, Value: name},
+		filter.Condition{Field: "category", Op: filter.Eq, Value: category},
+	)
+	return s.repo.GetProducts(ctx, opts)
+}
+
+// SearchProductsByCategory busca produtos da categoria, comparada pelo slug
+// ("Electronics" e "electronics" encontram a mesma categoria)
+func (s *ProductService) SearchProductsByCategory(ctx context.Context, category string, opts repository.ListOptions) (*repository.ProductPage, error) {
+	opts.Filter = filter.Condition{Field: "category", Op: filter.Eq, Value: category}
+	return s.repo.GetProducts(ctx, opts)
 }
 
 // SearchProductsByImage busca produtos com ou sem imagem
-func SearchProductsByImage(hasImage bool) ([]models.Product, error) {
-	return repository.SearchProductsByImage(hasImage)
-}
\ No newline at end of file
+func (s *ProductService) SearchProductsByImage(ctx context.Context, hasImage bool, opts repository.ListOptions) (*repository.ProductPage, error) {
+	opts.Filter = filter.Condition{Field: "has_image", Op: filter.Eq, Value: hasImage}
+	return s.repo.GetProducts(ctx, opts)
+}
@@ -0,0 +1,225 @@
+// Package suggest implementa o índice em memória usado no autocompletar
+// de nomes de produtos e categorias.
+//
+// O índice guarda cada texto normalizado (minúsculas e sem acentos) com a
+// quantidade de produtos que o usam. As sugestões aceitam prefixos do texto
+// inteiro ou de qualquer palavra dele e toleram erros de digitação.
+package suggest
+
+import (
+	"braip/internal/textutil"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Tipos de sugestão
+const (
+	KindName     = "name"
+	KindCategory = "category"
+)
+
+// Suggestion é uma sugestão de complemento para o texto digitado
+type Suggestion struct {
+	Text  string  `json:"text"`
+	Kind  string  `json:"type"`
+	Count int     `json:"count"` // Quantidade de produtos com esse nome ou categoria
+	Score float64 `json:"score"`
+}
+
+// Options controla a busca de sugestões
+type Options struct {
+	Limit    int    // Número máximo de sugestões
+	MaxTypos int    // Número máximo de erros de digitação tolerados (0 = apenas prefixo exato)
+	Kind     string // Restringe a um tipo de sugestão (vazio = nomes e categorias)
+}
+
+// entry é um texto indexado
+type entry struct {
+	text  string   // Texto original, como exibido ao usuário
+	key   string   // Texto normalizado
+	words []string // Palavras do texto normalizado
+	count int
+}
+
+// Index é o índice de sugestões; pode ser usado por várias goroutines
+type Index struct {
+	mu      sync.RWMutex
+	entries map[string]map[string]*entry // tipo -> texto normalizado -> entrada
+}
+
+// NewIndex cria um índice vazio
+func NewIndex() *Index {
+	return &Index{entries: map[string]map[string]*entry{
+		KindName:     {},
+		KindCategory: {},
+	}}
+}
+
+// Add registra um uso do texto (um produto com esse nome ou categoria)
+func (idx *Index) Add(kind, text string) {
+	key := textutil.Fold(strings.TrimSpace(text))
+	if key == "" {
+		return
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	e, ok := idx.entries[kind][key]
+	if !ok {
+		e = &entry{text: strings.TrimSpace(text), key: key, words: textutil.Words(key)}
+		idx.entries[kind][key] = e
+	}
+	e.count++
+}
+
+// Remove desfaz um uso do texto; a entrada some quando nenhum produto a usa mais
+func (idx *Index) Remove(kind, text string) {
+	key := textutil.Fold(strings.TrimSpace(text))
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	e, ok := idx.entries[kind][key]
+	if !ok {
+		return
+	}
+	e.count--
+	if e.count <= 0 {
+		delete(idx.entries[kind], key)
+	}
+}
+
+// Rename troca um texto por outro mantendo a quantidade de usos
+// (por exemplo, quando uma categoria é renomeada)
+func (idx *Index) Rename(kind, oldText, newText string) {
+	oldKey := textutil.Fold(strings.TrimSpace(oldText))
+	newKey := textutil.Fold(strings.TrimSpace(newText))
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	old, ok := idx.entries[kind][oldKey]
+	if !ok || newKey == "" {
+		return
+	}
+	delete(idx.entries[kind], oldKey)
+
+	e, ok := idx.entries[kind][newKey]
+	if !ok {
+		e = &entry{key: newKey, words: textutil.Words(newKey)}
+		idx.entries[kind][newKey] = e
+	}
+	e.text = strings.TrimSpace(newText)
+	e.count += old.count
+}
+
+// Suggest retorna as sugestões para o prefixo digitado, das mais relevantes para as menos.
+// A relevância favorece, nesta ordem: prefixo do texto inteiro, prefixo de uma palavra,
+// menos erros de digitação e textos usados por mais produtos.
+func (idx *Index) Suggest(prefix string, opts Options) []Suggestion {
+	query := textutil.Fold(strings.TrimSpace(prefix))
+	if query == "" || opts.Limit <= 0 {
+		return nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var suggestions []Suggestion
+	for kind, entries := range idx.entries {
+		if opts.Kind != "" && opts.Kind != kind {
+			continue
+		}
+		for _, e := range entries {
+			score, ok := match(query, e, opts.MaxTypos)
+			if !ok {
+				continue
+			}
+			suggestions = append(suggestions, Suggestion{Text: e.text, Kind: kind, Count: e.count, Score: score})
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if len(a.Text) != len(b.Text) {
+			return len(a.Text) < len(b.Text)
+		}
+		return a.Text < b.Text
+	})
+
+	if len(suggestions) > opts.Limit {
+		suggestions = suggestions[:opts.Limit]
+	}
+	return suggestions
+}
+
+// match verifica se a entrada completa o texto digitado e calcula a relevância
+func match(query string, e *entry, maxTypos int) (float64, bool) {
+	// Bônus pela quantidade de produtos, limitado para não superar a qualidade do casamento
+	popularity := float64(e.count) / float64(e.count+10)
+
+	if strings.HasPrefix(e.key, query) {
+		return 3 + popularity, true
+	}
+	for _, word := range e.words {
+		if strings.HasPrefix(word, query) {
+			return 2 + popularity, true
+		}
+	}
+
+	// Tolerância a erros: textos curtos demais gerariam sugestões sem sentido
+	if maxTypos <= 0 || len([]rune(query)) <= maxTypos+1 {
+		return 0, false
+	}
+	best := maxTypos + 1
+	if d := prefixDistance(query, e.key); d < best {
+		best = d
+	}
+	for _, word := range e.words {
+		if d := prefixDistance(query, word); d < best {
+			best = d
+		}
+	}
+	if best > maxTypos {
+		return 0, false
+	}
+	return 1 - float64(best)/float64(maxTypos+1) + popularity, true
+}
+
+// prefixDistance é a menor distância de edição (Levenshtein) entre o texto
+// digitado e algum prefixo do texto indexado
+func prefixDistance(query, text string) int {
+	q, t := []rune(query), []rune(text)
+
+	// prev[j] = distância entre o trecho de query já processado e t[:j]
+	prev := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(q); i++ {
+		curr := make([]int, len(t)+1)
+		curr[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if q[i-1] == t[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+
+	// A distância até o melhor prefixo é o menor valor da última linha
+	best := prev[0]
+	for _, d := range prev {
+		if d < best {
+			best = d
+		}
+	}
+	return best
+}
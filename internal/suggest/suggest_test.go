@@ -0,0 +1,121 @@
+package suggest
+
+import (
+	"testing"
+)
+
+// texts retorna os textos das sugestões, na ordem
+func texts(suggestions []Suggestion) []string {
+	result := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		result[i] = s.Text
+	}
+	return result
+}
+
+func newTestIndex() *Index {
+	idx := NewIndex()
+	for _, name := range []string{"Mens Cotton Jacket", "Camisa Algodão", "Camiseta Básica", "Camiseta Básica", "Calça Jeans"} {
+		idx.Add(KindName, name)
+	}
+	idx.Add(KindCategory, "Camping")
+	return idx
+}
+
+func TestSuggestPrefix(t *testing.T) {
+	idx := newTestIndex()
+	tests := []struct {
+		prefix string
+		kind   string
+		want   []string
+	}{
+		// O prefixo do texto inteiro vem antes; no empate, o texto usado por mais produtos
+		{"cami", "", []string{"Camiseta Básica", "Camisa Algodão"}},
+		{"cam", KindName, []string{"Camiseta Básica", "Camisa Algodão"}},
+		{"cam", KindCategory, []string{"Camping"}},
+		// Prefixo de qualquer palavra, sem diferenciar maiúsculas e acentos
+		{"JACK", "", []string{"Mens Cotton Jacket"}},
+		{"algodao", "", []string{"Camisa Algodão"}},
+		{"basi", "", []string{"Camiseta Básica"}},
+		{"xyz", "", []string{}},
+		{"  ", "", []string{}},
+	}
+	for _, tt := range tests {
+		got := texts(idx.Suggest(tt.prefix, Options{Limit: 10, Kind: tt.kind}))
+		if len(got) != len(tt.want) {
+			t.Errorf("Suggest(%q, %q) = %q; esperado %q", tt.prefix, tt.kind, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Suggest(%q, %q) = %q; esperado %q", tt.prefix, tt.kind, got, tt.want)
+				break
+			}
+		}
+	}
+
+	if got := idx.Suggest("cam", Options{Limit: 1}); len(got) != 1 {
+		t.Errorf("Suggest com limite 1 retornou %d sugestões", len(got))
+	}
+	if got := idx.Suggest("camiseta", Options{Limit: 10}); len(got) != 1 || got[0].Count != 2 {
+		t.Errorf("Suggest(camiseta) = %+v; esperado uma sugestão usada por 2 produtos", got)
+	}
+}
+
+func TestSuggestTypos(t *testing.T) {
+	idx := newTestIndex()
+	tests := []struct {
+		prefix   string
+		maxTypos int
+		want     string // Vazio: nenhuma sugestão
+	}{
+		{"jakcet", 2, "Mens Cotton Jacket"},
+		{"jakcet", 1, ""},
+		{"calca jens", 1, "Calça Jeans"},
+		{"cottn", 1, "Mens Cotton Jacket"},
+		{"cottn", 0, ""},
+		// Textos curtos demais para tolerar erros
+		{"xa", 1, ""},
+	}
+	for _, tt := range tests {
+		got := idx.Suggest(tt.prefix, Options{Limit: 10, MaxTypos: tt.maxTypos, Kind: KindName})
+		if tt.want == "" {
+			if len(got) != 0 {
+				t.Errorf("Suggest(%q, typos=%d) = %q; esperado nenhuma sugestão", tt.prefix, tt.maxTypos, texts(got))
+			}
+			continue
+		}
+		if len(got) == 0 || got[0].Text != tt.want {
+			t.Errorf("Suggest(%q, typos=%d) = %q; esperado %q primeiro", tt.prefix, tt.maxTypos, texts(got), tt.want)
+		}
+	}
+
+	// O casamento exato vale mais que o casamento com erros
+	idx.Add(KindName, "Jakarta Bag")
+	got := idx.Suggest("jac", Options{Limit: 10, MaxTypos: 1, Kind: KindName})
+	if len(got) != 2 || got[0].Text != "Mens Cotton Jacket" || got[1].Text != "Jakarta Bag" {
+		t.Errorf("Suggest(jac) = %q; esperado [Mens Cotton Jacket, Jakarta Bag]", texts(got))
+	}
+}
+
+func TestIndexRemoveAndRename(t *testing.T) {
+	idx := newTestIndex()
+
+	// A entrada só some quando nenhum produto a usa mais
+	idx.Remove(KindName, "camiseta basica")
+	if got := idx.Suggest("camiseta", Options{Limit: 10}); len(got) != 1 || got[0].Count != 1 {
+		t.Errorf("depois de remover um uso: %+v", got)
+	}
+	idx.Remove(KindName, "Camiseta Básica")
+	if got := idx.Suggest("camiseta", Options{Limit: 10}); len(got) != 0 {
+		t.Errorf("depois de remover todos os usos: %+v", got)
+	}
+
+	idx.Rename(KindCategory, "Camping", "Acampamento")
+	if got := idx.Suggest("camp", Options{Limit: 10, Kind: KindCategory}); len(got) != 0 {
+		t.Errorf("nome antigo ainda sugerido: %+v", got)
+	}
+	if got := idx.Suggest("acamp", Options{Limit: 10, Kind: KindCategory}); len(got) != 1 || got[0].Text != "Acampamento" || got[0].Count != 1 {
+		t.Errorf("Suggest(acamp) = %+v", got)
+	}
+}
@@ -0,0 +1,73 @@
+// Package textutil reúne funções de normalização de texto usadas nas buscas
+// e nas comparações que devem ignorar maiúsculas e acentos.
+package textutil
+
+import (
+	"strings"
+	"unicode"
+)
+
+// foldTable mapeia letras latinas acentuadas para a versão sem acento em minúsculas
+var foldTable = map[rune]string{
+	'À': "a", 'Á': "a", 'Â': "a", 'Ã': "a", 'Ä': "a", 'Å': "a", 'Ç': "c", 'È': "e", 'É': "e",
+	'Ê': "e", 'Ë': "e", 'Ì': "i", 'Í': "i", 'Î': "i", 'Ï': "i", 'Ñ': "n", 'Ò': "o", 'Ó': "o",
+	'Ô': "o", 'Õ': "o", 'Ö': "o", 'Ù': "u", 'Ú': "u", 'Û': "u", 'Ü': "u", 'Ý': "y", 'à': "a",
+	'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ç': "c", 'è': "e", 'é': "e", 'ê': "e",
+	'ë': "e", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ñ': "n", 'ò': "o", 'ó': "o", 'ô': "o",
+	'õ': "o", 'ö': "o", 'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ý': "y", 'ÿ': "y", 'Ā': "a",
+	'ā': "a", 'Ă': "a", 'ă': "a", 'Ą': "a", 'ą': "a", 'Ć': "c", 'ć': "c", 'Ĉ': "c", 'ĉ': "c",
+	'Ċ': "c", 'ċ': "c", 'Č': "c", 'č': "c", 'Ď': "d", 'ď': "d", 'Ē': "e", 'ē': "e", 'Ĕ': "e",
+	'ĕ': "e", 'Ė': "e", 'ė': "e", 'Ę': "e", 'ę': "e", 'Ě': "e", 'ě': "e", 'Ĝ': "g", 'ĝ': "g",
+	'Ğ': "g", 'ğ': "g", 'Ġ': "g", 'ġ': "g", 'Ģ': "g", 'ģ': "g", 'Ĥ': "h", 'ĥ': "h", 'Ĩ': "i",
+	'ĩ': "i", 'Ī': "i", 'ī': "i", 'Ĭ': "i", 'ĭ': "i", 'Į': "i", 'į': "i", 'İ': "i", 'Ĵ': "j",
+	'ĵ': "j", 'Ķ': "k", 'ķ': "k", 'Ĺ': "l", 'ĺ': "l", 'Ļ': "l", 'ļ': "l", 'Ľ': "l", 'ľ': "l",
+	'Ń': "n", 'ń': "n", 'Ņ': "n", 'ņ': "n", 'Ň': "n", 'ň': "n", 'Ō': "o", 'ō': "o", 'Ŏ': "o",
+	'ŏ': "o", 'Ő': "o", 'ő': "o", 'Ŕ': "r", 'ŕ': "r", 'Ŗ': "r", 'ŗ': "r", 'Ř': "r", 'ř': "r",
+	'Ś': "s", 'ś': "s", 'Ŝ': "s", 'ŝ': "s", 'Ş': "s", 'ş': "s", 'Š': "s", 'š': "s", 'Ţ': "t",
+	'ţ': "t", 'Ť': "t", 'ť': "t", 'Ũ': "u", 'ũ': "u", 'Ū': "u", 'ū': "u", 'Ŭ': "u", 'ŭ': "u",
+	'Ů': "u", 'ů': "u", 'Ű': "u", 'ű': "u", 'Ų': "u", 'ų': "u", 'Ŵ': "w", 'ŵ': "w", 'Ŷ': "y",
+	'ŷ': "y", 'Ÿ': "y", 'Ź': "z", 'ź': "z", 'Ż': "z", 'ż': "z", 'Ž': "z", 'ž': "z",
+	'ß': "ss", 'æ': "ae", 'Æ': "ae", 'ø': "o", 'Ø': "o", 'œ': "oe", 'Œ': "oe", 'đ': "d", 'Đ': "d", 'ł': "l", 'Ł': "l",
+}
+
+// Fold converte o texto para minúsculas e remove os acentos,
+// de forma que "Camisa Algodão" e "camisa algodao" fiquem iguais.
+func Fold(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if folded, ok := foldTable[r]; ok {
+			b.WriteString(folded)
+			continue
+		}
+		if unicode.Is(unicode.Mn, r) {
+			continue // Acentos combinantes (texto já decomposto)
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// Words separa o texto em palavras formadas por letras e dígitos
+func Words(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Slug gera o identificador de URL do texto: palavras sem acentos, em minúsculas,
+// separadas por hífen ("Eletrônicos e Games" vira "eletronicos-e-games")
+func Slug(s string) string {
+	return strings.Join(Words(Fold(s)), "-")
+}
+
+// Clean remove os espaços do início e do fim e junta os espaços repetidos
+func Clean(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Key é a forma usada para comparar nomes: sem acentos, em minúsculas e com os
+// espaços normalizados ("  Camisa  Algodão" e "camisa algodao" têm a mesma chave)
+func Key(s string) string {
+	return Fold(Clean(s))
+}
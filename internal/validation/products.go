@@ -0,0 +1,88 @@
+package validation
+
+import "braip/internal/models"
+
+// Tamanhos máximos dos campos de texto
+const (
+	MaxNameLength        = 200
+	MaxDescriptionLength = 5000
+	MaxCategoryLength    = 100
+	MaxURLLength         = 2048
+)
+
+// Product valida os campos de um produto, retornando Errors com todos os campos inválidos.
+// O nome único não é verificado aqui: ele é garantido pelo repositório, que retorna
+// um conflito (repository.NameInUseError) com o produto que já usa o nome.
+func Product(p models.Product) error {
+	var v Validator
+
+	v.Required("name", p.Name)
+	v.MaxLength("name", p.Name, MaxNameLength)
+	v.Positive("price", p.Price)
+	v.Currency("currency", p.Currency)
+	v.Required("description", p.Description)
+	v.MaxLength("description", p.Description, MaxDescriptionLength)
+
+	// A categoria pode ser informada pelo ID ou pelo nome
+	if p.CategoryID == 0 {
+		v.Required("category", p.Category)
+		v.MaxLength("category", p.Category, MaxCategoryLength)
+	}
+
+	v.MaxLength("image_url", p.ImageURL, MaxURLLength)
+	if !v.Has("image_url") {
+		v.URL("image_url", p.ImageURL)
+	}
+
+	return v.Err()
+}
+
+// ProductPatch valida apenas os campos informados em uma alteração parcial,
+// com as mesmas regras de Product
+func ProductPatch(p models.ProductPatch) error {
+	var v Validator
+
+	if p.Name != nil {
+		v.Required("name", *p.Name)
+		v.MaxLength("name", *p.Name, MaxNameLength)
+	}
+	if p.Price != nil {
+		v.Positive("price", *p.Price)
+	}
+	if p.Currency != nil {
+		v.Currency("currency", *p.Currency)
+	}
+	if p.Description != nil {
+		v.Required("description", *p.Description)
+		v.MaxLength("description", *p.Description, MaxDescriptionLength)
+	}
+
+	if p.CategoryID != nil {
+		v.Positive("category_id", *p.CategoryID)
+	} else if p.Category != nil {
+		v.Required("category", *p.Category)
+		v.MaxLength("category", *p.Category, MaxCategoryLength)
+	}
+
+	if p.ImageURL != nil {
+		v.MaxLength("image_url", *p.ImageURL, MaxURLLength)
+		if !v.Has("image_url") {
+			v.URL("image_url", *p.ImageURL)
+		}
+	}
+
+	return v.Err()
+}
+
+// Category valida os campos de uma categoria
+func Category(c models.Category) error {
+	var v Validator
+
+	v.Required("name", c.Name)
+	v.MaxLength("name", c.Name, MaxCategoryLength)
+	if c.ParentID != nil && *c.ParentID <= 0 {
+		v.Add("parent_id", CodePositive, "deve ser maior que zero")
+	}
+
+	return v.Err()
+}
@@ -0,0 +1,115 @@
+// Package validation valida os dados recebidos pela API e pelo importador,
+// acumulando um erro por campo com um código estável que os clientes podem
+// tratar sem depender do texto da mensagem.
+package validation
+
+import (
+	"braip/internal/apperror"
+	"braip/internal/money"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// Códigos de erro de validação
+const (
+	CodeRequired  = "required"         // Campo obrigatório ausente ou vazio
+	CodeMaxLength = "max_length"       // Texto maior que o permitido
+	CodePositive  = "positive"         // Número deve ser maior que zero
+	CodeURL       = "invalid_url"      // URL não é absoluta com http ou https
+	CodeNotFound  = "not_found"        // Referência a um registro inexistente
+	CodeCurrency  = "invalid_currency" // Moeda fora da lista ISO 4217 aceita
+	CodeDecimal   = "invalid_decimal"  // Valor decimal malformado ou com casas decimais demais
+)
+
+// FieldError é o erro de validação de um campo
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors reúne os erros de validação de todos os campos
+type Errors []FieldError
+
+// Kind classifica os erros de validação para a camada api
+func (e Errors) Kind() apperror.Kind {
+	return apperror.KindValidation
+}
+
+// Error implementa a interface error
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + ": " + fe.Message
+	}
+	return "dados inválidos: " + strings.Join(parts, "; ")
+}
+
+// Validator acumula os erros das verificações feitas sobre os campos
+type Validator struct {
+	errs Errors
+}
+
+// Add registra um erro no campo
+func (v *Validator) Add(field, code, message string) {
+	v.errs = append(v.errs, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Has indica se o campo já tem algum erro registrado
+func (v *Validator) Has(field string) bool {
+	for _, fe := range v.errs {
+		if fe.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Required verifica se o texto foi preenchido (espaços não contam)
+func (v *Validator) Required(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.Add(field, CodeRequired, "campo obrigatório")
+	}
+}
+
+// MaxLength verifica se o texto tem no máximo max caracteres
+func (v *Validator) MaxLength(field, value string, max int) {
+	if utf8.RuneCountInString(value) > max {
+		v.Add(field, CodeMaxLength, fmt.Sprintf("deve ter no máximo %d caracteres", max))
+	}
+}
+
+// Positive verifica se o número é maior que zero
+func (v *Validator) Positive(field string, value int) {
+	if value <= 0 {
+		v.Add(field, CodePositive, "deve ser maior que zero")
+	}
+}
+
+// URL verifica se o texto, quando preenchido, é uma URL absoluta http ou https
+func (v *Validator) URL(field, value string) {
+	if value == "" {
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.Add(field, CodeURL, "deve ser uma URL absoluta http ou https")
+	}
+}
+
+// Currency verifica se o texto é o código ISO 4217 de uma moeda aceita
+func (v *Validator) Currency(field, value string) {
+	if !money.Valid(value) {
+		v.Add(field, CodeCurrency, "deve ser o código ISO 4217 de uma moeda aceita (ex.: BRL, USD, EUR)")
+	}
+}
+
+// Err retorna os erros acumulados ou nil se todos os campos são válidos
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
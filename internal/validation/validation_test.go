@@ -0,0 +1,133 @@
+package validation
+
+import (
+	"braip/internal/apperror"
+	"braip/internal/models"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// codes retorna os erros como "campo:código", na ordem em que foram registrados
+func codes(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("erro %T (%v); esperado Errors", err, err)
+	}
+	result := make([]string, len(errs))
+	for i, fe := range errs {
+		if fe.Message == "" {
+			t.Errorf("erro sem mensagem no campo %s", fe.Field)
+		}
+		result[i] = fe.Field + ":" + fe.Code
+	}
+	return result
+}
+
+func validProduct() models.Product {
+	return models.Product{Name: "Camisa", Price: 1990, Currency: "BRL", Description: "Algodão", Category: "Roupas", ImageURL: "https://example.com/c.png"}
+}
+
+func TestProduct(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(p *models.Product)
+		want   string
+	}{
+		{"válido", func(p *models.Product) {}, ""},
+		{"sem imagem", func(p *models.Product) { p.ImageURL = "" }, ""},
+		{"categoria pelo ID", func(p *models.Product) { p.Category, p.CategoryID = "", 3 }, ""},
+		{"nome no limite", func(p *models.Product) { p.Name = strings.Repeat("ã", MaxNameLength) }, ""},
+		{"nome vazio", func(p *models.Product) { p.Name = "   " }, "name:required"},
+		{"nome longo", func(p *models.Product) { p.Name = strings.Repeat("ã", MaxNameLength+1) }, "name:max_length"},
+		{"preço zero", func(p *models.Product) { p.Price = 0 }, "price:positive"},
+		{"preço negativo", func(p *models.Product) { p.Price = -1 }, "price:positive"},
+		{"moeda", func(p *models.Product) { p.Currency = "XYZ" }, "currency:invalid_currency"},
+		{"sem descrição", func(p *models.Product) { p.Description = "" }, "description:required"},
+		{"descrição longa", func(p *models.Product) { p.Description = strings.Repeat("a", MaxDescriptionLength+1) }, "description:max_length"},
+		{"sem categoria", func(p *models.Product) { p.Category = "" }, "category:required"},
+		{"categoria longa", func(p *models.Product) { p.Category = strings.Repeat("a", MaxCategoryLength+1) }, "category:max_length"},
+		{"URL relativa", func(p *models.Product) { p.ImageURL = "/img/c.png" }, "image_url:invalid_url"},
+		{"URL ftp", func(p *models.Product) { p.ImageURL = "ftp://example.com/c.png" }, "image_url:invalid_url"},
+		{"URL sem host", func(p *models.Product) { p.ImageURL = "https://" }, "image_url:invalid_url"},
+		// Um erro por campo: a URL longa não é verificada também como URL
+		{"URL longa", func(p *models.Product) { p.ImageURL = "ftp://" + strings.Repeat("a", MaxURLLength) }, "image_url:max_length"},
+	}
+	for _, tt := range tests {
+		p := validProduct()
+		tt.change(&p)
+		got := strings.Join(codes(t, Product(p)), ",")
+		if got != tt.want {
+			t.Errorf("%s: %s; esperado %q", tt.name, got, tt.want)
+		}
+	}
+
+	// Todos os campos inválidos são informados juntos, na ordem dos campos
+	got := codes(t, Product(models.Product{Currency: "BRL", ImageURL: "x"}))
+	want := []string{"name:required", "price:positive", "description:required", "category:required", "image_url:invalid_url"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("produto vazio: %v; esperado %v", got, want)
+	}
+}
+
+func TestProductPatch(t *testing.T) {
+	text := func(s string) *string { return &s }
+	number := func(n int) *int { return &n }
+
+	if err := ProductPatch(models.ProductPatch{}); err != nil {
+		t.Errorf("patch vazio: %v", err)
+	}
+	if err := ProductPatch(models.ProductPatch{Price: number(1), ImageURL: text("")}); err != nil {
+		t.Errorf("patch válido, removendo a imagem: %v", err)
+	}
+
+	got := codes(t, ProductPatch(models.ProductPatch{
+		Name:        text(""),
+		Price:       number(0),
+		Currency:    text("reais"),
+		Description: text(" "),
+		CategoryID:  number(-1),
+		Category:    text(""), // Ignorado: category_id tem precedência
+		ImageURL:    text("nada"),
+	}))
+	want := []string{"name:required", "price:positive", "currency:invalid_currency", "description:required", "category_id:positive", "image_url:invalid_url"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("patch inválido: %v; esperado %v", got, want)
+	}
+
+	if got := codes(t, ProductPatch(models.ProductPatch{Category: text("")})); strings.Join(got, ",") != "category:required" {
+		t.Errorf("patch com categoria vazia: %v", got)
+	}
+}
+
+func TestCategory(t *testing.T) {
+	zero := 0
+	tests := []struct {
+		category models.Category
+		want     string
+	}{
+		{models.Category{Name: "Roupas"}, ""},
+		{models.Category{Name: ""}, "name:required"},
+		{models.Category{Name: strings.Repeat("a", MaxCategoryLength+1)}, "name:max_length"},
+		{models.Category{Name: "Roupas", ParentID: &zero}, "parent_id:positive"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(codes(t, Category(tt.category)), ","); got != tt.want {
+			t.Errorf("Category(%+v) = %s; esperado %q", tt.category, got, tt.want)
+		}
+	}
+}
+
+func TestErrorsKind(t *testing.T) {
+	err := Product(models.Product{})
+	if apperror.KindOf(err) != apperror.KindValidation {
+		t.Errorf("KindOf = %v; esperado KindValidation", apperror.KindOf(err))
+	}
+	if !strings.HasPrefix(err.Error(), "dados inválidos: name: campo obrigatório") {
+		t.Errorf("Error() = %q", err.Error())
+	}
+}
@@ -3,42 +3,204 @@ package main
 import (
 	"log"
 	"braip/internal/database"
+	"braip/internal/database/migrations"
+	"database/sql"
+	"flag"
 	"fmt"
 	"net/http"
+	"os"
 	"github.com/gorilla/mux"
 	"braip/internal/api"
+	"braip/internal/money"
+	"braip/internal/repository"
+	"braip/internal/services"
+	"braip/internal/suggest"
+	"context"
 )
 
 func main() {
-	// Abrir conexão com o banco
-	_, err := db.OpenDB()
+	// Subcomandos: "migrate" gerencia o esquema do banco, "purge" esvazia a
+	// lixeira e "export" grava os produtos num arquivo, todos sem subir o servidor
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "purge" {
+		if err := runPurge(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := runExport(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Backend de armazenamento dos produtos
+	store := flag.String("store", "sql", "Backend de armazenamento dos produtos: sql ou memory")
+	databaseURL := flag.String("database-url", envOrDefault("DATABASE_URL", db.DefaultDSN), "DSN do banco: caminho do arquivo SQLite ou postgres://...")
+	suggestLimit := flag.Int("suggest-limit", 10, "Número máximo de sugestões retornadas pelo autocompletar")
+	autoMigrate := flag.Bool("auto-migrate", true, "Aplicar as migrações pendentes ao iniciar o servidor")
+	idempotencyTTL := flag.Duration("idempotency-ttl", services.DefaultIdempotencyTTL, "Por quanto tempo a resposta de um POST com Idempotency-Key é guardada para ser repetida")
+	exchangeRates := flag.String("exchange-rates", os.Getenv("EXCHANGE_RATES"), "Fonte das cotações para ?currency=: arquivo JSON ou URL http(s) (vazio desativa a conversão)")
+
+	// Configurações do pool de conexões
+	defaults := db.DefaultPoolConfig()
+	maxOpenConns := flag.Int("db-max-open-conns", defaults.MaxOpenConns, "Número máximo de conexões abertas com o banco")
+	maxIdleConns := flag.Int("db-max-idle-conns", defaults.MaxIdleConns, "Número máximo de conexões ociosas no pool")
+	connMaxLifetime := flag.Duration("db-conn-max-lifetime", defaults.ConnMaxLifetime, "Tempo máximo de vida de uma conexão")
+	flag.Parse()
+
+	productRepo, closeStore, err := openStore(*store, *databaseURL, *autoMigrate, db.PoolConfig{
+		MaxOpenConns:    *maxOpenConns,
+		MaxIdleConns:    *maxIdleConns,
+		ConnMaxLifetime: *connMaxLifetime,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeStore()
+
+	// Índice de sugestões do autocompletar, mantido pelo repositório a cada alteração
+	productRepo, err = repository.NewSuggestIndexedStore(context.Background(), productRepo, suggest.NewIndex(), *suggestLimit)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// Criar a tabela se necessário
-	db.CreateTable()
-	log.Println("Banco de dados e tabela criados com sucesso!")
+	// Cotações usadas na conversão de moeda das leituras (?currency=)
+	var rates money.RateProvider
+	if *exchangeRates != "" {
+		if rates, err = money.NewRateProvider(*exchangeRates); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Camadas da aplicação: repository -> services -> api
+	productService := services.NewProductService(productRepo)
+	productHandler := api.NewProductHandler(productService, services.NewPriceConverter(rates))
+	categoryService := services.NewCategoryService(productRepo)
+	categoryHandler := api.NewCategoryHandler(categoryService)
+	auditHandler := api.NewAuditHandler(services.NewAuditService(productRepo))
+	idempotency := api.NewIdempotencyHandler(services.NewIdempotencyService(productRepo, *idempotencyTTL))
 
 
 	// Rotas da API
 
 	r := mux.NewRouter()
+	r.NotFoundHandler = api.NotFoundHandler()
+	r.MethodNotAllowedHandler = api.MethodNotAllowedHandler()
 
-	// Rotas de consulta de produtos
-	r.HandleFunc("/products/{id}", api.GetProductByID).Methods("GET")											// OK
-	r.HandleFunc("/products/search/categoryandname", api.SearchProductsByNameAndCategory).Methods("GET")		// OK
-	r.HandleFunc("/products/search/category", api.SearchProductsByCategory).Methods("GET")						// OK
-	r.HandleFunc("/products/search/image", api.SearchProductsByImage).Methods("GET")							// OK
+	// Rotas de consulta de produtos (busca e sugestões vêm antes de /products/{id})
+	r.HandleFunc("/products/search", productHandler.SearchProducts).Methods("GET")
+	r.HandleFunc("/products/suggest", productHandler.SuggestProducts).Methods("GET")
+	r.HandleFunc("/products/trash", productHandler.GetTrash).Methods("GET")
+	r.HandleFunc("/products/price-drops", productHandler.GetPriceDrops).Methods("GET")
+	r.HandleFunc("/products/export", productHandler.ExportProducts).Methods("GET")
+	r.HandleFunc("/products/bulk", productHandler.CreateProductsBulk).Methods("POST")
+	r.HandleFunc("/products/bulk", productHandler.PatchProductsBulk).Methods("PATCH")
+	r.HandleFunc("/products/bulk", productHandler.DeleteProductsBulk).Methods("DELETE")
+	r.HandleFunc("/products/{id}", productHandler.GetProductByID).Methods("GET")											// OK
+	r.HandleFunc("/products/search/categoryandname", productHandler.SearchProductsByNameAndCategory).Methods("GET")		// OK
+	r.HandleFunc("/products/search/category", productHandler.SearchProductsByCategory).Methods("GET")						// OK
+	r.HandleFunc("/products/search/image", productHandler.SearchProductsByImage).Methods("GET")							// OK
 
 	//Demais rotas padrões do CRUD
-	r.HandleFunc("/products", api.CreateProduct).Methods("POST")								// OK
-	r.HandleFunc("/products", api.GetProducts).Methods("GET")									// OK
-	r.HandleFunc("/products/{id}", api.UpdateProduct).Methods("PUT")							// OK
-	r.HandleFunc("/products/{id}", api.DeleteProduct).Methods("DELETE")							// OK
+	r.HandleFunc("/products", idempotency.Wrap(productHandler.CreateProduct)).Methods("POST")								// OK
+	r.HandleFunc("/products", productHandler.GetProducts).Methods("GET")									// OK
+	r.HandleFunc("/products/{id}", productHandler.UpdateProduct).Methods("PUT")							// OK
+	r.HandleFunc("/products/{id}", productHandler.PatchProduct).Methods("PATCH")
+	r.HandleFunc("/products/{id}", productHandler.DeleteProduct).Methods("DELETE")							// OK
+	r.HandleFunc("/products/{id}/restore", productHandler.RestoreProduct).Methods("POST")
+
+	// Rotas da auditoria e do histórico de preços de produtos
+	r.HandleFunc("/products/{id}/history", auditHandler.GetProductHistory).Methods("GET")
+	r.HandleFunc("/products/{id}/prices", productHandler.GetPriceHistory).Methods("GET")
+	r.HandleFunc("/audit", auditHandler.GetAudit).Methods("GET")
+
+	// Rotas de categorias
+	r.HandleFunc("/categories", categoryHandler.GetCategories).Methods("GET")
+	r.HandleFunc("/categories", categoryHandler.CreateCategory).Methods("POST")
+	r.HandleFunc("/categories/{id}", categoryHandler.GetCategoryByID).Methods("GET")
+	r.HandleFunc("/categories/{id}", categoryHandler.UpdateCategory).Methods("PUT")
+	r.HandleFunc("/categories/{id}", categoryHandler.DeleteCategory).Methods("DELETE")
 
 	fmt.Println("Servidor rodando na porta 4000...")
-	log.Fatal(http.ListenAndServe(":4000", r))
+	// O ID da requisição envolve todo o roteador, inclusive as rotas inexistentes;
+	// o autor (X-Actor) usado na auditoria é identificado logo depois dele, e a versão
+	// das respostas de produtos (Accept: ...; version=N) por último
+	log.Fatal(http.ListenAndServe(":4000", api.RequestIDMiddleware(api.ActorMiddleware(api.ResponseVersionMiddleware(r)))))
+
+
+}
 
+// openStore cria o backend de armazenamento escolhido na inicialização.
+// No backend sql, o banco (SQLite ou PostgreSQL) é escolhido pela DSN.
+// A função retornada libera os recursos do backend ao encerrar o servidor.
+func openStore(backend, dsn string, autoMigrate bool, pool db.PoolConfig) (repository.ProductStore, func(), error) {
+	switch backend {
+	case "memory":
+		log.Println("Usando armazenamento em memória: os dados serão perdidos ao encerrar o servidor")
+		return repository.NewMemoryProductRepository(), func() {}, nil
 
-}
\ No newline at end of file
+	case "sql":
+		// Abrir o pool de conexões com o banco (compartilhado por toda a aplicação)
+		driver := db.DriverFromDSN(dsn)
+		conn, err := db.OpenDB(dsn, pool)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := prepareSchema(conn, driver, autoMigrate); err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+
+		repo, err := repository.NewSQLProductRepository(conn, driver)
+		if err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+		return repo, func() { conn.Close() }, nil
+
+	default:
+		return nil, nil, fmt.Errorf("backend de armazenamento desconhecido: %q", backend)
+	}
+}
+
+// prepareSchema aplica as migrações pendentes ou, com a migração automática
+// desligada, apenas avisa se o esquema do banco estiver desatualizado
+func prepareSchema(conn *sql.DB, driver string, autoMigrate bool) error {
+	migrator, err := migrations.New(conn, driver)
+	if err != nil {
+		return err
+	}
+
+	if autoMigrate {
+		if _, err := migrator.Up(); err != nil {
+			return err
+		}
+		log.Printf("Banco de dados (%s) pronto na versão %d do esquema", driver, migrator.Latest())
+		return nil
+	}
+
+	version, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+	if version < migrator.Latest() {
+		log.Printf("Atenção: o esquema está na versão %d, mas a mais recente é %d. Execute \"migrate up\".", version, migrator.Latest())
+	}
+	return nil
+}
+
+// envOrDefault lê uma variável de ambiente, usando o valor padrão se ela estiver vazia
+func envOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
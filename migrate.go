@@ -0,0 +1,94 @@
+package main
+
+import (
+	"braip/internal/database"
+	"braip/internal/database/migrations"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+// runMigrate implementa o subcomando "migrate":
+//
+//	migrate [--database-url=...] status|up|down|to <versão>
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	databaseURL := fs.String("database-url", envOrDefault("DATABASE_URL", db.DefaultDSN), "DSN do banco: caminho do arquivo SQLite ou postgres://...")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Uso: migrate [--database-url=...] status|up|down|to <versão>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("informe uma ação de migração")
+	}
+
+	driver := db.DriverFromDSN(*databaseURL)
+	conn, err := db.OpenDB(*databaseURL, db.PoolConfig{MaxOpenConns: 1, MaxIdleConns: 1})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	migrator, err := migrations.New(conn, driver)
+	if err != nil {
+		return err
+	}
+
+	switch action := fs.Arg(0); action {
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSÃO\tNOME\tSITUAÇÃO")
+		for _, s := range statuses {
+			situation := "pendente"
+			if s.Applied {
+				situation = "aplicada em " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, situation)
+		}
+		return w.Flush()
+
+	case "up":
+		count, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d migração(ões) aplicada(s)\n", count)
+
+	case "down":
+		if err := migrator.Down(); err != nil {
+			return err
+		}
+
+	case "to":
+		if fs.NArg() < 2 {
+			return fmt.Errorf("informe a versão de destino: migrate to <versão>")
+		}
+		target, err := strconv.Atoi(fs.Arg(1))
+		if err != nil || target < 0 {
+			return fmt.Errorf("versão inválida: %q", fs.Arg(1))
+		}
+		if _, err := migrator.To(target); err != nil {
+			return err
+		}
+
+	default:
+		fs.Usage()
+		return fmt.Errorf("ação de migração desconhecida: %q", action)
+	}
+
+	version, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Versão atual do esquema: %d\n", version)
+	return nil
+}
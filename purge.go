@@ -0,0 +1,54 @@
+package main
+
+import (
+	"braip/internal/audit"
+	"braip/internal/database"
+	"braip/internal/repository"
+	"braip/internal/services"
+	"context"
+	"flag"
+	"fmt"
+	"time"
+)
+
+// defaultRetention é por quanto tempo os produtos ficam na lixeira antes de poderem ser removidos
+const defaultRetention = 30 * 24 * time.Hour
+
+// runPurge implementa o subcomando "purge", que remove de vez os produtos que
+// estão na lixeira há mais tempo que o período de retenção:
+//
+//	purge [--database-url=...] [--retention=720h]
+func runPurge(args []string) error {
+	fs := flag.NewFlagSet("purge", flag.ExitOnError)
+	databaseURL := fs.String("database-url", envOrDefault("DATABASE_URL", db.DefaultDSN), "DSN do banco: caminho do arquivo SQLite ou postgres://...")
+	retention := fs.Duration("retention", defaultRetention, "Tempo mínimo na lixeira antes da remoção definitiva")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Uso: purge [--database-url=...] [--retention=720h]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *retention < 0 {
+		return fmt.Errorf("o período de retenção não pode ser negativo")
+	}
+
+	conn, err := db.OpenDB(*databaseURL, db.PoolConfig{MaxOpenConns: 1, MaxIdleConns: 1})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	repo, err := repository.NewSQLProductRepository(conn, db.DriverFromDSN(*databaseURL))
+	if err != nil {
+		return err
+	}
+
+	// As remoções ficam registradas na auditoria em nome do usuário que executou o comando
+	ctx := audit.WithActor(context.Background(), audit.Actor{Name: envOrDefault("USER", "system"), Source: audit.SourceCLI})
+	purged, err := services.NewProductService(repo).PurgeTrash(ctx, *retention)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d produto(s) removido(s) da lixeira (na lixeira há mais de %s)\n", purged, *retention)
+	return nil
+}